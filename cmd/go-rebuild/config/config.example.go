@@ -1,6 +1,8 @@
 package appcore_config
 
 // import (
+// 	"time"
+
 // 	"github.com/spf13/viper"
 // )
 
@@ -25,6 +27,16 @@ package appcore_config
 // 	PostgresConnString string
 // 	MongoConnString    string
 
+// 	//database pool
+// 	PostgresMaxOpenConns    int
+// 	PostgresMaxIdleConns    int
+// 	PostgresConnMaxLifetime time.Duration
+// 	PostgresConnMaxIdleTime time.Duration
+// 	MongoMaxPoolSize        uint64
+// 	MongoMinPoolSize        uint64
+// 	MongoMaxConnIdleTime    time.Duration
+// 	DBQueryTimeout          time.Duration
+
 // 	//Redis
 // 	RedisUrl  string
 // 	RedisPass string
@@ -32,16 +44,67 @@ package appcore_config
 // 	// Message broker (rabbitmq)
 // 	RabbitmqUrl  string
 
-// 	//Storage
+// 	// Stock reservation
+// 	StockReservationTTL           time.Duration
+// 	StockReservationSweepInterval time.Duration
+
+// 	// Stock allocation (MOST_STOCK or NEAREST)
+// 	StockAllocationStrategy string
+// 	StockDefaultRegion      string
+
+// 	//Storage (MINIO or LOCAL)
+// 	StorageDriver      string
+// 	StorageLocalDir    string
+// 	StorageLocalURL    string
 // 	MinioURL           string
 // 	MinioSSL           bool
+// 	MinioRegion        string
 // 	MinioAccessKey     string
 // 	MinioSecretKey     string
 // 	MinioBucketName    string
 // 	MinioECMBucketName string
 
+// 	// Product images
+// 	ProductImageMaxSize   int64
+// 	ProductImageMaxPixels int
+// 	ProductThumbnailSize  int
+
+// 	// Product prices
+// 	PriceSchedulerInterval time.Duration
+// 	DefaultCurrency        string
+
+// 	// Cart
+// 	CartTTL time.Duration
+
+// 	// Payment, FAKE auto-captures, outside develop mode it needs PaymentAllowFake
+// 	PaymentProvider      string
+// 	PaymentWebhookSecret string
+// 	PaymentAllowFake     bool
+
+// 	// Invoice PDF, a TrueType font with the scripts of the shop, Thai included
+// 	InvoiceFontFile     string
+// 	InvoiceFontBoldFile string
+
 // 	SecretKey string
 
+// 	// Auth tokens
+// 	AccessTokenTTL  time.Duration
+// 	RefreshTokenTTL time.Duration
+
+// 	// JWT signing, RS256 or EdDSA by the key. To rotate, add the new public key
+// 	// to JWTVerifyKeyFiles, then sign with it, then drop the old one once the
+// 	// tokens it signed have expired. Only develop mode runs without a key file.
+// 	JWTSigningKeyFile string
+// 	JWTVerifyKeyFiles string // comma separated PEM public keys
+// 	JWTIssuer         string
+// 	JWTAudience       string
+
+// 	// Email verification, links point at PublicURL
+// 	EmailVerificationRequired       bool
+// 	EmailVerificationTTL            time.Duration
+// 	EmailVerificationResendInterval time.Duration
+// 	PublicURL                       string
+
 // 	// ENV
 // 	ENVIRONMENT string
 
@@ -63,13 +126,49 @@ package appcore_config
 // 	viper.SetDefault("OBSERVE_OTLP_ENDPOINT", )
 // 	viper.SetDefault("OBSERVE_INSECURE_MODE",  )
 
+// 	viper.SetDefault("POSTGRES_MAX_OPEN_CONNS", 25)
+// 	viper.SetDefault("POSTGRES_MAX_IDLE_CONNS", 10)
+// 	viper.SetDefault("POSTGRES_CONN_MAX_LIFETIME", "30m")
+// 	viper.SetDefault("POSTGRES_CONN_MAX_IDLE_TIME", "5m")
+// 	viper.SetDefault("MONGO_MAX_POOL_SIZE", 100)
+// 	viper.SetDefault("MONGO_MIN_POOL_SIZE", 0)
+// 	viper.SetDefault("MONGO_MAX_CONN_IDLE_TIME", "5m")
+// 	viper.SetDefault("DB_QUERY_TIMEOUT", "5s")
+
+// 	viper.SetDefault("STOCK_RESERVATION_TTL", "15m")
+// 	viper.SetDefault("STOCK_RESERVATION_SWEEP_INTERVAL", "1m")
+// 	viper.SetDefault("STOCK_ALLOCATION_STRATEGY", "MOST_STOCK")
+// 	viper.SetDefault("STOCK_DEFAULT_REGION", "")
+
+// 	viper.SetDefault("STORAGE_DRIVER", "LOCAL")
+// 	viper.SetDefault("STORAGE_LOCAL_DIR", "./uploads")
+// 	viper.SetDefault("STORAGE_LOCAL_URL", "/uploads")
 // 	viper.SetDefault("MINIO_URL", )
+// 	viper.SetDefault("MINIO_REGION", "us-east-1")
 // 	viper.SetDefault("MINIO_SSL", )
 // 	viper.SetDefault("MINIO_ACCESS_KEY", )
 // 	viper.SetDefault("MINIO_SECRET_KEY", )
 // 	viper.SetDefault("MINIO_BUCKET_NAME", )
 // 	viper.SetDefault("MINIO_ECM_BUCKET_NAME", )
 
+// 	viper.SetDefault("PRODUCT_IMAGE_MAX_SIZE", 5<<20)
+// 	viper.SetDefault("PRODUCT_IMAGE_MAX_PIXELS", 40_000_000)
+// 	viper.SetDefault("PRODUCT_THUMBNAIL_SIZE", 320)
+// 	viper.SetDefault("PRICE_SCHEDULER_INTERVAL", "1m")
+// 	viper.SetDefault("DEFAULT_CURRENCY", "THB")
+// 	viper.SetDefault("CART_TTL", "168h")
+// 	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+// 	viper.SetDefault("REFRESH_TOKEN_TTL", "720h")
+// 	viper.SetDefault("JWT_SIGNING_KEY_FILE", )
+// 	viper.SetDefault("JWT_VERIFY_KEY_FILES", )
+// 	viper.SetDefault("JWT_ISSUER", "auth-service")
+// 	viper.SetDefault("JWT_AUDIENCE", "go-rebuild")
+// 	viper.SetDefault("EMAIL_VERIFICATION_REQUIRED", false)
+// 	viper.SetDefault("EMAIL_VERIFICATION_TTL", "24h")
+// 	viper.SetDefault("EMAIL_VERIFICATION_RESEND_INTERVAL", "1m")
+// 	viper.SetDefault("PUBLIC_URL", "http://localhost:3000")
+// 	viper.SetDefault("PAYMENT_PROVIDER", "FAKE")
+
 // 	Config = &Configurations{
 // 		Mode:                viper.GetString("MODE"),
 // 		GinIsReleaseMode:    viper.GetBool("GIN_IS_RELEASE_MODE"),
@@ -79,16 +178,53 @@ package appcore_config
 // 		ObserveInsecureMode: viper.GetString("OBSERVE_INSECURE_MODE"),
 // 		PostgresConnString:  viper.GetString("POSTGRES_URL"),
 // 		MongoConnString:     viper.GetString("MONGO_URL"),
+// 		PostgresMaxOpenConns:    viper.GetInt("POSTGRES_MAX_OPEN_CONNS"),
+// 		PostgresMaxIdleConns:    viper.GetInt("POSTGRES_MAX_IDLE_CONNS"),
+// 		PostgresConnMaxLifetime: viper.GetDuration("POSTGRES_CONN_MAX_LIFETIME"),
+// 		PostgresConnMaxIdleTime: viper.GetDuration("POSTGRES_CONN_MAX_IDLE_TIME"),
+// 		MongoMaxPoolSize:        viper.GetUint64("MONGO_MAX_POOL_SIZE"),
+// 		MongoMinPoolSize:        viper.GetUint64("MONGO_MIN_POOL_SIZE"),
+// 		MongoMaxConnIdleTime:    viper.GetDuration("MONGO_MAX_CONN_IDLE_TIME"),
+// 		DBQueryTimeout:          viper.GetDuration("DB_QUERY_TIMEOUT"),
 // 		RedisUrl:            viper.GetString("REDIS_URL"),
 // 		RedisPass:           viper.GetString("REDIS_PASS"),
 // 		RabbitmqUrl:         viper.GetString("Rabbitmq_URL"),
+// 		StockReservationTTL:           viper.GetDuration("STOCK_RESERVATION_TTL"),
+// 		StockReservationSweepInterval: viper.GetDuration("STOCK_RESERVATION_SWEEP_INTERVAL"),
+// 		StockAllocationStrategy:       viper.GetString("STOCK_ALLOCATION_STRATEGY"),
+// 		StockDefaultRegion:            viper.GetString("STOCK_DEFAULT_REGION"),
+// 		StorageDriver:       viper.GetString("STORAGE_DRIVER"),
+// 		StorageLocalDir:     viper.GetString("STORAGE_LOCAL_DIR"),
+// 		StorageLocalURL:     viper.GetString("STORAGE_LOCAL_URL"),
 // 		MinioURL:            viper.GetString("MINIO_URL"),
 // 		MinioSSL:            viper.GetBool("MINIO_SSL"),
+// 		MinioRegion:         viper.GetString("MINIO_REGION"),
 // 		MinioAccessKey:      viper.GetString("MINIO_ACCESS_KEY"),
 // 		MinioSecretKey:      viper.GetString("MINIO_SECRET_KEY"),
 // 		MinioBucketName:     viper.GetString("MINIO_BUCKET_NAME"),
 // 		MinioECMBucketName:  viper.GetString("MINIO_ECM_BUCKET_NAME"),
+// 		ProductImageMaxSize:   viper.GetInt64("PRODUCT_IMAGE_MAX_SIZE"),
+// 		ProductImageMaxPixels: viper.GetInt("PRODUCT_IMAGE_MAX_PIXELS"),
+// 		ProductThumbnailSize:  viper.GetInt("PRODUCT_THUMBNAIL_SIZE"),
+// 		PriceSchedulerInterval: viper.GetDuration("PRICE_SCHEDULER_INTERVAL"),
+// 		DefaultCurrency:        viper.GetString("DEFAULT_CURRENCY"),
+// 		CartTTL:                viper.GetDuration("CART_TTL"),
+// 		PaymentProvider:      viper.GetString("PAYMENT_PROVIDER"),
+// 		PaymentWebhookSecret: viper.GetString("PAYMENT_WEBHOOK_SECRET"),
+// 		PaymentAllowFake:     viper.GetBool("PAYMENT_ALLOW_FAKE"),
+// 		InvoiceFontFile:     viper.GetString("INVOICE_FONT_FILE"),
+// 		InvoiceFontBoldFile: viper.GetString("INVOICE_FONT_BOLD_FILE"),
 // 		SecretKey:           viper.GetString("SECRET_KEY"),
+// 		AccessTokenTTL:      viper.GetDuration("ACCESS_TOKEN_TTL"),
+// 		RefreshTokenTTL:     viper.GetDuration("REFRESH_TOKEN_TTL"),
+// 		JWTSigningKeyFile:   viper.GetString("JWT_SIGNING_KEY_FILE"),
+// 		JWTVerifyKeyFiles:   viper.GetString("JWT_VERIFY_KEY_FILES"),
+// 		JWTIssuer:           viper.GetString("JWT_ISSUER"),
+// 		JWTAudience:         viper.GetString("JWT_AUDIENCE"),
+// 		EmailVerificationRequired:       viper.GetBool("EMAIL_VERIFICATION_REQUIRED"),
+// 		EmailVerificationTTL:            viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+// 		EmailVerificationResendInterval: viper.GetDuration("EMAIL_VERIFICATION_RESEND_INTERVAL"),
+// 		PublicURL:                       viper.GetString("PUBLIC_URL"),
 // 		ENVIRONMENT:         viper.GetString("ENVIRONMENT"),
 // 		EmailSTMPHost:       viper.GetString("EMAIL_SMTP_HOST"),
 // 		EmailSMTPPort:       viper.GetString("EMAIL_SMTP_PORT"),
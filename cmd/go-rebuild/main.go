@@ -8,25 +8,50 @@ import (
 	"go-rebuild/internal/db"
 	"go-rebuild/internal/handler"
 	"go-rebuild/internal/handler/api"
+	"go-rebuild/internal/invoice"
 	"go-rebuild/internal/mail"
 	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
+	"go-rebuild/internal/payment"
 	"go-rebuild/internal/realtime"
+	"go-rebuild/internal/storage"
+	"go-rebuild/internal/tax"
 
+	cartSvc "go-rebuild/internal/module/cart"
+	categorySvc "go-rebuild/internal/module/category"
+	couponSvc "go-rebuild/internal/module/coupon"
+	invoiceSvc "go-rebuild/internal/module/invoice"
 	messageSvc "go-rebuild/internal/module/message"
 	orderSvc "go-rebuild/internal/module/order"
+	paymentSvc "go-rebuild/internal/module/payment"
 	productSvc "go-rebuild/internal/module/product"
+	reportSvc "go-rebuild/internal/module/report"
+	returnSvc "go-rebuild/internal/module/returns"
+	reviewSvc "go-rebuild/internal/module/review"
+	shipmentSvc "go-rebuild/internal/module/shipment"
 	stockSvc "go-rebuild/internal/module/stock"
+	taxSvc "go-rebuild/internal/module/tax"
 	userSvc "go-rebuild/internal/module/user"
+	warehouseSvc "go-rebuild/internal/module/warehouse"
+	cartRepo "go-rebuild/internal/repository/cart"
+	categoryRepo "go-rebuild/internal/repository/category"
+	couponRepo "go-rebuild/internal/repository/coupon"
 	messageRepo "go-rebuild/internal/repository/message"
 	orderRepo "go-rebuild/internal/repository/order"
+	paymentRepo "go-rebuild/internal/repository/payment"
 	productRepo "go-rebuild/internal/repository/product"
+	returnRepo "go-rebuild/internal/repository/returns"
+	reviewRepo "go-rebuild/internal/repository/review"
+	shipmentRepo "go-rebuild/internal/repository/shipment"
 	stockRepo "go-rebuild/internal/repository/stock"
+	taxRepo "go-rebuild/internal/repository/tax"
 	userRepo "go-rebuild/internal/repository/user"
+	warehouseRepo "go-rebuild/internal/repository/warehouse"
 
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -48,6 +73,7 @@ var (
 func main() {
 	// ------------------------------ Setup Config ------------------------------
 	appcore_config.InitConfigurations()
+	model.DefaultCurrency = appcore_config.Config.DefaultCurrency
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -74,6 +100,9 @@ func main() {
 			log.Panic("fail to connect mongodb: ", err)
 		}
 
+		if err := db.MigrateMongo(initMongoCtx, mgDBInstant, "miniproject"); err != nil {
+			log.Fatal(err)
+		}
 		dbRepo = db.NewMongoRepo(mgDBInstant, "miniproject")
 
 	} else {
@@ -99,6 +128,61 @@ func main() {
 	mailClient := mail.InitSMTP()
 	mailService := mail.NewMailService(mailClient)
 
+	// init object storage
+	var objectStorage storage.Storage
+	if appcore_config.Config.StorageDriver == "MINIO" {
+		objectStorage = storage.NewS3Storage(
+			appcore_config.Config.MinioURL,
+			appcore_config.Config.MinioSSL,
+			appcore_config.Config.MinioRegion,
+			appcore_config.Config.MinioBucketName,
+			appcore_config.Config.MinioAccessKey,
+			appcore_config.Config.MinioSecretKey,
+		)
+	} else {
+		objectStorage, err = storage.NewLocalStorage(appcore_config.Config.StorageLocalDir, appcore_config.Config.StorageLocalURL)
+		if err != nil {
+			log.Fatalf("Failed to init local storage: %v", err)
+		}
+		router.Static(appcore_config.Config.StorageLocalURL, appcore_config.Config.StorageLocalDir)
+	}
+
+	// init payment provider, the fake one captures every payment so outside
+	// develop it runs only when PAYMENT_ALLOW_FAKE opts in, e.g. on staging
+	var paymentProvider payment.Provider
+	switch appcore_config.Config.PaymentProvider {
+	case "FAKE":
+		if appcore_config.Config.Mode != "develop" {
+			if !appcore_config.Config.PaymentAllowFake {
+				log.Fatalf("Payment provider FAKE takes no real money, set PAYMENT_ALLOW_FAKE=true to run it in %s mode", appcore_config.Config.Mode)
+			}
+			log.Warnf("Payment provider FAKE is running in %s mode, every payment is captured without money", appcore_config.Config.Mode)
+		}
+		paymentProvider = payment.NewFake(appcore_config.Config.PaymentWebhookSecret)
+	default:
+		log.Fatalf("Unsupported payment provider: %s", appcore_config.Config.PaymentProvider)
+	}
+
+	// init invoice fonts
+	if appcore_config.Config.InvoiceFontFile != "" {
+		if invoice.Font, err = invoice.LoadFont(appcore_config.Config.InvoiceFontFile); err != nil {
+			log.Fatalf("Failed to load invoice font: %v", err)
+		}
+		if appcore_config.Config.InvoiceFontBoldFile != "" {
+			if invoice.BoldFont, err = invoice.LoadFont(appcore_config.Config.InvoiceFontBoldFile); err != nil {
+				log.Fatalf("Failed to load invoice bold font: %v", err)
+			}
+		}
+	} else {
+		log.Warn("INVOICE_FONT_FILE is not set, invoice PDFs only print Latin-1 text")
+	}
+
+	// init jwt keys
+	jwtKeys, err := auth.LoadKeySet(appcore_config.Config.JWTSigningKeyFile, strings.Split(appcore_config.Config.JWTVerifyKeyFiles, ","), appcore_config.Config.Mode == "develop")
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
 	// init websocket
 	websocketServer := realtime.NewWebSocketServer()
 
@@ -107,6 +191,7 @@ func main() {
 	producerChannel := messagebroker.OpenChannel(rabbitMQConn)
 	userConsumeChannel := messagebroker.OpenChannel(rabbitMQConn)
 	stockConsumeChannel := messagebroker.OpenChannel(rabbitMQConn)
+	notificationConsumeChannel := messagebroker.OpenChannel(rabbitMQConn)
 
 	if err := messagebroker.SetupExchangeAndQueue(userConsumeChannel, &model.MQConfig{
 		ExchangeName: messagebroker.UserExchangeName,
@@ -126,45 +211,134 @@ func main() {
 		log.Fatalf("Failed to setup stock exchange and queue: %v", err)
 	}
 
+	if err := messagebroker.SetupExchangeAndQueue(notificationConsumeChannel, &model.MQConfig{
+		ExchangeName: messagebroker.NotificationExchangeName,
+		ExchangeType: messagebroker.NotificationExchangeType,
+		QueueName:    messagebroker.NotificationQueueName,
+		RoutingKey:   "#",
+	}); err != nil {
+		log.Fatalf("Failed to setup notification exchange and queue: %v", err)
+	}
+
 	// ------------------------------ Start service ------------------------------
 	// Repository
 	userRepository := userRepo.NewUserRepo(dbRepo, cacheSvc)
+	addressRepository := userRepo.NewAddressRepo(dbRepo)
+	wishlistRepository := userRepo.NewWishlistRepo(dbRepo)
+	tokenRepository := userRepo.NewTokenRepo(cacheSvc)
 	ProductRepository := productRepo.NewProductRepo(dbRepo, cacheSvc)
+	productVariantRepository := productRepo.NewProductVariantRepo(dbRepo)
+	productImageRepository := productRepo.NewProductImageRepo(dbRepo)
+	priceChangeRepository := productRepo.NewPriceChangeRepo(dbRepo)
+	reviewRepository := reviewRepo.NewReviewRepo(dbRepo)
+	productRatingRepository := reviewRepo.NewProductRatingRepo(dbRepo)
+	categoryRepository := categoryRepo.NewCategoryRepo(dbRepo, cacheSvc)
+	productCategoryRepository := categoryRepo.NewProductCategoryRepo(dbRepo)
 	orderRepository := orderRepo.NewOrderRepo(dbRepo, cacheSvc)
+	salesReportRepository := orderRepo.NewSalesReportRepo(dbRepo)
+	paymentRepository := paymentRepo.NewPaymentRepo(dbRepo)
+	paymentEventRepository := paymentRepo.NewPaymentEventRepo(dbRepo)
+	returnRepository := returnRepo.NewReturnRepo(dbRepo)
+	shipmentRepository := shipmentRepo.NewShipmentRepo(dbRepo)
+	cartRepository := cartRepo.NewCartRepo(cacheSvc, appcore_config.Config.CartTTL)
+	couponRepository := couponRepo.NewCouponRepo(dbRepo)
+	couponRedemptionRepository := couponRepo.NewCouponRedemptionRepo(dbRepo)
+	taxRuleRepository := taxRepo.NewTaxRuleRepo(dbRepo)
 	stockRepository := stockRepo.NewStockRepo(dbRepo, cacheSvc)
+	stockMovementRepository := stockRepo.NewStockMovementRepo(dbRepo)
+	stockSubscriptionRepository := stockRepo.NewStockSubscriptionRepo(dbRepo)
+	stockReservationRepository := stockRepo.NewStockReservationRepo(dbRepo)
+	warehouseStockRepository := stockRepo.NewWarehouseStockRepo(dbRepo)
+	warehouseRepository := warehouseRepo.NewWarehouseRepo(dbRepo)
 	messageRepository := messageRepo.NewMessageRepo(dbRepo, cacheSvc)
 
 	// Service
-	stockService := stockSvc.NewStockService(stockRepository)
 	producerService := messagebroker.NewProducer(producerChannel)
-	consumerService := messagebroker.NewConsumer(userConsumeChannel, stockConsumeChannel, mailService, stockService)
+	stockService := stockSvc.NewStockService(dbRepo, stockRepository, stockMovementRepository, stockSubscriptionRepository, stockReservationRepository, warehouseRepository, warehouseStockRepository, ProductRepository, productVariantRepository, producerService)
+	userService := userSvc.NewUserService(userRepository, tokenRepository, producerService)
+	addressService := userSvc.NewAddressService(addressRepository)
+	authService := auth.NewAuthService(jwtKeys, userService, producerService, tokenRepository)
+	productService := productSvc.NewProductService(dbRepo, ProductRepository, productVariantRepository, productImageRepository, productRatingRepository, priceChangeRepository, stockRepository, stockService, producerService, objectStorage)
+	invoiceService := invoiceSvc.NewInvoiceService(orderRepository, productService, userService, mailService)
+	wishlistService := userSvc.NewWishlistService(wishlistRepository, productService)
+	consumerService := messagebroker.NewConsumer(userConsumeChannel, stockConsumeChannel, notificationConsumeChannel, messagebroker.ConsumerDeps{
+		MailSvc:     mailService,
+		StockSvc:    stockService,
+		UserSvc:     userService,
+		ProductSvc:  productService,
+		InvoiceSvc:  invoiceService,
+		WishlistSvc: wishlistService,
+		Notifier:    websocketServer,
+	})
 	mqBroker := messagebroker.NewMessageBroker(producerService, consumerService)
-	userService := userSvc.NewUserService(userRepository, producerService)
-	authService := auth.NewAuthService(userService, producerService)
-	productSvc := productSvc.NewProductService(ProductRepository, producerService)
-	orderService := orderSvc.NewOrderService(orderRepository, productSvc, producerService)
+	couponService := couponSvc.NewCouponService(dbRepo, couponRepository, couponRedemptionRepository)
+	taxCalculator := tax.NewRulesCalculator(taxRuleRepository, categoryRepository, productCategoryRepository)
+	taxRuleService := taxSvc.NewTaxRuleService(taxRuleRepository, categoryRepository)
+	orderService := orderSvc.NewOrderService(orderRepository, productService, stockService, couponService, addressService, taxCalculator, producerService)
+	cartService := cartSvc.NewCartService(cartRepository, productService, stockService, orderService)
+	paymentService := paymentSvc.NewPaymentService(paymentRepository, paymentEventRepository, orderRepository, stockService, paymentProvider, producerService)
+	returnService := returnSvc.NewReturnService(returnRepository, orderRepository, productService, stockService, paymentService, userService, mailService)
+	shipmentService := shipmentSvc.NewShipmentService(shipmentRepository, orderRepository, productService, producerService)
+	reportService := reportSvc.NewReportService(orderRepository, salesReportRepository, userService)
 	messageService := messageSvc.NewMessageService(messageRepository)
+	warehouseService := warehouseSvc.NewWarehouseService(warehouseRepository, warehouseStockRepository)
+	reviewService := reviewSvc.NewReviewService(reviewRepository, productRatingRepository, ProductRepository, orderRepository)
+	categoryService := categorySvc.NewCategoryService(categoryRepository, productCategoryRepository, productService)
 	liveChat := realtime.NewLiveChat(websocketServer, messageService, authService)
 
 	// Handler
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, cartService, userService)
 	userHandler := handler.NewUserHandler(userService)
-	productHandler := handler.NewProductHandler(productSvc)
+	productHandler := handler.NewProductHandler(productService)
 	orderHandler := handler.NewOrderHandler(orderService)
+	cartHandler := handler.NewCartHandler(cartService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	returnHandler := handler.NewReturnHandler(returnService)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+	addressHandler := handler.NewAddressHandler(addressService)
+	wishlistHandler := handler.NewWishlistHandler(wishlistService)
 	stockHandler := handler.NewStockHandler(stockService)
 	messageHandler := handler.NewMessageHandler(liveChat, messageService)
+	sellerHandler := handler.NewSellerHandler(productService, stockService, reportService)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	couponHandler := handler.NewCouponHandler(couponService)
+	taxHandler := handler.NewTaxHandler(taxRuleService)
+	healthHandler := handler.NewHealthHandler(dbRepo)
 
 	// API
-	api.RegisterAuthAPI(router, authHandler)
+	api.RegisterAuthAPI(router, authHandler, authService)
 	api.RegisterUserAPI(router, userHandler, authService)
 	api.RegisterProductAPI(router, productHandler, authService)
 	api.RegisterOrderAPI(router, orderHandler, authService)
+	api.RegisterCartAPI(router, cartHandler, authService)
+	api.RegisterPaymentAPI(router, paymentHandler, authService)
+	api.RegisterReturnAPI(router, returnHandler, authService)
+	api.RegisterShipmentAPI(router, shipmentHandler, authService)
+	api.RegisterInvoiceAPI(router, invoiceHandler, authService)
+	api.RegisterAddressAPI(router, addressHandler, authService)
+	api.RegisterWishlistAPI(router, wishlistHandler, authService)
 	api.RegisterStockAPI(router, stockHandler, authService)
 	api.RegisterMessageAPI(router, messageHandler, authService)
+	api.RegisterSellerAPI(router, sellerHandler, authService)
+	api.RegisterWarehouseAPI(router, warehouseHandler, authService)
+	api.RegisterCategoryAPI(router, categoryHandler, authService)
+	api.RegisterReviewAPI(router, reviewHandler, authService)
+	api.RegisterCouponAPI(router, couponHandler, authService)
+	api.RegisterTaxAPI(router, taxHandler, authService)
+	api.RegisterHealthAPI(router, healthHandler)
 
 	// start consume
 	go mqBroker.EmailConsuming(messagebroker.UserQueueName, "user_consume")
 	go mqBroker.StockConsuming(messagebroker.StockQueueName, "stock_consume")
+	go mqBroker.NotificationConsuming(messagebroker.NotificationQueueName, "notification_consume")
+
+	// cancel unpaid orders once their reservation expires, apply scheduled prices once due
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	go orderSvc.StartReservationSweeper(sweeperCtx, orderService, appcore_config.Config.StockReservationSweepInterval)
+	go productSvc.StartPriceScheduler(sweeperCtx, productService, appcore_config.Config.PriceSchedulerInterval)
 
 	// ------------------------------ Start server ------------------------------
 	server := &http.Server{
@@ -191,6 +365,7 @@ func main() {
 	log.Info("[Signal]: shutdown signal received")
 
 	// call shutdown all service
+	stopSweeper()
 	gracefulShutdown(shutdownCtx, server)
 
 }
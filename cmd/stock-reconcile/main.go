@@ -0,0 +1,111 @@
+// stock-reconcile replays the stock movement ledger and reports every stock
+// total and warehouse row whose quantity drifted from it. Run with -apply to
+// write the ledger quantity back. Stock created before the ledger has no
+// opening entry and is skipped, a ledger quantity under what is reserved is
+// reported but kept.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
+	redisclient "go-rebuild/internal/cache"
+	"go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	stockSvc "go-rebuild/internal/module/stock"
+	productRepo "go-rebuild/internal/repository/product"
+	stockRepo "go-rebuild/internal/repository/stock"
+	warehouseRepo "go-rebuild/internal/repository/warehouse"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "write the ledger quantity back to drifted stocks")
+	useMongo := flag.Bool("mongo", false, "reconcile the mongodb database instead of postgres")
+	flag.Parse()
+
+	appcore_config.InitConfigurations()
+	model.DefaultCurrency = appcore_config.Config.DefaultCurrency
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// ------------------------------ Init db ------------------------------
+	var dbRepo db.DB
+	if *useMongo {
+		mgDB, err := db.InitMongoDB(ctx)
+		if err != nil {
+			log.Fatal("fail to connect mongodb: ", err)
+		}
+		defer mgDB.Disconnect(context.Background())
+		dbRepo = db.NewMongoRepo(mgDB, "miniproject")
+	} else {
+		pgDB, err := db.InitPsqlDB()
+		if err != nil {
+			log.Fatal("fail to connect psqldb: ", err)
+		}
+		dbRepo, err = db.NewPsqlRepo(pgDB)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// stock writes invalidate the cached stock, so -apply needs redis as well
+	redisClient := redisclient.InitRedisClient(appcore_config.Config.RedisUrl, appcore_config.Config.RedisPass)
+	defer redisClient.Close()
+	cacheSvc := redisclient.NewCacheService(redisClient)
+
+	// reconcile only resets quantities, it never crosses into alerts, so it
+	// runs without a message broker
+	stockService := stockSvc.NewStockService(
+		dbRepo,
+		stockRepo.NewStockRepo(dbRepo, cacheSvc),
+		stockRepo.NewStockMovementRepo(dbRepo),
+		stockRepo.NewStockSubscriptionRepo(dbRepo),
+		stockRepo.NewStockReservationRepo(dbRepo),
+		warehouseRepo.NewWarehouseRepo(dbRepo),
+		stockRepo.NewWarehouseStockRepo(dbRepo),
+		productRepo.NewProductRepo(dbRepo, cacheSvc),
+		productRepo.NewProductVariantRepo(dbRepo),
+		nil,
+	)
+
+	// ------------------------------ Reconcile ------------------------------
+	drifts, err := stockService.Reconcile(ctx, *apply)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRODUCT_ID\tWAREHOUSE_ID\tQUANTITY\tRESERVED\tLEDGER\tDRIFT\t")
+	kept := 0
+	for _, d := range drifts {
+		warehouseID := d.WarehouseID
+		if warehouseID == "" {
+			warehouseID = "(total)"
+		}
+		note := ""
+		if d.Kept {
+			note = "kept, ledger under reserved"
+			kept++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%+d\t%s\n", d.ProductID, warehouseID, d.Quantity, d.Reserved, d.LedgerQuantity, d.Drift, note)
+	}
+	w.Flush()
+
+	if err != nil {
+		log.Fatal("reconcile stopped: ", err)
+	}
+
+	switch {
+	case len(drifts) == 0:
+		fmt.Println("no drift found")
+	case *apply:
+		fmt.Printf("%d drift(s) reset to the ledger quantity, %d kept\n", len(drifts)-kept, kept)
+	default:
+		fmt.Printf("%d drift(s) found, rerun with -apply to fix\n", len(drifts))
+		os.Exit(1)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -23,41 +24,65 @@ var (
 	ErrSendWelcomeEmail = errors.New("failed to send welcome email")
 	ErrInternalServer   = errors.New("internal server error")
 	ErrVerifyToken      = errors.New("token verification failed")
+	ErrTokenRevoked     = errors.New("token is revoked")
+	ErrLogout           = errors.New("failed to log out")
 )
 
 type authService struct {
-	secretKey   string
+	keys        *KeySet
+	issuer      string
+	audience    string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	verifyEmail bool
 	userSvc     module.UserService
 	producerSvc messagebroker.ProducerService
+	tokenRepo   repository.TokenRepository
 }
 
-func NewAuthService(userSvc module.UserService, producerSvc messagebroker.ProducerService) Jwt {
+func NewAuthService(keys *KeySet, userSvc module.UserService, producerSvc messagebroker.ProducerService, tokenRepo repository.TokenRepository) Jwt {
 	return &authService{
-		secretKey:   appcore_config.Config.SecretKey,
+		keys:        keys,
+		issuer:      appcore_config.Config.JWTIssuer,
+		audience:    appcore_config.Config.JWTAudience,
+		accessTTL:   appcore_config.Config.AccessTokenTTL,
+		refreshTTL:  appcore_config.Config.RefreshTokenTTL,
+		verifyEmail: appcore_config.Config.EmailVerificationRequired,
 		userSvc:     userSvc,
 		producerSvc: producerSvc,
+		tokenRepo:   tokenRepo,
 	}
 }
 
-func (a *authService) GenerateToken(user *model.User) (*string, error) {
+// GenerateToken signs an access token of the session, its jti lets it be
+// revoked before it expires
+func (a *authService) GenerateToken(user *model.User, sessionID string) (*string, error) {
 	var baseLogFileds = log.Fields{
 		"user_id":   user.ID,
 		"layer":     "auth_service",
 		"operation": "verifyToken",
 	}
 
+	jti, err := randomHex(16)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFileds)
+		return nil, ErrCreateToken
+	}
+
 	claims := model.Claims{
-		Email: user.Email,
+		Email:     user.Email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "auth-service",
+			Issuer:    a.issuer,
+			Audience:  jwt.ClaimStrings{a.audience},
 			Subject:   user.ID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(appcore_config.Config.SecretKey))
+	tokenStr, err := a.keys.Sign(claims)
 	if err != nil {
 		log.WithError(err).WithFields(baseLogFileds)
 		return nil, ErrCreateToken
@@ -73,10 +98,14 @@ func (a *authService) VerifyToken(tokenStr string) (*model.Claims, error) {
 		"operation": "verifyToken",
 	}
 
+	// only the algorithms of our keys, and only tokens we issued for us
 	claims := &model.Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(appcore_config.Config.SecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, claims, a.keys.Keyfunc,
+		jwt.WithValidMethods(a.keys.Methods()),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithAudience(a.audience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		log.WithError(err).WithFields(baseLogFileds)
@@ -88,10 +117,38 @@ func (a *authService) VerifyToken(tokenStr string) (*model.Claims, error) {
 		return nil, ErrVerifyToken
 	}
 
+	// a logged out token is refused, and so is every token while the
+	// denylist cannot be read
+	revoked, err := a.tokenRepo.IsTokenRevoked(context.Background(), claims.ID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFileds).Error("check revoked token")
+		return nil, ErrVerifyToken
+	}
+	if revoked {
+		log.WithError(ErrTokenRevoked).WithFields(baseLogFileds)
+		return nil, ErrVerifyToken
+	}
+
+	// a token of a session that was logged out or revoked for reuse goes with it
+	if claims.SessionID != "" {
+		var session model.Session
+		if err := a.tokenRepo.GetSession(context.Background(), claims.SessionID, &session); err != nil {
+			if !errors.Is(err, model.ErrRefreshToken) {
+				log.WithError(err).WithFields(baseLogFileds).Error("check session")
+			}
+			return nil, ErrVerifyToken
+		}
+	}
+
 	return claims, nil
 }
 
-func (a *authService) Login(ctx context.Context, user *model.User) (*string, error) {
+// JWKS is the public keys tokens are verified with, for other services
+func (a *authService) JWKS() *JWKS {
+	return a.keys.JWKS()
+}
+
+func (a *authService) Login(ctx context.Context, user *model.User) (*model.TokenResp, error) {
 	log.Info("login in auth service call")
 	var baseLogFileds = log.Fields{
 		"user_id":   user.ID,
@@ -115,14 +172,20 @@ func (a *authService) Login(ctx context.Context, user *model.User) (*string, err
 		return nil, ErrInvalidCredentials
 	}
 
-	tokenStr, err := a.GenerateToken(exisUser)
+	// checked after the password, so it does not tell who has an account
+	if a.verifyEmail && !exisUser.EmailVerified {
+		log.WithError(model.ErrEmailNotVerified).WithFields(baseLogFileds)
+		return nil, model.ErrEmailNotVerified
+	}
+
+	tokens, err := a.startSession(ctx, exisUser)
 	if err != nil {
 		log.WithError(err).WithFields(baseLogFileds)
 		return nil, ErrInternalServer
 	}
 
 	log.Info("login in auth service call before return")
-	return tokenStr, nil
+	return tokens, nil
 
 }
 
@@ -7,11 +7,14 @@ import (
 
 
 type Jwt interface {
-	Login(ctx context.Context, user *model.User) (*string, error)  
+	Login(ctx context.Context, user *model.User) (*model.TokenResp, error)
 	Register(ctx context.Context, user *model.User) error
 	GetRoleUserByID(ctx context.Context, userID string) (*string, error)
-	GenerateToken(user *model.User) (*string, error)
+	GenerateToken(user *model.User, sessionID string) (*string, error)
+	Refresh(ctx context.Context, refreshToken string) (*model.TokenResp, error)
+	Logout(ctx context.Context, refreshToken string, claims *model.Claims) error
 	
 	VerifyToken(token string) (*model.Claims, error)
+	JWKS() *JWKS
 	CheckAllowRoles(userID string, allowedRoles []string) bool
 }
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrKeyFile    = errors.New("key file must hold one PEM encoded RSA or Ed25519 key")
+	ErrKeySize    = errors.New("RSA keys must be at least 2048 bits")
+	ErrUnknownKey = errors.New("token is signed with an unknown key")
+	ErrNoKeyFile  = errors.New("a JWT signing key file is required outside develop mode")
+)
+
+const minRSABits = 2048
+
+// verifyKey is a public key tokens are checked with, named by its kid
+type verifyKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// KeySet signs tokens with one private key and verifies them with every key
+// it knows, so a key can be added before it signs and dropped after the last
+// token it signed expires
+type KeySet struct {
+	signingKID    string
+	signingMethod jwt.SigningMethod
+	signingKey    crypto.Signer
+	keys          map[string]*verifyKey
+	order         []string
+}
+
+// JWK is a public key as published in the JWKS
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ------------------------ Constructor ------------------------
+// LoadKeySet reads the PEM private key tokens are signed with and the PEM
+// public keys still accepted. Without a signing key file a key is generated
+// when allowGenerated, in develop mode, its tokens stop verifying on restart
+// and on every other instance. Otherwise that is ErrNoKeyFile.
+func LoadKeySet(signingKeyFile string, verifyKeyFiles []string, allowGenerated bool) (*KeySet, error) {
+	var signer crypto.Signer
+	if signingKeyFile == "" {
+		if !allowGenerated {
+			return nil, ErrNoKeyFile
+		}
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		log.Warn("[Auth]: no JWT signing key file, using a generated key")
+		signer = key
+	} else {
+		key, err := readPrivateKey(signingKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", signingKeyFile, err)
+		}
+		signer = key
+	}
+
+	ks := &KeySet{keys: make(map[string]*verifyKey)}
+	signing, err := ks.add(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	ks.signingKID = signing.kid
+	ks.signingMethod = signing.method
+	ks.signingKey = signer
+
+	for _, file := range verifyKeyFiles {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		public, err := readPublicKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("verify key %s: %w", file, err)
+		}
+		if _, err := ks.add(public); err != nil {
+			return nil, fmt.Errorf("verify key %s: %w", file, err)
+		}
+	}
+
+	log.Infof("[Auth]: signing tokens with %s key %s, %d verification keys", ks.signingMethod.Alg(), ks.signingKID, len(ks.order))
+	return ks, nil
+}
+
+// ------------------------ Public Method ------------------------
+// Sign signs the claims with the current key and names it in the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signingMethod, claims)
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc finds the key named by the kid header, a token signed with another
+// algorithm than its key's is refused
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// Methods are the algorithms of the known keys, every other alg is refused
+// before a key is looked up
+func (ks *KeySet) Methods() []string {
+	var methods []string
+	seen := make(map[string]bool)
+	for _, kid := range ks.order {
+		if alg := ks.keys[kid].method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWKS is every verification key, the signing key first
+func (ks *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		jwks.Keys = append(jwks.Keys, ks.keys[kid].jwk())
+	}
+	return jwks
+}
+
+// ------------------------ Private Method ------------------------
+// add keeps a public key under its thumbprint, a key given twice is kept once
+func (ks *KeySet) add(public crypto.PublicKey) (*verifyKey, error) {
+	key := &verifyKey{public: public}
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, ErrKeySize
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrKeyFile
+	}
+	key.kid = key.thumbprint()
+
+	if existing, ok := ks.keys[key.kid]; ok {
+		return existing, nil
+	}
+	ks.keys[key.kid] = key
+	ks.order = append(ks.order, key.kid)
+	return key, nil
+}
+
+func (k *verifyKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(public.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(public)
+	}
+	return jwk
+}
+
+// thumbprint is the RFC 7638 thumbprint of the key, used as its kid so the
+// same key always has the same kid on every instance
+func (k *verifyKey) thumbprint() string {
+	jwk := k.jwk()
+	var members string
+	if jwk.Kty == "RSA" {
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	} else {
+		members = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64(sum[:])
+}
+
+func readPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, ErrKeyFile
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, ErrKeyFile
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, ErrKeyFile
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrKeyFile
+	}
+	return block, nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
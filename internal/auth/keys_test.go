@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLoadKeySetErrors(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	smallPrivate := writePEM(t, dir, "small.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small))
+	smallPublic := writePEM(t, dir, "small.pub", "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&small.PublicKey))
+
+	tests := []struct {
+		name           string
+		signingKeyFile string
+		verifyKeyFiles []string
+		allowGenerated bool
+		wantErr        error
+	}{
+		{name: "no key file outside develop", wantErr: ErrNoKeyFile},
+		{name: "no key file in develop", allowGenerated: true},
+		{name: "small RSA signing key", signingKeyFile: smallPrivate, wantErr: ErrKeySize},
+		{name: "small RSA verify key", verifyKeyFiles: []string{smallPublic}, allowGenerated: true, wantErr: ErrKeySize},
+		{name: "not a PEM file", signingKeyFile: writeFile(t, dir, "junk.pem", "junk"), wantErr: ErrKeyFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeySet(tt.signingKeyFile, tt.verifyKeyFiles, tt.allowGenerated); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyfunc(t *testing.T) {
+	ks, err := LoadKeySet("", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := LoadKeySet("", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.RegisteredClaims{Subject: "u1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+	signed, err := ks.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := other.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   *jwt.Token
+		wantErr error
+	}{
+		{name: "known kid", token: &jwt.Token{Method: jwt.SigningMethodEdDSA, Header: map[string]any{"kid": ks.signingKID}}},
+		{name: "unknown kid", token: &jwt.Token{Method: jwt.SigningMethodEdDSA, Header: map[string]any{"kid": other.signingKID}}, wantErr: ErrUnknownKey},
+		{name: "no kid", token: &jwt.Token{Method: jwt.SigningMethodEdDSA, Header: map[string]any{}}, wantErr: ErrUnknownKey},
+		{name: "alg of another key type", token: &jwt.Token{Method: jwt.SigningMethodRS256, Header: map[string]any{"kid": ks.signingKID}}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "hmac with the public key", token: &jwt.Token{Method: jwt.SigningMethodHS256, Header: map[string]any{"kid": ks.signingKID}}, wantErr: jwt.ErrTokenSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ks.Keyfunc(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// the same through a full parse
+	if _, err := jwt.Parse(signed, ks.Keyfunc, jwt.WithValidMethods(ks.Methods())); err != nil {
+		t.Errorf("own token: %v", err)
+	}
+	if _, err := jwt.Parse(foreign, ks.Keyfunc, jwt.WithValidMethods(ks.Methods())); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("foreign token: err = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+// The RSA example of RFC 7638 section 3.1 and a generated Ed25519 key, the kid
+// is worked out again from the members the RFC names.
+func TestJWKSKidIsThumbprint(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := LoadKeySet("", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfcKey, err := ks.add(&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; rfcKey.kid != want {
+		t.Errorf("RFC 7638 example kid = %s, want %s", rfcKey.kid, want)
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != ks.signingKID {
+		t.Fatalf("JWKS = %+v, want the signing key first and the RFC key", jwks.Keys)
+	}
+	for _, jwk := range jwks.Keys {
+		// json.Marshal sorts map keys, the order RFC 7638 asks for
+		members := map[string]string{"kty": jwk.Kty}
+		if jwk.Kty == "RSA" {
+			members["n"], members["e"] = jwk.N, jwk.E
+		} else {
+			members["crv"], members["x"] = jwk.Crv, jwk.X
+		}
+		data, err := json.Marshal(members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		if want := base64.RawURLEncoding.EncodeToString(sum[:]); jwk.Kid != want {
+			t.Errorf("%s kid = %s, want %s", jwk.Kty, jwk.Kid, want)
+		}
+	}
+}
+
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	t.Helper()
+	return writeFile(t, dir, name, string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})))
+}
+
+func writeFile(t *testing.T, dir string, name string, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-rebuild/internal/model"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Refresh trades a refresh token for a new access token and a new refresh
+// token. The old one is spent, using it again revokes the whole session, so a
+// stolen token stops working as soon as either party refreshes. The rotation
+// only goes through while the session still holds the token presented, two
+// refreshes racing with one token count as reuse.
+func (a *authService) Refresh(ctx context.Context, refreshToken string) (*model.TokenResp, error) {
+	var baseLogFileds = log.Fields{
+		"layer":     "auth_service",
+		"operation": "refresh",
+	}
+
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, model.ErrRefreshToken
+	}
+	baseLogFileds["session_id"] = sessionID
+
+	var session model.Session
+	if err := a.tokenRepo.GetSession(ctx, sessionID, &session); err != nil {
+		if !errors.Is(err, model.ErrRefreshToken) {
+			log.WithError(err).WithFields(baseLogFileds).Error("get session")
+		}
+		return nil, model.ErrRefreshToken
+	}
+
+	if !session.Matches(hashToken(refreshToken)) {
+		a.revokeReused(ctx, &session, baseLogFileds)
+		return nil, model.ErrRefreshReused
+	}
+
+	user, err := a.userSvc.GetByID(ctx, session.UserID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFileds).Error("get user")
+		return nil, model.ErrRefreshToken
+	}
+
+	tokens, err := a.issueTokens(ctx, user, &session, session.TokenHash)
+	if errors.Is(err, model.ErrRefreshReused) {
+		a.revokeReused(ctx, &session, baseLogFileds)
+		return nil, err
+	}
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFileds).Error("issue tokens")
+		return nil, ErrInternalServer
+	}
+	return tokens, nil
+}
+
+// Logout revokes the session of the refresh token and denies the access
+// token until it expires, either one of them is enough
+func (a *authService) Logout(ctx context.Context, refreshToken string, claims *model.Claims) error {
+	var baseLogFileds = log.Fields{
+		"layer":     "auth_service",
+		"operation": "logout",
+	}
+
+	if refreshToken == "" && claims == nil {
+		return model.ErrLogoutToken
+	}
+
+	if claims != nil {
+		baseLogFileds["user_id"] = claims.Subject
+		if ttl := time.Until(claims.Expiry()); claims.ID != "" && ttl > 0 {
+			if err := a.tokenRepo.RevokeToken(ctx, claims.ID, ttl); err != nil {
+				log.WithError(err).WithFields(baseLogFileds).Error("revoke access token")
+				return ErrLogout
+			}
+		}
+		if claims.SessionID != "" {
+			if err := a.tokenRepo.DeleteSession(ctx, claims.SessionID); err != nil {
+				log.WithError(err).WithFields(baseLogFileds).Error("delete session")
+				return ErrLogout
+			}
+		}
+	}
+
+	if refreshToken != "" {
+		sessionID, ok := parseRefreshToken(refreshToken)
+		if !ok {
+			return model.ErrRefreshToken
+		}
+
+		var session model.Session
+		if err := a.tokenRepo.GetSession(ctx, sessionID, &session); err != nil {
+			// already gone with the access token or expired
+			if errors.Is(err, model.ErrRefreshToken) && claims != nil {
+				return nil
+			}
+			return model.ErrRefreshToken
+		}
+		if !session.Matches(hashToken(refreshToken)) {
+			return model.ErrRefreshToken
+		}
+		if err := a.tokenRepo.DeleteSession(ctx, session.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFileds).Error("delete session")
+			return ErrLogout
+		}
+	}
+
+	log.WithFields(baseLogFileds).Info("[Service]: logout success")
+	return nil
+}
+
+// ------------------------ Private Method ------------------------
+// startSession opens a session for a user who just signed in
+func (a *authService) startSession(ctx context.Context, user *model.User) (*model.TokenResp, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}
+	return a.issueTokens(ctx, user, session, "")
+}
+
+// issueTokens rotates the refresh token of the session and signs a new access
+// token. The session ttl starts again, a session used within it never ends.
+// from is the token hash a refresh found in the session, the rotation is
+// model.ErrRefreshReused when the session no longer holds it.
+func (a *authService) issueTokens(ctx context.Context, user *model.User, session *model.Session, from string) (*model.TokenResp, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	refreshToken := session.ID + "." + base64.RawURLEncoding.EncodeToString(secret)
+
+	session.TokenHash = hashToken(refreshToken)
+	session.RotatedAt = time.Now()
+	if from == "" {
+		if err := a.tokenRepo.SaveSession(ctx, session, a.refreshTTL); err != nil {
+			return nil, err
+		}
+	} else {
+		rotated, err := a.tokenRepo.RotateSession(ctx, session, from, a.refreshTTL)
+		if err != nil {
+			return nil, err
+		}
+		if !rotated {
+			return nil, model.ErrRefreshReused
+		}
+	}
+
+	accessToken, err := a.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenResp{
+		Token:        *accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    model.TokenTypeBearer,
+		ExpiresIn:    int(a.accessTTL.Seconds()),
+	}, nil
+}
+
+// revokeReused ends a session whose refresh token was used twice, every access
+// token of it is refused from then on
+func (a *authService) revokeReused(ctx context.Context, session *model.Session, fields log.Fields) {
+	if err := a.tokenRepo.DeleteSession(ctx, session.ID); err != nil {
+		log.WithError(err).WithFields(fields).Error("revoke reused session")
+	}
+	log.WithFields(fields).WithField("user_id", session.UserID).Warn("refresh token reused, session revoked")
+}
+
+// parseRefreshToken takes the session id out of a "<session id>.<secret>" token
+func parseRefreshToken(token string) (string, bool) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", false
+	}
+	return sessionID, true
+}
+
+// hashToken is what is stored of a refresh token, the token itself never is
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	a, tokens := newTestAuth(t)
+
+	first, err := a.startSession(ctx, &model.User{ID: "u1", Email: "u1@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := a.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	if _, err := a.VerifyToken(second.Token); err != nil {
+		t.Fatalf("new access token: %v", err)
+	}
+
+	third, err := a.Refresh(ctx, second.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+	if len(tokens.sessions) != 1 {
+		t.Errorf("sessions = %d, want 1", len(tokens.sessions))
+	}
+	if _, err := a.VerifyToken(third.Token); err != nil {
+		t.Fatalf("latest access token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	a, tokens := newTestAuth(t)
+
+	first, err := a.startSession(ctx, &model.User{ID: "u1", Email: "u1@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the spent token comes back, from whoever stole it or from the owner
+	if _, err := a.Refresh(ctx, first.RefreshToken); !errors.Is(err, model.ErrRefreshReused) {
+		t.Fatalf("reuse: err = %v, want %v", err, model.ErrRefreshReused)
+	}
+	if len(tokens.sessions) != 0 {
+		t.Fatalf("sessions = %d after reuse, want the session revoked", len(tokens.sessions))
+	}
+	if _, err := a.Refresh(ctx, second.RefreshToken); !errors.Is(err, model.ErrRefreshToken) {
+		t.Errorf("refresh after revoke: err = %v, want %v", err, model.ErrRefreshToken)
+	}
+	if _, err := a.VerifyToken(second.Token); !errors.Is(err, ErrVerifyToken) {
+		t.Errorf("access token of the revoked session: err = %v, want %v", err, ErrVerifyToken)
+	}
+}
+
+// Two refreshes with one token that both read the session before either
+// rotates it: only one rotation goes through, the other counts as reuse.
+func TestRefreshRace(t *testing.T) {
+	ctx := context.Background()
+	a, tokens := newTestAuth(t)
+
+	first, err := a.startSession(ctx, &model.User{ID: "u1", Email: "u1@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raced error
+	tokens.beforeRotate = func() {
+		tokens.beforeRotate = nil
+		_, raced = a.Refresh(ctx, first.RefreshToken)
+	}
+	if _, err := a.Refresh(ctx, first.RefreshToken); !errors.Is(err, model.ErrRefreshReused) {
+		t.Fatalf("err = %v, want %v", err, model.ErrRefreshReused)
+	}
+	if raced != nil {
+		t.Fatalf("first refresh to rotate: %v", raced)
+	}
+	if len(tokens.sessions) != 0 {
+		t.Errorf("sessions = %d, want the session revoked", len(tokens.sessions))
+	}
+}
+
+func TestRefreshInvalidToken(t *testing.T) {
+	ctx := context.Background()
+	a, _ := newTestAuth(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no secret part", token: "abc"},
+		{name: "no session id", token: ".secret"},
+		{name: "empty secret", token: "abc."},
+		{name: "unknown session", token: "abc.secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Refresh(ctx, tt.token); !errors.Is(err, model.ErrRefreshToken) {
+				t.Errorf("err = %v, want %v", err, model.ErrRefreshToken)
+			}
+		})
+	}
+}
+
+// ------------------------ Fakes ------------------------
+func newTestAuth(t *testing.T) (*authService, *fakeTokenRepo) {
+	t.Helper()
+	keys, err := LoadKeySet("", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := &fakeTokenRepo{sessions: make(map[string]model.Session), revoked: make(map[string]bool)}
+	return &authService{
+		keys:       keys,
+		issuer:     "go-rebuild",
+		audience:   "go-rebuild",
+		accessTTL:  time.Minute,
+		refreshTTL: time.Hour,
+		userSvc:    &fakeUserService{},
+		tokenRepo:  tokens,
+	}, tokens
+}
+
+// fakeUserService knows every user id, a method the tests don't expect the
+// service to call panics through the embedded interface
+type fakeUserService struct {
+	module.UserService
+}
+
+func (s *fakeUserService) GetByID(ctx context.Context, id string) (*model.User, error) {
+	return &model.User{ID: id, Email: id + "@example.com"}, nil
+}
+
+// fakeTokenRepo keeps sessions in memory, RotateSession compares and sets
+// like the redis script. beforeRotate runs once a refresh read the session.
+type fakeTokenRepo struct {
+	repository.TokenRepository
+	sessions     map[string]model.Session
+	revoked      map[string]bool
+	beforeRotate func()
+}
+
+func (r *fakeTokenRepo) SaveSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeTokenRepo) RotateSession(ctx context.Context, session *model.Session, from string, ttl time.Duration) (bool, error) {
+	if r.beforeRotate != nil {
+		r.beforeRotate()
+	}
+	current, ok := r.sessions[session.ID]
+	if !ok || current.TokenHash != from {
+		return false, nil
+	}
+	r.sessions[session.ID] = *session
+	return true, nil
+}
+
+func (r *fakeTokenRepo) DeleteSession(ctx context.Context, id string) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *fakeTokenRepo) GetSession(ctx context.Context, id string, session *model.Session) error {
+	found, ok := r.sessions[id]
+	if !ok {
+		return model.ErrRefreshToken
+	}
+	*session = found
+	return nil
+}
+
+func (r *fakeTokenRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.revoked[jti], nil
+}
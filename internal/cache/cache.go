@@ -15,6 +15,10 @@ type Cache interface {
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
 	Get(ctx context.Context, key string, result any) error
 	Delete(ctx context.Context, key string) error
+
+	// SetIf sets key to value only while the JSON object stored at key has
+	// field equal to expected, in one step. False when it differs or key is gone.
+	SetIf(ctx context.Context, key string, field string, expected string, value any, expiration time.Duration) (bool, error)
 }
 
 type KeyGenerator struct {
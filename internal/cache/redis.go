@@ -14,6 +14,25 @@ type cacheService struct {
 	redisClient *redis.Client
 }
 
+// setIfScript compares and sets in redis itself, so no other client writes
+// between the two
+var setIfScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local ok, decoded = pcall(cjson.decode, current)
+if not ok or type(decoded) ~= 'table' or decoded[ARGV[1]] ~= ARGV[2] then
+	return 0
+end
+if tonumber(ARGV[4]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4])
+else
+	redis.call('SET', KEYS[1], ARGV[3])
+end
+return 1
+`)
+
 func InitRedisClient(addr string, password string) *redis.Client {
 	redisAddr := addr
 	redisPass := password
@@ -63,3 +82,15 @@ func (s *cacheService) Get(ctx context.Context, key string, result any) error {
 func (s *cacheService) Delete(ctx context.Context, key string) error {
 	return s.redisClient.Del(ctx, key).Err()
 }
+
+func (s *cacheService) SetIf(ctx context.Context, key string, field string, expected string, value any, expiration time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	set, err := setIfScript.Run(ctx, s.redisClient, []string{key}, field, expected, data, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return set == 1, nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"go-rebuild/internal/model"
+	"time"
 )
 
 type DB interface {
@@ -16,7 +17,53 @@ type DB interface {
 	GetAll(ctx context.Context, collection string, results any) error
 	GetByID(ctx context.Context, collection string, id string, result any) error
 	GetByField(ctx context.Context, collection string, field string, value any, result any) error
+	GetAllByField(ctx context.Context, collection string, field string, value any, results any) error
+
+	// one page of the records matching every field of filter, newest first, and the total count
+	GetPage(ctx context.Context, collection string, filter map[string]any, page model.Page, results any) (int64, error)
+
+	// update records keyed by something other than id
+	UpdateByField(ctx context.Context, collection string, m any, field string, value any) error
+	DeleteByField(ctx context.Context, collection string, m any, field string, value any) error
+
+	// atomic writes for counters and state changes that race. IncrementWhere
+	// adds inc to the record matching filter when every guard holds, UpdateWhere
+	// sets fields on it. Both report whether a record was written.
+	IncrementWhere(ctx context.Context, collection string, m any, filter map[string]any, inc map[string]int, guards ...Guard) (bool, error)
+	UpdateWhere(ctx context.Context, collection string, m any, filter map[string]any, set map[string]any) (bool, error)
+
+	// run fn in one transaction, every call made with the ctx passed to fn joins
+	// it. Mongo needs a replica set for transactions.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
 
 	// advance query for messages
 	FindMessageBetweenUser(ctx context.Context, sender_id string, receiver_id string) ([]model.Message, error)
+
+	// advance query for the orders of the products a seller created, newest
+	// first, and the sales aggregated over them
+	FindOrdersBySeller(ctx context.Context, sellerID string, status string, page model.Page) ([]model.Order, int64, error)
+	SalesByStatus(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
+	SalesByPeriod(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
+	SalesByProduct(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
+
+	// health
+	Ping(ctx context.Context) error
+	Stats(ctx context.Context) (*model.DBStats, error)
+}
+
+// Guard is the condition field - minus >= atLeast checked by the record being
+// written, minus is optional
+type Guard struct {
+	Field   string
+	Minus   string
+	AtLeast int
+}
+
+// queryContext bounds a single query with the configured DB_QUERY_TIMEOUT.
+// A zero timeout leaves the caller's deadline untouched.
+func queryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
\ No newline at end of file
@@ -7,22 +7,58 @@ import (
 	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	"go-rebuild/internal/model"
 	"reflect"
+	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type mongoRepo struct {
-	client *mongo.Client
-	dbName string
+	client       *mongo.Client
+	dbName       string
+	queryTimeout time.Duration
+}
+
+// mongoPoolMonitor counts connections from driver pool events, the mongo
+// driver does not expose pool stats the way database/sql does.
+type mongoPoolMonitor struct {
+	open      atomic.Int64
+	inUse     atomic.Int64
+	idleClose atomic.Int64
+}
+
+var mongoPool = &mongoPoolMonitor{}
+
+func (m *mongoPoolMonitor) handle(evt *event.PoolEvent) {
+	switch evt.Type {
+	case event.ConnectionCreated:
+		m.open.Add(1)
+	case event.ConnectionClosed:
+		m.open.Add(-1)
+		if evt.Reason == event.ReasonIdle {
+			m.idleClose.Add(1)
+		}
+	case event.GetSucceeded:
+		m.inUse.Add(1)
+	case event.ConnectionReturned:
+		m.inUse.Add(-1)
+	}
 }
 
 func InitMongoDB(ctx context.Context) (*mongo.Client, error) {
 	url := appcore_config.Config.MongoConnString
-	opts := options.Client().ApplyURI(url).SetMaxPoolSize(100).SetRetryWrites(true)
+	opts := options.Client().ApplyURI(url).
+		SetMaxPoolSize(appcore_config.Config.MongoMaxPoolSize).
+		SetMinPoolSize(appcore_config.Config.MongoMinPoolSize).
+		SetMaxConnIdleTime(appcore_config.Config.MongoMaxConnIdleTime).
+		SetTimeout(appcore_config.Config.DBQueryTimeout).
+		SetPoolMonitor(&event.PoolMonitor{Event: mongoPool.handle}).
+		SetRetryWrites(true)
 	client, err := mongo.Connect(ctx, opts)
 
 	if err != nil {
@@ -44,9 +80,21 @@ func DisconnectMongoDB(mgDB *mongo.Database, ctx context.Context) error {
 	return nil
 }
 
+// MigrateMongo brings documents written by older versions up to date, safe
+// to run on every start. Users from before email verification have no
+// email_verified field and count as verified.
+func MigrateMongo(ctx context.Context, client *mongo.Client, dbName string) error {
+	users := client.Database(dbName).Collection("users")
+	_, err := users.UpdateMany(ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"email_verified": true, "email_verified_at": "$created_at"}}},
+	)
+	return err
+}
+
 // ------------------------ Constructor ------------------------
 func NewMongoRepo(client *mongo.Client, dbName string) DB {
-	return &mongoRepo{client: client, dbName: dbName}
+	return &mongoRepo{client: client, dbName: dbName, queryTimeout: appcore_config.Config.DBQueryTimeout}
 }
 
 // ------------------------ Method ------------------------
@@ -82,6 +130,9 @@ func (m *mongoRepo) modelToBSONDoc(model any) (bson.M, error) {
             doc["_id"] = primitive.NewObjectID()
             delete(doc, "id")
         }
+    } else if idStr, ok := doc["_id"].(string); ok && primitive.IsValidObjectID(idStr) {
+        // models tagged _id keep their hex id, stored as the ObjectID filters look for
+        doc["_id"], _ = primitive.ObjectIDFromHex(idStr)
     } else {
         // ถ้าไม่มี id field
         doc["_id"] = primitive.NewObjectID()
@@ -92,6 +143,9 @@ func (m *mongoRepo) modelToBSONDoc(model any) (bson.M, error) {
 
 // ------------------------ Method Basic CUD ------------------------
 func (m *mongoRepo) Create(ctx context.Context, coll string, model any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
     doc, err := m.modelToBSONDoc(model)
     if err != nil {
         return err
@@ -102,6 +156,9 @@ func (m *mongoRepo) Create(ctx context.Context, coll string, model any) error {
 }
 
 func (m *mongoRepo) Update(ctx context.Context, coll string, model any, id string) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -111,6 +168,9 @@ func (m *mongoRepo) Update(ctx context.Context, coll string, model any, id strin
 }
 
 func (m *mongoRepo) Delete(ctx context.Context, coll string, model any, id string) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -119,8 +179,91 @@ func (m *mongoRepo) Delete(ctx context.Context, coll string, model any, id strin
 	return err
 }
 
+func (m *mongoRepo) UpdateByField(ctx context.Context, coll string, model any, field string, value any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	filter, update, err := updateByFieldQuery(model, field, value)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.setCollection(coll).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (m *mongoRepo) DeleteByField(ctx context.Context, coll string, _ any, field string, value any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	_, err := m.setCollection(coll).DeleteMany(ctx, mongoFilter(map[string]any{field: value}))
+	return err
+}
+
+func (m *mongoRepo) IncrementWhere(ctx context.Context, coll string, _ any, filter map[string]any, inc map[string]int, guards ...Guard) (bool, error) {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	query := mongoFilter(filter)
+	if len(guards) > 0 {
+		conditions := make(bson.A, 0, len(guards))
+		for _, guard := range guards {
+			var value any = "$" + guard.Field
+			if guard.Minus != "" {
+				value = bson.M{"$subtract": bson.A{"$" + guard.Field, "$" + guard.Minus}}
+			}
+			conditions = append(conditions, bson.M{"$gte": bson.A{value, guard.AtLeast}})
+		}
+		query["$expr"] = bson.M{"$and": conditions}
+	}
+
+	result, err := m.setCollection(coll).UpdateOne(ctx, query, bson.M{"$inc": inc})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (m *mongoRepo) UpdateWhere(ctx context.Context, coll string, _ any, filter map[string]any, set map[string]any) (bool, error) {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	result, err := m.setCollection(coll).UpdateOne(ctx, mongoFilter(filter), bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Transaction runs fn in a session, ctx carries it to every call made with it
+func (m *mongoRepo) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
 // ------------------------ Method Basic Query ------------------------
 func (m *mongoRepo) GetAll(ctx context.Context, coll string, results any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
 	cursor, err := m.setCollection(coll).Find(ctx, bson.M{})
 	if err != nil {
 		return err
@@ -152,6 +295,9 @@ func (m *mongoRepo) GetAll(ctx context.Context, coll string, results any) error
 
 
 func (m *mongoRepo) GetByID(ctx context.Context, coll string, id string, result any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -166,6 +312,9 @@ func (m *mongoRepo) GetByID(ctx context.Context, coll string, id string, result
 
 
 func (m *mongoRepo) GetByField(ctx context.Context, coll string, field string, value any, result any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
 	filter := bson.M{field: value}
 
 	if err := m.setCollection(coll).FindOne(ctx, filter).Decode(result); err != nil {
@@ -176,7 +325,238 @@ func (m *mongoRepo) GetByField(ctx context.Context, coll string, field string, v
 
 }
 
+func (m *mongoRepo) GetAllByField(ctx context.Context, coll string, field string, value any, results any) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	cursor, err := m.setCollection(coll).Find(ctx, bson.M{field: value})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+func (m *mongoRepo) GetPage(ctx context.Context, coll string, filter map[string]any, page model.Page, results any) (int64, error) {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	total, err := m.setCollection(coll).CountDocuments(ctx, bson.M(filter))
+	if err != nil {
+		return 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(page.Offset())).
+		SetLimit(int64(page.Size))
+
+	cursor, err := m.setCollection(coll).Find(ctx, bson.M(filter), opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	return total, cursor.All(ctx, results)
+}
+
 // advance query for messages
 func (m *mongoRepo) FindMessageBetweenUser(ctx context.Context, sender_id string, receiver_id string) ([]model.Message, error) {
 	return nil, nil
 }
+
+// advance query for sellers
+func (m *mongoRepo) FindOrdersBySeller(ctx context.Context, sellerID string, status string, page model.Page) ([]model.Order, int64, error) {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	match := bson.M{"deleted_at": bson.M{"$exists": false}}
+	if status != "" {
+		match["status"] = status
+	}
+
+	pipeline := append(sellerOrderStages(sellerID, match),
+		bson.D{{Key: "$facet", Value: bson.M{
+			"items": bson.A{
+				bson.M{"$sort": bson.M{"created_at": -1}},
+				bson.M{"$skip": page.Offset()},
+				bson.M{"$limit": page.Size},
+				bson.M{"$project": bson.M{"product": 0}},
+			},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}}},
+	)
+
+	cursor, err := m.setCollection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Items []model.Order `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 || len(result[0].Total) == 0 {
+		return nil, 0, nil
+	}
+	return result[0].Items, result[0].Total[0].Count, nil
+}
+
+func (m *mongoRepo) SalesByStatus(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	return m.aggregateSales(ctx, filter, false, bson.M{"status": "$status"}, nil)
+}
+
+func (m *mongoRepo) SalesByPeriod(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	period := bson.M{"$dateTrunc": bson.M{
+		"date":        "$created_at",
+		"unit":        filter.TruncUnit(),
+		"startOfWeek": "monday",
+		"timezone":    "UTC",
+	}}
+	sort := bson.D{{Key: "period", Value: 1}, {Key: "currency", Value: 1}}
+	return m.aggregateSales(ctx, filter, true, bson.M{"period": period}, mongo.Pipeline{
+		{{Key: "$sort", Value: sort}},
+	})
+}
+
+func (m *mongoRepo) SalesByProduct(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	sort := bson.D{{Key: "units", Value: -1}, {Key: "revenue", Value: -1}}
+	return m.aggregateSales(ctx, filter, true, bson.M{"product_id": "$product_id"}, mongo.Pipeline{
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$limit", Value: filter.Limit}},
+	})
+}
+
+// ------------------------ Method Health ------------------------
+func (m *mongoRepo) Ping(ctx context.Context) error {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+	return m.client.Ping(ctx, readpref.Primary())
+}
+
+func (m *mongoRepo) Stats(ctx context.Context) (*model.DBStats, error) {
+	start := time.Now()
+	pingErr := m.Ping(ctx)
+
+	open := int(mongoPool.open.Load())
+	inUse := int(mongoPool.inUse.Load())
+	stats := &model.DBStats{
+		Driver:             "mongo",
+		Up:                 pingErr == nil,
+		MaxOpenConnections: int(appcore_config.Config.MongoMaxPoolSize),
+		OpenConnections:    open,
+		InUse:              inUse,
+		Idle:               max(open-inUse, 0),
+		MaxIdleClosed:      mongoPool.idleClose.Load(),
+	}
+	stats.SetPingLatency(time.Since(start))
+
+	return stats, pingErr
+}
+
+// ------------------------ Private Method ------------------------
+// mongoFilter keys id as _id, the way models are stored
+func mongoFilter(filter map[string]any) bson.M {
+	query := make(bson.M, len(filter))
+	for field, value := range filter {
+		if field == "id" {
+			field = "_id"
+			if id, ok := value.(string); ok && primitive.IsValidObjectID(id) {
+				value, _ = primitive.ObjectIDFromHex(id)
+			}
+		}
+		query[field] = value
+	}
+	return query
+}
+
+// updateByFieldQuery builds the filter and $set of UpdateByField. _id is left
+// out of the $set, the model keeps it as a hex string and mongo refuses to
+// change it from the stored ObjectID.
+func updateByFieldQuery(model any, field string, value any) (bson.M, bson.M, error) {
+	data, err := bson.Marshal(model)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var set bson.M
+	if err := bson.Unmarshal(data, &set); err != nil {
+		return nil, nil, err
+	}
+	delete(set, "_id")
+	return mongoFilter(map[string]any{field: value}), bson.M{"$set": set}, nil
+}
+
+// sellerOrderStages matches the orders, then keeps those of the products the
+// seller created, with the product under "product". Orders keep the product
+// id as a hex string, so it is converted to join on the product _id.
+func sellerOrderStages(sellerID string, match bson.M) mongo.Pipeline {
+	productID := bson.M{"$convert": bson.M{"input": "$$product_id", "to": "objectId", "onError": nil, "onNull": nil}}
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "products",
+			"let":  bson.M{"product_id": "$product_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", productID}}}},
+				bson.M{"$project": bson.M{"title": 1, "created_by": 1}},
+			},
+			"as": "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$match", Value: bson.M{"product.created_by": sellerID}}},
+	}
+}
+
+// aggregateSales groups the orders of the filter by key and currency into
+// SalesRow fields named as the keys, then runs the stages after
+func (m *mongoRepo) aggregateSales(ctx context.Context, filter *model.SalesFilter, salesOnly bool, key bson.M, after mongo.Pipeline) ([]model.SalesRow, error) {
+	ctx, cancel := queryContext(ctx, m.queryTimeout)
+	defer cancel()
+
+	match := bson.M{
+		"deleted_at": bson.M{"$exists": false},
+		"created_at": bson.M{"$gte": filter.From, "$lt": filter.To},
+	}
+	if salesOnly {
+		match["status"] = bson.M{"$in": model.SalesStatuses}
+	}
+
+	id := bson.M{"currency": "$amount.currency"}
+	project := bson.M{"_id": 0, "currency": "$_id.currency", "orders": 1, "units": 1, "revenue": 1, "title": 1}
+	for field, value := range key {
+		id[field] = value
+		project[field] = "$_id." + field
+	}
+
+	pipeline := append(sellerOrderStages(filter.SellerID, match),
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":     id,
+			"title":   bson.M{"$first": "$product.title"},
+			"orders":  bson.M{"$sum": 1},
+			"units":   bson.M{"$sum": "$quantity"},
+			"revenue": bson.M{"$sum": "$amount.amount"},
+		}}},
+		bson.D{{Key: "$project", Value: project}},
+	)
+	pipeline = append(pipeline, after...)
+
+	cursor, err := m.setCollection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []model.SalesRow
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"go-rebuild/internal/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The filter has to match the document Create stored, _id as an ObjectID, or
+// the update lands nowhere.
+func TestUpdateByFieldQuery(t *testing.T) {
+	warehouse := &model.Warehouse{ID: primitive.NewObjectID().Hex(), SellerID: "s1", Name: "Main"}
+	stored, err := (&mongoRepo{}).modelToBSONDoc(warehouse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		field     string
+		value     any
+		wantField string
+		want      any
+	}{
+		{name: "by id", field: "id", value: warehouse.ID, wantField: "_id", want: stored["_id"]},
+		{name: "by other field", field: "seller_id", value: "s1", wantField: "seller_id", want: stored["seller_id"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warehouse.Name = "Renamed"
+			filter, update, err := updateByFieldQuery(warehouse, tt.field, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(filter) != 1 || filter[tt.wantField] != tt.want {
+				t.Errorf("filter = %v, want %s: %v", filter, tt.wantField, tt.want)
+			}
+			set, _ := update["$set"].(bson.M)
+			if _, ok := set["_id"]; ok {
+				t.Error("$set changes _id")
+			}
+			if set["name"] != "Renamed" {
+				t.Errorf("$set name = %v, want Renamed", set["name"])
+			}
+		})
+	}
+}
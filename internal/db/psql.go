@@ -5,13 +5,15 @@ import (
 	"fmt"
 	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	"go-rebuild/internal/model"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type psqlRepo struct {
-	db *gorm.DB
+	db           *gorm.DB
+	queryTimeout time.Duration
 }
 
 func InitPsqlDB() (*gorm.DB, error) {
@@ -21,6 +23,17 @@ func InitPsqlDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// tune the underlying sql.DB pool
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get sql db from gorm: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(appcore_config.Config.PostgresMaxOpenConns)
+	sqlDB.SetMaxIdleConns(appcore_config.Config.PostgresMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(appcore_config.Config.PostgresConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(appcore_config.Config.PostgresConnMaxIdleTime)
+
 	return db, nil
 }
 
@@ -28,9 +41,30 @@ func InitPsqlDB() (*gorm.DB, error) {
 func NewPsqlRepo(db *gorm.DB) (DB, error) {
 	model := []interface{}{
 		&model.User{},
+		&model.Address{},
 		&model.Product{},
+		&model.ProductVariant{},
+		&model.ProductImage{},
+		&model.PriceChange{},
+		&model.Review{},
+		&model.ProductRating{},
+		&model.Category{},
+		&model.ProductCategory{},
 		&model.Stock{},
+		&model.StockMovement{},
+		&model.StockSubscription{},
+		&model.Wishlist{},
+		&model.StockReservation{},
+		&model.Warehouse{},
+		&model.WarehouseStock{},
+		&model.Coupon{},
+		&model.TaxRule{},
+		&model.CouponRedemption{},
 		&model.Order{},
+		&model.Payment{},
+		&model.PaymentEvent{},
+		&model.Return{},
+		&model.Shipment{},
 		&model.Message{},
 	}
 
@@ -39,16 +73,29 @@ func NewPsqlRepo(db *gorm.DB) (DB, error) {
 			return nil, fmt.Errorf("failed to auto migrate model %T: %w", m, err)
 		}
 	}
-	return &psqlRepo{db: db}, nil
+
+	if err := migrateMoneyColumns(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate money columns: %w", err)
+	}
+	// users from before email verification got the column as NULL, new ones
+	// are written with false until they verify
+	if err := db.Exec("UPDATE users SET email_verified = true, email_verified_at = created_at WHERE email_verified IS NULL").Error; err != nil {
+		return nil, fmt.Errorf("failed to backfill email verified: %w", err)
+	}
+	return &psqlRepo{db: db, queryTimeout: appcore_config.Config.DBQueryTimeout}, nil
 }
 
 // ------------------------ Method Basic CUD ------------------------
 func (p *psqlRepo) Create(ctx context.Context, _ string, model any) error {
-	return p.db.WithContext(ctx).Create(model).Error
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+	return p.conn(ctx).Create(model).Error
 }
 
 func (p *psqlRepo) Update(ctx context.Context, _ string, model any, id string) error {
-	result := p.db.WithContext(ctx).Model(model).Where("id = ?", id).Updates(model)
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+	result := p.conn(ctx).Model(model).Where("id = ?", id).Updates(model)
 
 	if result.Error != nil {
 		return result.Error
@@ -60,12 +107,87 @@ func (p *psqlRepo) Update(ctx context.Context, _ string, model any, id string) e
 }
 
 func (p *psqlRepo) Delete(ctx context.Context, _ string, model any, id string) error {
-	return p.db.WithContext(ctx).Delete(model, "id = ?", id).Error
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+	return p.conn(ctx).Delete(model, "id = ?", id).Error
+}
+
+// UpdateByField writes every column of m, including zero values, so callers
+// must pass the full record they loaded.
+func (p *psqlRepo) UpdateByField(ctx context.Context, _ string, model any, field string, value any) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	condition := map[string]any{field: value}
+	result := p.conn(ctx).Model(model).Where(condition).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IncrementWhere adds inc in one UPDATE, so concurrent writers can't lose each
+// other's change or push a guarded counter past its bound
+func (p *psqlRepo) IncrementWhere(ctx context.Context, _ string, model any, filter map[string]any, inc map[string]int, guards ...Guard) (bool, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	query := p.conn(ctx).Model(model).Where(filter)
+	for _, guard := range guards {
+		condition := guard.Field
+		if guard.Minus != "" {
+			condition += " - " + guard.Minus
+		}
+		query = query.Where(condition+" >= ?", guard.AtLeast)
+	}
+
+	columns := make(map[string]any, len(inc))
+	for column, delta := range inc {
+		columns[column] = gorm.Expr(column+" + ?", delta)
+	}
+
+	result := query.Updates(columns)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (p *psqlRepo) UpdateWhere(ctx context.Context, _ string, model any, filter map[string]any, set map[string]any) (bool, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	result := p.conn(ctx).Model(model).Where(filter).Updates(set)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Transaction keeps the tx in ctx, conn picks it up for every query made with it
+func (p *psqlRepo) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(psqlTxKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, psqlTxKey{}, tx))
+	})
+}
+
+func (p *psqlRepo) DeleteByField(ctx context.Context, _ string, model any, field string, value any) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+	return p.conn(ctx).Where(map[string]any{field: value}).Delete(model).Error
 }
 
 // ------------------------ Method Basic Query ------------------------
 func (p *psqlRepo) GetAll(ctx context.Context, _ string, results any) error {
-	res := p.db.WithContext(ctx).Find(results)
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+	res := p.conn(ctx).Find(results)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -73,8 +195,10 @@ func (p *psqlRepo) GetAll(ctx context.Context, _ string, results any) error {
 }
 
 func (p *psqlRepo) GetByID(ctx context.Context, _ string, id string, result any) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
 	condition := map[string]any{"id": id}
-	res := p.db.WithContext(ctx).Where(condition).First(result)
+	res := p.conn(ctx).Where(condition).First(result)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -82,18 +206,49 @@ func (p *psqlRepo) GetByID(ctx context.Context, _ string, id string, result any)
 }
 
 func (p *psqlRepo) GetByField(ctx context.Context, _ string, field string, value any, result any) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
 	condition := map[string]any{field: value}
-	res := p.db.WithContext(ctx).Where(condition).First(result)
+	res := p.conn(ctx).Where(condition).First(result)
 	if res.Error != nil {
 		return res.Error
 	}
 	return nil
 }
 
+func (p *psqlRepo) GetAllByField(ctx context.Context, _ string, field string, value any, results any) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	condition := map[string]any{field: value}
+	return p.conn(ctx).Where(condition).Find(results).Error
+}
+
+func (p *psqlRepo) GetPage(ctx context.Context, _ string, filter map[string]any, page model.Page, results any) (int64, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	var total int64
+	if err := p.conn(ctx).Model(results).Where(filter).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	err := p.conn(ctx).
+		Where(filter).
+		Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Size).
+		Find(results).Error
+	return total, err
+}
+
 // advance query for messages
 func (p *psqlRepo) FindMessageBetweenUser(ctx context.Context, sender_id string, receiver_id string) ([]model.Message, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
 	var messages []model.Message
-	err := p.db.WithContext(ctx).
+	err := p.conn(ctx).
 		Where(
 			"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 			sender_id, receiver_id,
@@ -105,3 +260,183 @@ func (p *psqlRepo) FindMessageBetweenUser(ctx context.Context, sender_id string,
 	}
 	return messages, nil
 }
+
+// advance query for sellers
+func (p *psqlRepo) FindOrdersBySeller(ctx context.Context, sellerID string, status string, page model.Page) ([]model.Order, int64, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	query := func() *gorm.DB {
+		q := p.sellerOrders(ctx, sellerID)
+		if status != "" {
+			q = q.Where("o.status = ?", status)
+		}
+		return q
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []model.Order
+	err := query().
+		Select("o.*").
+		Order("o.created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Size).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+func (p *psqlRepo) SalesByStatus(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	var rows []model.SalesRow
+	err := p.salesOrders(ctx, filter).
+		Select("o.status AS status, " + salesColumns).
+		Group("o.status, o.total_currency").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (p *psqlRepo) SalesByPeriod(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	var rows []model.SalesRow
+	err := p.salesOrders(ctx, filter).
+		Select("date_trunc(?, o.created_at AT TIME ZONE 'UTC') AS period, "+salesColumns, filter.TruncUnit()).
+		Where("o.status IN ?", model.SalesStatuses).
+		Group("period, o.total_currency").
+		Order("period, currency").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (p *psqlRepo) SalesByProduct(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	var rows []model.SalesRow
+	err := p.salesOrders(ctx, filter).
+		Select("o.product_id AS product_id, MAX(p.title) AS title, "+salesColumns).
+		Where("o.status IN ?", model.SalesStatuses).
+		Group("o.product_id, o.total_currency").
+		Order("units DESC, revenue DESC").
+		Limit(filter.Limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ------------------------ Method Health ------------------------
+func (p *psqlRepo) Ping(ctx context.Context) error {
+	ctx, cancel := queryContext(ctx, p.queryTimeout)
+	defer cancel()
+
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (p *psqlRepo) Stats(ctx context.Context) (*model.DBStats, error) {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	pingErr := p.Ping(ctx)
+
+	poolStats := sqlDB.Stats()
+	stats := &model.DBStats{
+		Driver:             "postgres",
+		Up:                 pingErr == nil,
+		MaxOpenConnections: poolStats.MaxOpenConnections,
+		OpenConnections:    poolStats.OpenConnections,
+		InUse:              poolStats.InUse,
+		Idle:               poolStats.Idle,
+		WaitCount:          poolStats.WaitCount,
+		MaxIdleClosed:      poolStats.MaxIdleClosed + poolStats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  poolStats.MaxLifetimeClosed,
+	}
+	stats.SetPingLatency(time.Since(start))
+	stats.SetWaitDuration(poolStats.WaitDuration)
+
+	return stats, pingErr
+}
+
+// ------------------------ Private Method ------------------------
+type psqlTxKey struct{}
+
+// conn runs on the transaction of ctx if there is one
+func (p *psqlRepo) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(psqlTxKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return p.db.WithContext(ctx)
+}
+
+// salesColumns are the aggregates of every sales query, grouped by currency
+const salesColumns = "o.total_currency AS currency, COUNT(*) AS orders, COALESCE(SUM(o.quantity), 0) AS units, COALESCE(SUM(o.total_amount), 0) AS revenue"
+
+// sellerOrders selects the orders, as o, of the products, as p, the seller created
+func (p *psqlRepo) sellerOrders(ctx context.Context, sellerID string) *gorm.DB {
+	return p.conn(ctx).
+		Table("orders AS o").
+		Joins("JOIN products AS p ON p.id = o.product_id").
+		Where("p.created_by = ? AND o.deleted_at IS NULL", sellerID)
+}
+
+func (p *psqlRepo) salesOrders(ctx context.Context, filter *model.SalesFilter) *gorm.DB {
+	return p.sellerOrders(ctx, filter.SellerID).
+		Where("o.created_at >= ? AND o.created_at < ?", filter.From, filter.To)
+}
+
+// integer price columns written before Money, and the prefix of the Money
+// columns that replaced them
+var legacyMoneyColumns = []struct {
+	model  any
+	column string
+	prefix string
+}{
+	{&model.Product{}, "price", "price_"},
+	{&model.ProductVariant{}, "price", "price_"},
+	{&model.Order{}, "price", "price_"},
+	{&model.Order{}, "subtotal", "subtotal_"},
+	{&model.Order{}, "discount", "discount_"},
+	{&model.Order{}, "amount", "total_"},
+	{&model.PriceChange{}, "old_price", "old_price_"},
+	{&model.PriceChange{}, "new_price", "new_price_"},
+	{&model.CouponRedemption{}, "discount", "discount_"},
+}
+
+// migrateMoneyColumns copies legacy integer amounts, which were in major units,
+// into the Money columns as minor units of the default currency. Rows that
+// already have a currency are left alone, so it is safe on every start. The
+// old columns are kept for a manual drop.
+func migrateMoneyColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, legacy := range legacyMoneyColumns {
+		if !migrator.HasColumn(legacy.model, legacy.column) {
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(legacy.model); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(
+			"UPDATE %s SET %samount = %s * ?, %scurrency = ? WHERE %s IS NOT NULL AND (%scurrency IS NULL OR %scurrency = '')",
+			stmt.Schema.Table, legacy.prefix, legacy.column, legacy.prefix, legacy.column, legacy.prefix, legacy.prefix,
+		)
+		if err := db.Exec(query, model.MinorUnits(model.DefaultCurrency), model.DefaultCurrency).Error; err != nil {
+			return fmt.Errorf("%s.%s: %w", stmt.Schema.Table, legacy.column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddressHandler struct {
+	service module.AddressService
+}
+
+func NewAddressHandler(service module.AddressService) *AddressHandler {
+	return &AddressHandler{service: service}
+}
+
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	var addressReq model.AddressReq
+	if err := c.ShouldBindJSON(&addressReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	address, err := h.service.Save(c.Request.Context(), &addressReq, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "address created", "data": address})
+}
+
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	var addressReq model.AddressReq
+	if err := c.ShouldBindJSON(&addressReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	address, err := h.service.Update(c.Request.Context(), &addressReq, c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address updated", "data": address})
+}
+
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "address deleted"})
+}
+
+func (h *AddressHandler) GetAddresses(c *gin.Context) {
+	userID := c.GetString("user_id")
+	addresses, err := h.service.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get addresses success", "data": addresses})
+}
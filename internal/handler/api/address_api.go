@@ -0,0 +1,20 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAddressAPI(router *gin.Engine, addressHandler *handler.AddressHandler, authSvc auth.Jwt) {
+	protected := router.Group("/addresses")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.GET("/", addressHandler.GetAddresses)
+	protected.POST("/", addressHandler.CreateAddress)
+	protected.PUT("/:id", addressHandler.UpdateAddress)
+	protected.DELETE("/:id", addressHandler.DeleteAddress)
+}
@@ -1,13 +1,22 @@
 package api
 
 import (
+	"go-rebuild/internal/auth"
 	"go-rebuild/internal/handler"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterAuthAPI(router *gin.Engine, authHandler handler.AuthHandler) {
+func RegisterAuthAPI(router *gin.Engine, authHandler handler.AuthHandler, authSvc auth.Jwt) {
 	router.POST("/register/user", authHandler.RegisterUser)
 	router.POST("/register/seller", authHandler.RegisterSeller)
 	router.POST("/login", authHandler.Login)
+
+	tokens := router.Group("/auth")
+	tokens.POST("/refresh", authHandler.Refresh)
+	tokens.POST("/logout", handler.OptionalAuthenticateMiddleware(authSvc), authHandler.Logout)
+	tokens.GET("/verify-email", authHandler.VerifyEmail)
+	tokens.POST("/resend-verification", authHandler.ResendVerification)
+
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 }
\ No newline at end of file
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCartAPI(router *gin.Engine, cartHandler *handler.CartHandler, authSvc auth.Jwt) {
+	// guests send X-Cart-ID instead of a token, it is handed out on the first add
+	cart := router.Group("/cart")
+	cart.Use(handler.OptionalAuthenticateMiddleware(authSvc))
+	cart.GET("/", cartHandler.GetCart)
+	cart.DELETE("/", cartHandler.ClearCart)
+	cart.POST("/lines", cartHandler.AddCartLine)
+	cart.PATCH("/lines/:id", cartHandler.UpdateCartLine)
+	cart.DELETE("/lines/:id", cartHandler.RemoveCartLine)
+
+	protected := router.Group("/cart")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.POST("/checkout", cartHandler.Checkout)
+}
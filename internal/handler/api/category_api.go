@@ -0,0 +1,30 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCategoryAPI(router *gin.Engine, categoryHandler *handler.CategoryHandler, authSvc auth.Jwt) {
+	public := router.Group("/categories")
+	public.GET("/", categoryHandler.GetCategories)
+	public.GET("/:slug/products", categoryHandler.GetCategoryProducts)
+
+	adminOnly := router.Group("/categories")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
+	adminOnly.POST("/", categoryHandler.CreateCategory)
+	adminOnly.PUT("/:id", categoryHandler.UpdateCategory)
+	adminOnly.DELETE("/:id", categoryHandler.DeleteCategory)
+
+	protected := router.Group("/products")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER", "ADMIN"),
+	)
+	protected.PUT("/:id/categories", categoryHandler.SetProductCategories)
+}
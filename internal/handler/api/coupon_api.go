@@ -0,0 +1,21 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCouponAPI(router *gin.Engine, couponHandler *handler.CouponHandler, authSvc auth.Jwt) {
+	adminOnly := router.Group("/coupons")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
+	adminOnly.GET("/", couponHandler.GetCoupons)
+	adminOnly.GET("/:id", couponHandler.GetCoupon)
+	adminOnly.POST("/", couponHandler.CreateCoupon)
+	adminOnly.PUT("/:id", couponHandler.UpdateCoupon)
+	adminOnly.DELETE("/:id", couponHandler.DeleteCoupon)
+}
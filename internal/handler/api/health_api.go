@@ -0,0 +1,15 @@
+package api
+
+import (
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHealthAPI(router *gin.Engine, healthHandler *handler.HealthHandler) {
+	public := router.Group("/health")
+	public.GET("/live", healthHandler.Live)
+	public.GET("/ready", healthHandler.Ready)
+
+	router.GET("/metrics", healthHandler.Metrics)
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterInvoiceAPI(router *gin.Engine, invoiceHandler *handler.InvoiceHandler, authSvc auth.Jwt) {
+	protected := router.Group("/orders")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.GET("/:id/invoice", invoiceHandler.GetInvoice)
+}
@@ -19,6 +19,17 @@ func RegisterOrderAPI(router *gin.Engine, orderHandler *handler.OrderHandler, au
 	protected.PATCH("/:id", orderHandler.UpdateOrder)
 	protected.DELETE("/:id", orderHandler.DeleteOrder)
 
-	adminOnly := router.Group("/orders").Use(handler.AuthorizeMiddleware(authSvc, "ADMIN"))
+	me := router.Group("/users/me")
+	me.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	me.GET("/orders", orderHandler.GetMyOrders)
+
+	adminOnly := router.Group("/orders")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
 	adminOnly.GET("/", orderHandler.GetOrders)
 }
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPaymentAPI(router *gin.Engine, paymentHandler *handler.PaymentHandler, authSvc auth.Jwt) {
+	// signed by the provider, not by a user token
+	router.POST("/payments/webhook", paymentHandler.Webhook)
+
+	protected := router.Group("/orders")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.POST("/:id/pay", paymentHandler.PayOrder)
+	protected.GET("/:id/payments", paymentHandler.GetOrderPayments)
+
+	adminOnly := router.Group("/payments")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
+	adminOnly.POST("/:id/refund", paymentHandler.RefundPayment)
+}
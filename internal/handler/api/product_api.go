@@ -11,6 +11,7 @@ func RegisterProductAPI(router *gin.Engine, productHandler *handler.ProductHandl
 	public := router.Group("/products")
 	public.GET("/", productHandler.GetProducts)
 	public.GET("/:id", productHandler.GetProduct)
+	public.GET("/:id/price-history", productHandler.GetPriceHistory)
 
 	protected := router.Group("/products")
 	protected.Use(
@@ -20,4 +21,14 @@ func RegisterProductAPI(router *gin.Engine, productHandler *handler.ProductHandl
 	protected.POST("/", productHandler.CreateProduct)
 	protected.PATCH("/:id", productHandler.UpdateProduct)
 	protected.DELETE("/:id", productHandler.DeleteProduct)
+
+	sellerOnly := router.Group("/products")
+	sellerOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER"),
+	)
+	sellerOnly.POST("/:id/images", productHandler.UploadProductImage)
+	sellerOnly.DELETE("/:id/images/:image_id", productHandler.DeleteProductImage)
+	sellerOnly.POST("/:id/price-schedule", productHandler.SchedulePrice)
+	sellerOnly.DELETE("/:id/price-schedule/:change_id", productHandler.CancelScheduledPrice)
 }
\ No newline at end of file
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReturnAPI(router *gin.Engine, returnHandler *handler.ReturnHandler, authSvc auth.Jwt) {
+	protected := router.Group("/orders")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.POST("/:id/returns", returnHandler.RequestReturn)
+	protected.GET("/:id/returns", returnHandler.GetOrderReturns)
+
+	sellerOnly := router.Group("/returns")
+	sellerOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER", "ADMIN"),
+	)
+	sellerOnly.GET("/", returnHandler.GetSellerReturns)
+	sellerOnly.PATCH("/:id", returnHandler.DecideReturn)
+}
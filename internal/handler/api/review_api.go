@@ -0,0 +1,29 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReviewAPI(router *gin.Engine, reviewHandler *handler.ReviewHandler, authSvc auth.Jwt) {
+	public := router.Group("/products")
+	public.GET("/:id/reviews", reviewHandler.GetProductReviews)
+
+	protected := router.Group("/products")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.POST("/:id/reviews", reviewHandler.CreateReview)
+
+	adminOnly := router.Group("/reviews")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
+	adminOnly.GET("/products/:product_id", reviewHandler.GetProductReviewsForModeration)
+	adminOnly.PATCH("/:id", reviewHandler.ModerateReview)
+	adminOnly.DELETE("/:id", reviewHandler.DeleteReview)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSellerAPI(router *gin.Engine, sellerHandler *handler.SellerHandler, authSvc auth.Jwt) {
+	protected := router.Group("/sellers/me")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER"),
+	)
+	protected.GET("/products", sellerHandler.GetMyProducts)
+	protected.GET("/stocks", sellerHandler.GetMyStocks)
+	protected.GET("/orders", sellerHandler.GetMyOrders)
+
+	// ?from=&to= as YYYY-MM-DD, add format=csv for a CSV file
+	protected.GET("/reports/summary", sellerHandler.GetMySalesSummary)
+	protected.GET("/reports/revenue", sellerHandler.GetMyRevenue)
+	protected.GET("/reports/top-products", sellerHandler.GetMyTopProducts)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterShipmentAPI(router *gin.Engine, shipmentHandler *handler.ShipmentHandler, authSvc auth.Jwt) {
+	protected := router.Group("/orders")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.GET("/:id/shipment", shipmentHandler.GetShipment)
+
+	sellerOnly := router.Group("/orders")
+	sellerOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER", "ADMIN"),
+	)
+	sellerOnly.POST("/:id/shipment", shipmentHandler.ShipOrder)
+	sellerOnly.POST("/:id/shipment/events", shipmentHandler.AddShipmentEvent)
+}
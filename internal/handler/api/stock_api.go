@@ -11,4 +11,28 @@ func RegisterStockAPI(router *gin.Engine, stockHandler *handler.StockHandler, au
 	public := router.Group("/stocks")
 	public.GET("/", stockHandler.GetStocks)
 	public.GET("/:product_id", stockHandler.GetStock)
+
+	sellerOnly := router.Group("/stocks")
+	sellerOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER"),
+	)
+	sellerOnly.POST("/:product_id/adjust", stockHandler.AdjustStock)
+	sellerOnly.PUT("/:product_id/threshold", stockHandler.SetReorderThreshold)
+	sellerOnly.POST("/:product_id/transfer", stockHandler.TransferStock)
+
+	protected := router.Group("/stocks")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER", "ADMIN"),
+	)
+	protected.GET("/:product_id/movements", stockHandler.GetStockMovements)
+
+	subscriber := router.Group("/stocks")
+	subscriber.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	subscriber.POST("/:product_id/subscription", stockHandler.Subscribe)
+	subscriber.DELETE("/:product_id/subscription", stockHandler.Unsubscribe)
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTaxAPI(router *gin.Engine, taxHandler *handler.TaxHandler, authSvc auth.Jwt) {
+	adminOnly := router.Group("/tax-rules")
+	adminOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "ADMIN"),
+	)
+	adminOnly.GET("/", taxHandler.GetTaxRules)
+	adminOnly.POST("/", taxHandler.CreateTaxRule)
+	adminOnly.PUT("/:id", taxHandler.UpdateTaxRule)
+	adminOnly.DELETE("/:id", taxHandler.DeleteTaxRule)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWarehouseAPI(router *gin.Engine, warehouseHandler *handler.WarehouseHandler, authSvc auth.Jwt) {
+	sellerOnly := router.Group("/warehouses")
+	sellerOnly.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "SELLER"),
+	)
+	sellerOnly.GET("/", warehouseHandler.GetMyWarehouses)
+	sellerOnly.POST("/", warehouseHandler.CreateWarehouse)
+	sellerOnly.PUT("/:id", warehouseHandler.UpdateWarehouse)
+	sellerOnly.DELETE("/:id", warehouseHandler.DeleteWarehouse)
+}
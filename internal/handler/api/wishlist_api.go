@@ -0,0 +1,19 @@
+package api
+
+import (
+	"go-rebuild/internal/auth"
+	"go-rebuild/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWishlistAPI(router *gin.Engine, wishlistHandler *handler.WishlistHandler, authSvc auth.Jwt) {
+	protected := router.Group("/users/me/wishlist")
+	protected.Use(
+		handler.AuthenticateMiddleware(authSvc),
+		handler.AuthorizeMiddleware(authSvc, "USER", "SELLER", "ADMIN"),
+	)
+	protected.GET("/", wishlistHandler.GetWishlist)
+	protected.POST("/", wishlistHandler.AddWishlist)
+	protected.DELETE("/:product_id", wishlistHandler.RemoveWishlist)
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"go-rebuild/internal/auth"
 	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,10 +14,12 @@ import (
 
 type AuthHandler struct {
 	service auth.Jwt
+	cartSvc module.CartService
+	userSvc module.UserService
 }
 
-func NewAuthHandler(service auth.Jwt) AuthHandler {
-	return AuthHandler{service: service}
+func NewAuthHandler(service auth.Jwt, cartSvc module.CartService, userSvc module.UserService) AuthHandler {
+	return AuthHandler{service: service, cartSvc: cartSvc, userSvc: userSvc}
 }
 
 func (h *AuthHandler) RegisterUser(c *gin.Context) {
@@ -60,11 +65,113 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.Login(c.Request.Context(), &user)
+	tokens, err := h.service.Login(c.Request.Context(), &user)
+	if errors.Is(err, model.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
-}
\ No newline at end of file
+	// a guest cart is merged into the user's cart, failing that it is only logged
+	if guestID := c.GetHeader(CartIDHeader); guestID != "" {
+		h.mergeCart(c, guestID, tokens.Token)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var refreshReq model.RefreshReq
+	if err := c.ShouldBindJSON(&refreshReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := refreshReq.Verify(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), refreshReq.RefreshToken)
+	if err != nil {
+		tokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout takes the access token from the Authorization header, the refresh
+// token from the body, or both
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var refreshReq model.RefreshReq
+	if err := c.ShouldBindJSON(&refreshReq); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var claims *model.Claims
+	if v, ok := c.Get("claims"); ok {
+		claims = v.(*model.Claims)
+	}
+
+	if err := h.service.Logout(c.Request.Context(), refreshReq.RefreshToken, claims); err != nil {
+		tokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	err := h.userSvc.VerifyEmail(c.Request.Context(), c.Query("token"))
+	switch {
+	case errors.Is(err, model.ErrVerificationToken), errors.Is(err, model.ErrEmailVerified):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+	}
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var resendReq model.ResendVerificationReq
+	if err := c.ShouldBindJSON(&resendReq); err != nil || resendReq.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	// the same answer whatever happened, it must not tell which emails have an account
+	h.userSvc.ResendVerification(c.Request.Context(), resendReq.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "a verification email is sent if the email has an unverified account"})
+}
+
+// JWKS publishes the keys tokens are verified with, a rotated key shows up
+// within the cache time
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.service.JWKS())
+}
+
+func tokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrLogoutToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrRefreshToken), errors.Is(err, model.ErrRefreshReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) mergeCart(c *gin.Context, guestID string, token string) {
+	claims, err := h.service.VerifyToken(token)
+	if err != nil {
+		logrus.WithError(err).Warn("[Handler]: verify token to merge cart")
+		return
+	}
+	if err := h.cartSvc.Merge(c.Request.Context(), guestID, claims.Subject); err != nil {
+		logrus.WithError(err).Warn("[Handler]: merge guest cart")
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartIDHeader carries the id of a guest cart, a signed in user has one cart
+const CartIDHeader = "X-Cart-ID"
+
+type CartHandler struct {
+	service module.CartService
+}
+
+func NewCartHandler(service module.CartService) *CartHandler {
+	return &CartHandler{service: service}
+}
+
+func (h *CartHandler) GetCart(c *gin.Context) {
+	cart, err := h.service.Get(c.Request.Context(), cartOwner(c))
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get cart success", "data": cart})
+}
+
+func (h *CartHandler) AddCartLine(c *gin.Context) {
+	var lineReq model.CartLineReq
+	if err := c.ShouldBindJSON(&lineReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := h.service.AddLine(c.Request.Context(), cartOwner(c), &lineReq)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	if cart.GuestID != "" {
+		c.Header(CartIDHeader, cart.GuestID)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "cart updated", "data": cart})
+}
+
+func (h *CartHandler) UpdateCartLine(c *gin.Context) {
+	var quantityReq model.CartQuantityReq
+	if err := c.ShouldBindJSON(&quantityReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := h.service.UpdateLine(c.Request.Context(), cartOwner(c), c.Param("id"), &quantityReq)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "cart updated", "data": cart})
+}
+
+func (h *CartHandler) RemoveCartLine(c *gin.Context) {
+	cart, err := h.service.RemoveLine(c.Request.Context(), cartOwner(c), c.Param("id"))
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "cart updated", "data": cart})
+}
+
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	if err := h.service.Clear(c.Request.Context(), cartOwner(c)); err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "cart cleared"})
+}
+
+func (h *CartHandler) Checkout(c *gin.Context) {
+	var checkoutReq model.CheckoutReq
+	if err := c.ShouldBindJSON(&checkoutReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	checkout, err := h.service.Checkout(c.Request.Context(), userID, &checkoutReq)
+	if err != nil {
+		cartError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "cart checked out", "data": checkout})
+}
+
+// cartOwner is the signed in user, or the guest named by the X-Cart-ID header
+func cartOwner(c *gin.Context) model.CartOwner {
+	return model.CartOwner{
+		UserID:  c.GetString("user_id"),
+		GuestID: c.GetHeader(CartIDHeader),
+	}
+}
+
+// cartError answers 400 for a cart the request can't change, 409 when the
+// cart changed under checkout, 500 otherwise
+func cartError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrCartChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrCartEmpty), errors.Is(err, model.ErrCartLine), errors.Is(err, model.ErrCartFull),
+		errors.Is(err, model.ErrCartQuantity), errors.Is(err, model.ErrCartProduct), errors.Is(err, model.ErrCartGuest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	service module.CategoryService
+}
+
+func NewCategoryHandler(service module.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	tree, err := h.service.GetTree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get categories success", "data": tree})
+}
+
+func (h *CategoryHandler) GetCategoryProducts(c *gin.Context) {
+	products, err := h.service.GetProductsBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get category products success", "data": products})
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var categoryReq model.CategoryReq
+	if err := c.ShouldBindJSON(&categoryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Save(c.Request.Context(), &categoryReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "category created", "data": category})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var categoryReq model.CategoryReq
+	if err := c.ShouldBindJSON(&categoryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Update(c.Request.Context(), &categoryReq, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category updated", "data": category})
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+}
+
+func (h *CategoryHandler) SetProductCategories(c *gin.Context) {
+	var productCategoryReq model.ProductCategoryReq
+	if err := c.ShouldBindJSON(&productCategoryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	if err := h.service.SetProductCategories(c.Request.Context(), c.Param("id"), productCategoryReq.CategoryIDs, userID, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "product categories updated"})
+}
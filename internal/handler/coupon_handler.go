@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponHandler struct {
+	service module.CouponService
+}
+
+func NewCouponHandler(service module.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var couponReq model.CouponReq
+	if err := c.ShouldBindJSON(&couponReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.service.Save(c.Request.Context(), &couponReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "coupon created", "data": coupon})
+}
+
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	var couponReq model.CouponReq
+	if err := c.ShouldBindJSON(&couponReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coupon, err := h.service.Update(c.Request.Context(), &couponReq, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon updated", "data": coupon})
+}
+
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "coupon deleted"})
+}
+
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	coupons, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get coupons success", "data": coupons})
+}
+
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	coupon, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get coupon success", "data": coupon})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type HealthHandler struct {
+	db db.DB
+}
+
+func NewHealthHandler(db db.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthHandler) Ready(c *gin.Context) {
+	stats, err := h.db.Stats(c.Request.Context())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"layer":     "handler",
+			"operation": "health_ready",
+		}).Error("database ping failed")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "db": stats})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "db": stats})
+}
+
+// Metrics exposes the db pool stats in the prometheus text format.
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	stats, err := h.db.Stats(c.Request.Context())
+	if stats == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4", []byte(dbStatsToMetrics(stats)))
+}
+
+func dbStatsToMetrics(stats *model.DBStats) string {
+	up := 0
+	if stats.Up {
+		up = 1
+	}
+
+	var b strings.Builder
+	writeMetric := func(name string, kind string, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, kind)
+		fmt.Fprintf(&b, "%s{driver=%q} %v\n", name, stats.Driver, value)
+	}
+
+	writeMetric("db_up", "gauge", "Whether the last database ping succeeded.", up)
+	writeMetric("db_ping_latency_seconds", "gauge", "Latency of the last database ping.", stats.PingLatency.Seconds())
+	writeMetric("db_pool_max_open_connections", "gauge", "Maximum number of open connections.", stats.MaxOpenConnections)
+	writeMetric("db_pool_open_connections", "gauge", "Number of established connections.", stats.OpenConnections)
+	writeMetric("db_pool_in_use_connections", "gauge", "Number of connections currently in use.", stats.InUse)
+	writeMetric("db_pool_idle_connections", "gauge", "Number of idle connections.", stats.Idle)
+	writeMetric("db_pool_wait_count_total", "counter", "Total number of connections waited for.", stats.WaitCount)
+	writeMetric("db_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a connection.", stats.WaitDuration.Seconds())
+	writeMetric("db_pool_max_idle_closed_total", "counter", "Total connections closed due to idle limits.", stats.MaxIdleClosed)
+	writeMetric("db_pool_max_lifetime_closed_total", "counter", "Total connections closed due to max lifetime.", stats.MaxLifetimeClosed)
+
+	return b.String()
+}
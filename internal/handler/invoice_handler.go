@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"go-rebuild/internal/invoice"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceHandler struct {
+	service module.InvoiceService
+}
+
+func NewInvoiceHandler(service module.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{service: service}
+}
+
+// GetInvoice downloads the invoice of an order, as a PDF unless ?format=html
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	format := c.DefaultQuery("format", invoice.FormatPDF)
+	doc, err := h.service.GetByOrderID(c.Request.Context(), c.Param("id"), format, userID, role)
+	if errors.Is(err, invoice.ErrFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+doc.Filename+`"`)
+	c.Data(http.StatusOK, doc.ContentType, doc.Data)
+}
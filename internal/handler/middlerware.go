@@ -42,10 +42,24 @@ func AuthenticateMiddleware(authSvc auth.Jwt) gin.HandlerFunc {
 		
 		userID := claims.Subject
 		c.Set("user_id", userID)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
 
+// OptionalAuthenticateMiddleware lets a request without a token through as a
+// guest, a token that is sent still has to be valid
+func OptionalAuthenticateMiddleware(authSvc auth.Jwt) gin.HandlerFunc {
+	authenticate := AuthenticateMiddleware(authSvc)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
 func AuthorizeMiddleware(authSvc auth.Jwt, allowedRoles ...string) gin.HandlerFunc {
 	var baseLogFields = log.Fields{
 		"layer":     "middleware",
@@ -71,6 +85,7 @@ func AuthorizeMiddleware(authSvc auth.Jwt, allowedRoles ...string) gin.HandlerFu
 		for _, allowed := range allowedRoles {
 			if *role == allowed {
 				log.Info("[Middleware]: Role pass")
+				c.Set("role", *role)
 				c.Next()
 				return
 			}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,12 +26,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Save(c.Request.Context(), &oReq, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	order, err := h.service.Save(c.Request.Context(), &oReq, userID)
+	if err != nil {
+		orderError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "order created"})
+	c.JSON(http.StatusCreated, gin.H{"message": "order created", "data": order})
 }
 
 func (h *OrderHandler) UpdateOrder(c *gin.Context) {
@@ -49,16 +52,18 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if err := h.service.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		orderError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "order deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "order cancelled"})
 }
 
 func (h *OrderHandler) GetOrder(c *gin.Context) {
-	order, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	order, err := h.service.GetByID(c.Request.Context(), c.Param("id"), userID, role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		orderError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "get order success", "data": order})
@@ -72,3 +77,35 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "get orders success", "data": orders})
 }
+
+func (h *OrderHandler) GetMyOrders(c *gin.Context) {
+	var page model.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	status := strings.ToUpper(c.Query("status"))
+	orders, err := h.service.GetByUser(c.Request.Context(), userID, status, page)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get orders success", "data": orders})
+}
+
+// orderError answers 404 for an order that doesn't exist, 403 for one of
+// someone else, 400 for what the request got wrong and 500 otherwise
+func orderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrOrderAccess):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrOrderRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/payment"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookSize caps the webhook body read into memory
+const maxWebhookSize = 1 << 20
+
+type PaymentHandler struct {
+	service module.PaymentService
+}
+
+func NewPaymentHandler(service module.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+func (h *PaymentHandler) PayOrder(c *gin.Context) {
+	userID := c.GetString("user_id")
+	payment, err := h.service.Pay(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "payment created", "data": payment})
+}
+
+func (h *PaymentHandler) GetOrderPayments(c *gin.Context) {
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	payments, err := h.service.GetByOrderID(c.Request.Context(), c.Param("id"), userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get payments success", "data": payments})
+}
+
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	var refundReq model.PaymentRefundReq
+	if err := c.ShouldBindJSON(&refundReq); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.service.Refund(c.Request.Context(), c.Param("id"), refundReq.Amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "payment refunded", "data": payment})
+}
+
+// Webhook takes provider events, the signature is checked on the raw body.
+// A bad signature gets 400 so the provider does not retry it, any other
+// error 500 so it does.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.HandleWebhook(c.Request.Context(), payload, c.GetHeader("X-Payment-Signature"))
+	if errors.Is(err, payment.ErrInvalidSignature) || errors.Is(err, payment.ErrInvalidEvent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook received"})
+}
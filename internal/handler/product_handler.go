@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +80,96 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "get product success", "data": productRes})
 }
+
+// UploadProductImage takes a multipart form with the file in "image"
+func (h *ProductHandler) UploadProductImage(c *gin.Context) {
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxSize := appcore_config.Config.ProductImageMaxSize
+	if maxSize > 0 && fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": model.ErrImageSize.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// one byte over the limit is enough for the service to refuse it
+	reader := io.Reader(file)
+	if maxSize > 0 {
+		reader = io.LimitReader(file, maxSize+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	imageRes, err := h.service.UploadImage(c.Request.Context(), c.Param("id"), data, userID)
+	if errors.Is(err, model.ErrImageSize) || errors.Is(err, model.ErrImagePixels) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "product image uploaded", "data": imageRes})
+}
+
+func (h *ProductHandler) DeleteProductImage(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.DeleteImage(c.Request.Context(), c.Param("id"), c.Param("image_id"), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "product image deleted"})
+}
+
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	changes, err := h.service.GetPriceHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get price history success", "data": changes})
+}
+
+func (h *ProductHandler) SchedulePrice(c *gin.Context) {
+	var scheduleReq model.PriceScheduleReq
+	if err := c.ShouldBindJSON(&scheduleReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	change, err := h.service.SchedulePrice(c.Request.Context(), c.Param("id"), &scheduleReq, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "product price scheduled", "data": change})
+}
+
+func (h *ProductHandler) CancelScheduledPrice(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.CancelScheduledPrice(c.Request.Context(), c.Param("id"), c.Param("change_id"), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "scheduled price cancelled"})
+}
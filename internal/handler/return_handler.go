@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReturnHandler struct {
+	service module.ReturnService
+}
+
+func NewReturnHandler(service module.ReturnService) *ReturnHandler {
+	return &ReturnHandler{service: service}
+}
+
+func (h *ReturnHandler) RequestReturn(c *gin.Context) {
+	var returnReq model.ReturnReq
+	if err := c.ShouldBindJSON(&returnReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	r, err := h.service.Request(c.Request.Context(), c.Param("id"), &returnReq, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "return requested", "data": r})
+}
+
+func (h *ReturnHandler) DecideReturn(c *gin.Context) {
+	var decisionReq model.ReturnDecisionReq
+	if err := c.ShouldBindJSON(&decisionReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	r, err := h.service.Decide(c.Request.Context(), c.Param("id"), &decisionReq, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return updated", "data": r})
+}
+
+func (h *ReturnHandler) GetOrderReturns(c *gin.Context) {
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	returns, err := h.service.GetByOrderID(c.Request.Context(), c.Param("id"), userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get returns success", "data": returns})
+}
+
+func (h *ReturnHandler) GetSellerReturns(c *gin.Context) {
+	userID := c.GetString("user_id")
+	returns, err := h.service.GetBySeller(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get returns success", "data": returns})
+}
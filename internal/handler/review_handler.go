@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	service module.ReviewService
+}
+
+func NewReviewHandler(service module.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	var reviewReq model.ReviewReq
+	if err := c.ShouldBindJSON(&reviewReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	review, err := h.service.Save(c.Request.Context(), c.Param("id"), &reviewReq, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "review created", "data": review})
+}
+
+// GetProductReviews lists the published reviews of a product, ?page=1&size=20
+func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
+	h.getReviews(c, c.Param("id"), false)
+}
+
+// GetProductReviewsForModeration lists every review of a product, hidden ones included
+func (h *ReviewHandler) GetProductReviewsForModeration(c *gin.Context) {
+	h.getReviews(c, c.Param("product_id"), true)
+}
+
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	var moderateReq model.ReviewModerateReq
+	if err := c.ShouldBindJSON(&moderateReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.service.Moderate(c.Request.Context(), c.Param("id"), &moderateReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "review moderated", "data": review})
+}
+
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "review deleted"})
+}
+
+func (h *ReviewHandler) getReviews(c *gin.Context, productID string, withHidden bool) {
+	var page model.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.service.GetByProductID(c.Request.Context(), productID, page, withHidden)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get reviews success", "data": reviews})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SellerHandler struct {
+	productSvc module.ProductService
+	stockSvc   module.StockService
+	reportSvc  module.ReportService
+}
+
+func NewSellerHandler(productSvc module.ProductService, stockSvc module.StockService, reportSvc module.ReportService) *SellerHandler {
+	return &SellerHandler{
+		productSvc: productSvc,
+		stockSvc:   stockSvc,
+		reportSvc:  reportSvc,
+	}
+}
+
+func (h *SellerHandler) GetMyProducts(c *gin.Context) {
+	sellerID := c.GetString("user_id")
+	products, err := h.productSvc.GetBySeller(c.Request.Context(), sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get seller products success", "data": products})
+}
+
+func (h *SellerHandler) GetMyStocks(c *gin.Context) {
+	sellerID := c.GetString("user_id")
+	stocks, err := h.stockSvc.GetBySeller(c.Request.Context(), sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get seller stocks success", "data": stocks})
+}
+
+func (h *SellerHandler) GetMyOrders(c *gin.Context) {
+	var page model.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sellerID := c.GetString("user_id")
+	status := strings.ToUpper(c.Query("status"))
+	orders, err := h.reportSvc.GetSellerOrders(c.Request.Context(), sellerID, status, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get seller orders success", "data": orders})
+}
+
+func (h *SellerHandler) GetMySalesSummary(c *gin.Context) {
+	var req model.ReportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.reportSvc.GetSummary(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		reportError(c, err)
+		return
+	}
+	if req.IsCSV() {
+		writeCSV(c, "sales-summary.csv", summary.CSVRows())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get sales summary success", "data": summary})
+}
+
+func (h *SellerHandler) GetMyRevenue(c *gin.Context) {
+	var req model.ReportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points, err := h.reportSvc.GetRevenue(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		reportError(c, err)
+		return
+	}
+	if req.IsCSV() {
+		writeCSV(c, "revenue.csv", model.RevenueCSVRows(points))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get revenue success", "data": points})
+}
+
+func (h *SellerHandler) GetMyTopProducts(c *gin.Context) {
+	var req model.ReportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, err := h.reportSvc.GetTopProducts(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		reportError(c, err)
+		return
+	}
+	if req.IsCSV() {
+		writeCSV(c, "top-products.csv", model.TopProductsCSVRows(products))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get top products success", "data": products})
+}
+
+// reportError answers 400 for a bad range or period, 500 otherwise
+func reportError(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrReportDate) || errors.Is(err, model.ErrReportRange) || errors.Is(err, model.ErrReportPeriod) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// writeCSV sends rows as a CSV download, the first row is the header
+func writeCSV(c *gin.Context, filename string, rows [][]string) {
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(rows); err != nil {
+		c.Error(err)
+	}
+}
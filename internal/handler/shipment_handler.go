@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShipmentHandler struct {
+	service module.ShipmentService
+}
+
+func NewShipmentHandler(service module.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{service: service}
+}
+
+func (h *ShipmentHandler) ShipOrder(c *gin.Context) {
+	var shipmentReq model.ShipmentReq
+	if err := c.ShouldBindJSON(&shipmentReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	shipment, err := h.service.Ship(c.Request.Context(), c.Param("id"), &shipmentReq, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "order shipped", "data": shipment})
+}
+
+func (h *ShipmentHandler) AddShipmentEvent(c *gin.Context) {
+	var eventReq model.ShipmentEventReq
+	if err := c.ShouldBindJSON(&eventReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	shipment, err := h.service.AddEvent(c.Request.Context(), c.Param("id"), &eventReq, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "shipment updated", "data": shipment})
+}
+
+func (h *ShipmentHandler) GetShipment(c *gin.Context) {
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	shipment, err := h.service.GetByOrderID(c.Request.Context(), c.Param("id"), userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get shipment success", "data": shipment})
+}
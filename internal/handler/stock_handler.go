@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"net/http"
 
@@ -35,3 +37,93 @@ func (h *StockHandler) GetStock(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "get stock success", "data": stock})
 }
+
+func (h *StockHandler) AdjustStock(c *gin.Context) {
+	var adjustReq model.StockAdjustReq
+	if err := c.ShouldBindJSON(&adjustReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	stock, err := h.service.Adjust(c.Request.Context(), c.Param("product_id"), &adjustReq, userID)
+	if err != nil {
+		stockError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stock adjusted", "data": stock})
+}
+
+func (h *StockHandler) TransferStock(c *gin.Context) {
+	var transferReq model.StockTransferReq
+	if err := c.ShouldBindJSON(&transferReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	stock, err := h.service.Transfer(c.Request.Context(), c.Param("product_id"), &transferReq, userID)
+	if err != nil {
+		stockError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "stock transferred", "data": stock})
+}
+
+func (h *StockHandler) GetStockMovements(c *gin.Context) {
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+	movements, err := h.service.GetMovements(c.Request.Context(), c.Param("product_id"), userID, role)
+	if err != nil {
+		stockError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get stock movements success", "data": movements})
+}
+
+func (h *StockHandler) SetReorderThreshold(c *gin.Context) {
+	var thresholdReq model.StockThresholdReq
+	if err := c.ShouldBindJSON(&thresholdReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	stock, err := h.service.SetReorderThreshold(c.Request.Context(), c.Param("product_id"), thresholdReq.ReorderThreshold, userID)
+	if err != nil {
+		stockError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "reorder threshold updated", "data": stock})
+}
+
+func (h *StockHandler) Subscribe(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := h.service.Subscribe(c.Request.Context(), c.Param("product_id"), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "subscribed to back in stock alert"})
+}
+
+func (h *StockHandler) Unsubscribe(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := h.service.Unsubscribe(c.Request.Context(), c.Param("product_id"), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unsubscribed from back in stock alert"})
+}
+
+func stockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrStockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrStockAccess):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrStockRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	service module.TaxRuleService
+}
+
+func NewTaxHandler(service module.TaxRuleService) *TaxHandler {
+	return &TaxHandler{service: service}
+}
+
+func (h *TaxHandler) GetTaxRules(c *gin.Context) {
+	rules, err := h.service.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get tax rules success", "data": rules})
+}
+
+func (h *TaxHandler) CreateTaxRule(c *gin.Context) {
+	var ruleReq model.TaxRuleReq
+	if err := c.ShouldBindJSON(&ruleReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.Save(c.Request.Context(), &ruleReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "tax rule created", "data": rule})
+}
+
+func (h *TaxHandler) UpdateTaxRule(c *gin.Context) {
+	var ruleReq model.TaxRuleReq
+	if err := c.ShouldBindJSON(&ruleReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.Update(c.Request.Context(), &ruleReq, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax rule updated", "data": rule})
+}
+
+func (h *TaxHandler) DeleteTaxRule(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tax rule deleted"})
+}
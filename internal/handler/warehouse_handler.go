@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WarehouseHandler struct {
+	service module.WarehouseService
+}
+
+func NewWarehouseHandler(service module.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{
+		service: service,
+	}
+}
+
+func (h *WarehouseHandler) GetMyWarehouses(c *gin.Context) {
+	sellerID := c.GetString("user_id")
+	warehouses, err := h.service.GetBySeller(c.Request.Context(), sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get warehouses success", "data": warehouses})
+}
+
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var warehouseReq model.WarehouseReq
+	if err := c.ShouldBindJSON(&warehouseReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sellerID := c.GetString("user_id")
+	warehouse, err := h.service.Save(c.Request.Context(), &warehouseReq, sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "warehouse created", "data": warehouse})
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	var warehouseReq model.WarehouseReq
+	if err := c.ShouldBindJSON(&warehouseReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sellerID := c.GetString("user_id")
+	warehouse, err := h.service.Update(c.Request.Context(), &warehouseReq, c.Param("id"), sellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "warehouse updated", "data": warehouse})
+}
+
+func (h *WarehouseHandler) DeleteWarehouse(c *gin.Context) {
+	sellerID := c.GetString("user_id")
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), sellerID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "warehouse deleted"})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WishlistHandler struct {
+	service module.WishlistService
+}
+
+func NewWishlistHandler(service module.WishlistService) *WishlistHandler {
+	return &WishlistHandler{service: service}
+}
+
+func (h *WishlistHandler) AddWishlist(c *gin.Context) {
+	var wishlistReq model.WishlistReq
+	if err := c.ShouldBindJSON(&wishlistReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	item, err := h.service.Add(c.Request.Context(), &wishlistReq, userID)
+	if err != nil {
+		wishlistError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "added to wishlist", "data": item})
+}
+
+func (h *WishlistHandler) RemoveWishlist(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := h.service.Remove(c.Request.Context(), c.Param("product_id"), userID); err != nil {
+		wishlistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "removed from wishlist"})
+}
+
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	userID := c.GetString("user_id")
+	items, err := h.service.GetByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "get wishlist success", "data": items})
+}
+
+func wishlistError(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrWishlistProduct) || errors.Is(err, model.ErrWishlistFull) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
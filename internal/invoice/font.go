@@ -0,0 +1,248 @@
+package invoice
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	ErrFont = errors.New("invoice font must be a TrueType font file")
+)
+
+// Font and BoldFont are embedded in every invoice PDF when main loads them
+// from config, so text in any script they cover prints, Thai included.
+// Without Font the PDF falls back to Helvetica, which only has Latin-1. Bold
+// text is drawn with Font stroked when BoldFont is not set.
+var (
+	Font     *TrueTypeFont
+	BoldFont *TrueTypeFont
+)
+
+// TrueTypeFont is a parsed .ttf file, what the PDF needs to embed it whole and
+// lay out text with it. Glyphs are placed one after the other by their advance
+// width, without shaping, which marks of zero width like Thai vowels and tone
+// marks survive.
+type TrueTypeFont struct {
+	Name       string // PostScript name
+	data       []byte
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+	glyphs     map[rune]uint16
+	advances   []uint16
+}
+
+// ------------------------ Constructor ------------------------
+func LoadFont(path string) (*TrueTypeFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// ParseFont reads the tables of a TrueType font that a PDF needs. Fonts with
+// CFF outlines (.otf) and collections (.ttc) are not supported.
+func ParseFont(data []byte) (*TrueTypeFont, error) {
+	r := fontReader(data)
+	if version := r.u32(0); version != 0x00010000 && version != 0x74727565 { // 'true'
+		return nil, ErrFont
+	}
+
+	tables := make(map[string]fontReader)
+	for i := range int(r.u16(4)) {
+		record := 12 + 16*i
+		offset, length := int(r.u32(record+8)), int(r.u32(record+12))
+		if record+16 > len(data) || offset+length > len(data) {
+			return nil, ErrFont
+		}
+		tables[string(data[record:record+4])] = fontReader(data[offset : offset+length])
+	}
+
+	head, hhea, maxp, hmtx, cmap := tables["head"], tables["hhea"], tables["maxp"], tables["hmtx"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 || hmtx == nil || cmap == nil {
+		return nil, ErrFont
+	}
+
+	f := &TrueTypeFont{
+		Name:       "Embedded",
+		data:       data,
+		unitsPerEm: int(head.u16(18)),
+		bbox:       [4]int{int(head.i16(36)), int(head.i16(38)), int(head.i16(40)), int(head.i16(42))},
+		ascent:     int(hhea.i16(4)),
+		descent:    int(hhea.i16(6)),
+	}
+	if f.unitsPerEm == 0 {
+		return nil, ErrFont
+	}
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && os2.u16(0) >= 2 {
+		f.capHeight = int(os2.i16(88))
+	}
+	if name := postScriptName(tables["name"]); name != "" {
+		f.Name = name
+	}
+
+	// glyphs past the last metric share its advance width
+	numGlyphs, numMetrics := int(maxp.u16(4)), int(hhea.u16(34))
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, ErrFont
+	}
+	f.advances = make([]uint16, max(numGlyphs, numMetrics))
+	for i := range f.advances {
+		f.advances[i] = hmtx.u16(4 * min(i, numMetrics-1))
+	}
+
+	var err error
+	if f.glyphs, err = parseCmap(cmap); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ------------------------ Public Method ------------------------
+// Glyph is the glyph of r, 0 the font's "missing" glyph when it has none
+func (f *TrueTypeFont) Glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// Advance is the width of glyph in 1/1000 em, the unit of PDF font metrics
+func (f *TrueTypeFont) Advance(glyph uint16) int {
+	if int(glyph) >= len(f.advances) {
+		return 0
+	}
+	return f.scale(int(f.advances[glyph]))
+}
+
+// ------------------------ Private Method ------------------------
+func (f *TrueTypeFont) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// parseCmap maps characters to glyphs from the Unicode subtable, the full
+// repertoire of format 12 preferred over the BMP of format 4
+func parseCmap(cmap fontReader) (map[rune]uint16, error) {
+	format4, format12 := -1, -1
+	for i := range int(cmap.u16(2)) {
+		record := 4 + 8*i
+		platform, encoding, offset := cmap.u16(record), cmap.u16(record+2), int(cmap.u32(record+4))
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode || offset+4 > len(cmap) {
+			continue
+		}
+		switch cmap.u16(offset) {
+		case 4:
+			format4 = offset
+		case 12:
+			format12 = offset
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case format12 >= 0:
+		t := cmap[format12:]
+		groups := int(t.u32(12))
+		if 16+12*groups > len(t) {
+			return nil, ErrFont
+		}
+		for i := range groups {
+			group := 16 + 12*i
+			start, end, glyph := t.u32(group), t.u32(group+4), t.u32(group+8)
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				glyphs[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case format4 >= 0:
+		t := cmap[format4:]
+		segments := int(t.u16(6)) / 2
+		ends, starts, deltas, ranges := 14, 16+2*segments, 16+4*segments, 16+6*segments
+		if ranges+2*segments > len(t) {
+			return nil, ErrFont
+		}
+		for i := range segments {
+			start, end := int(t.u16(starts+2*i)), int(t.u16(ends+2*i))
+			delta, rangeOffset := t.u16(deltas+2*i), int(t.u16(ranges+2*i))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := uint16(c) + delta
+				if rangeOffset != 0 {
+					// idRangeOffset points from its own slot into the glyph array
+					at := ranges + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(t) {
+						return nil, ErrFont
+					}
+					if glyph = t.u16(at); glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					glyphs[rune(c)] = glyph
+				}
+			}
+		}
+	default:
+		return nil, ErrFont
+	}
+	return glyphs, nil
+}
+
+// postScriptName is name 6 of the name table, kept to the characters a PDF name allows
+func postScriptName(name fontReader) string {
+	if len(name) < 6 {
+		return ""
+	}
+	storage := int(name.u16(4))
+	for i := range int(name.u16(2)) {
+		record := 6 + 12*i
+		if record+12 > len(name) || name.u16(record+6) != 6 {
+			continue
+		}
+		platform, length, offset := name.u16(record), int(name.u16(record+8)), storage+int(name.u16(record+10))
+		if offset+length > len(name) {
+			continue
+		}
+		raw := name[offset : offset+length]
+
+		s := string(raw)
+		if platform == 0 || platform == 3 {
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = raw.u16(2 * j)
+			}
+			s = string(utf16.Decode(units))
+		}
+		return strings.Map(func(r rune) rune {
+			if r > ' ' && r < 0x7F && !strings.ContainsRune("()<>[]{}/%#", r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	return ""
+}
+
+// fontReader reads the big endian numbers of a font table, past the end reads zero
+type fontReader []byte
+
+func (r fontReader) u16(at int) uint16 {
+	if at < 0 || at+2 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(r[at:])
+}
+
+func (r fontReader) i16(at int) int16 {
+	return int16(r.u16(at))
+}
+
+func (r fontReader) u32(at int) uint32 {
+	if at < 0 || at+4 > len(r) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(r[at:])
+}
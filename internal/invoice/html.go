@@ -0,0 +1,59 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"taxLabel":     TaxLabel,
+	"addressLines": AddressLines,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; margin: 40px; }
+table { width: 100%; border-collapse: collapse; margin-top: 24px; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.num { text-align: right; }
+.parties { display: flex; gap: 64px; margin-top: 24px; }
+.total td { font-weight: bold; border-bottom: none; }
+</style>
+</head>
+<body>
+<h1>Invoice</h1>
+<p>
+Invoice no. {{.Number}}<br>
+Date {{.IssuedAt.Format "2006-01-02"}}<br>
+Order {{.OrderID}} ({{.Status}})
+</p>
+<div class="parties">
+<div>
+<strong>From</strong><br>
+{{.Seller.Name}}<br>
+{{.Seller.Email}}
+</div>
+<div>
+<strong>Bill to</strong><br>
+{{.Buyer.Name}}<br>
+{{.Buyer.Email}}{{range addressLines .Buyer.Address}}<br>
+{{.}}{{end}}
+</div>
+</div>
+<table>
+<tr><th>Item</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td class="num">{{.Quantity}}</td><td class="num">{{.UnitPrice}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}<tr><td colspan="3" class="num">Subtotal</td><td class="num">{{.Subtotal}}</td></tr>
+{{if not .Discount.IsZero}}<tr><td colspan="3" class="num">Discount{{if .CouponCode}} ({{.CouponCode}}){{end}}</td><td class="num">-{{.Discount}}</td></tr>
+{{end}}{{range .TaxLines}}<tr><td colspan="3" class="num">{{taxLabel .}}</td><td class="num">{{.Tax}}</td></tr>
+{{end}}<tr class="total"><td colspan="3" class="num">Total</td><td class="num">{{.Total}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func RenderHTML(w io.Writer, inv *Invoice) error {
+	return htmlTemplate.Execute(w, inv)
+}
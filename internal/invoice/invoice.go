@@ -0,0 +1,138 @@
+package invoice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-rebuild/internal/model"
+	"strings"
+	"time"
+)
+
+var (
+	ErrFormat = errors.New("invoice format must be pdf or html")
+)
+
+const (
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+
+	ContentTypePDF  = "application/pdf"
+	ContentTypeHTML = "text/html; charset=utf-8"
+)
+
+// Party is the seller or the buyer named on an invoice
+type Party struct {
+	Name    string
+	Email   string
+	Address *model.ShippingAddress
+}
+
+type Line struct {
+	Description string
+	Quantity    int
+	UnitPrice   model.Money
+	Amount      model.Money
+}
+
+// Invoice is an order laid out for rendering. It holds no state of its own,
+// the same order always renders the same invoice.
+type Invoice struct {
+	Number     string
+	IssuedAt   time.Time
+	OrderID    string
+	Status     string
+	Seller     Party
+	Buyer      Party
+	Lines      []Line
+	Subtotal   model.Money
+	Discount   model.Money
+	CouponCode string
+	TaxLines   []model.TaxLine
+	Total      model.Money
+}
+
+// ------------------------ Constructor ------------------------
+// New builds the invoice of an order of the product titled title. The number
+// is taken from the order, it is issued when the order was placed.
+func New(order *model.Order, title string, seller *model.User, buyer *model.User) *Invoice {
+	return &Invoice{
+		Number:   "INV-" + strings.ToUpper(order.ID),
+		IssuedAt: order.CreatedAt,
+		OrderID:  order.ID,
+		Status:   order.Status,
+		Seller:   Party{Name: seller.Username, Email: seller.Email},
+		Buyer:    Party{Name: buyer.Username, Email: buyer.Email, Address: order.ShippingAddress},
+		Lines: []Line{{
+			Description: title,
+			Quantity:    order.Quantity,
+			UnitPrice:   order.Price,
+			Amount:      order.Subtotal,
+		}},
+		Subtotal:   order.Subtotal,
+		Discount:   order.Discount,
+		CouponCode: order.CouponCode,
+		TaxLines:   order.TaxLines,
+		Total:      order.Amount,
+	}
+}
+
+// ------------------------ Public Method ------------------------
+func (inv *Invoice) Filename(ext string) string {
+	return strings.ToLower(inv.Number) + "." + ext
+}
+
+// TaxLabel names a tax line with its rate, the rate is in basis points
+func TaxLabel(line model.TaxLine) string {
+	label := fmt.Sprintf("%s %d.%02d%%", line.Name, line.Rate/100, line.Rate%100)
+	if line.Inclusive {
+		label += " (included)"
+	}
+	return label
+}
+
+// AddressLines is the address as it is printed, empty parts left out
+func AddressLines(a *model.ShippingAddress) []string {
+	if a == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, line := range []string{
+		a.Line1,
+		a.Line2,
+		strings.TrimSpace(strings.Join([]string{a.City, a.Region, a.PostalCode}, " ")),
+		a.Country,
+		a.Phone,
+	} {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Document is a rendered invoice
+type Document struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Render renders the invoice as "pdf" or "html"
+func Render(inv *Invoice, format string) (*Document, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatPDF:
+		if err := RenderPDF(&buf, inv); err != nil {
+			return nil, err
+		}
+		return &Document{Filename: inv.Filename(FormatPDF), ContentType: ContentTypePDF, Data: buf.Bytes()}, nil
+	case FormatHTML:
+		if err := RenderHTML(&buf, inv); err != nil {
+			return nil, err
+		}
+		return &Document{Filename: inv.Filename(FormatHTML), ContentType: ContentTypeHTML, Data: buf.Bytes()}, nil
+	}
+	return nil, ErrFormat
+}
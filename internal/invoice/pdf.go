@@ -0,0 +1,344 @@
+package invoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 in points, the unit PDF coordinates are in, with y going up from the bottom
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+)
+
+// columns of the item table, amounts are right aligned on their x
+const (
+	colItem   = margin
+	colQty    = 360.0
+	colUnit   = 460.0
+	colAmount = pageWidth - margin
+)
+
+// uppercase widths of Helvetica in 1/1000 em, digits and anything else not
+// listed is taken as 556. Only used to right align amounts, so close is enough.
+var helveticaUpper = map[rune]float64{
+	'A': 667, 'B': 667, 'C': 722, 'D': 722, 'E': 667, 'F': 611, 'G': 778, 'H': 722, 'I': 278,
+	'J': 500, 'K': 667, 'L': 556, 'M': 833, 'N': 722, 'O': 778, 'P': 667, 'Q': 778, 'R': 722,
+	'S': 667, 'T': 611, 'U': 722, 'V': 667, 'W': 944, 'X': 667, 'Y': 667, 'Z': 611,
+	' ': 278, '.': 278, ',': 278, '-': 333, '(': 333, ')': 333, '%': 889,
+}
+
+// pdfDoc writes text top to bottom on A4 pages. With Font loaded the text is
+// drawn in it, embedded whole as a CIDFontType2 font with the glyph ids as
+// the two byte codes (Identity-H), and a ToUnicode map keeps it searchable.
+// Without it the two standard Helvetica fonts are used, which every viewer
+// has, and text outside Latin-1 prints as '?'.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	y     float64
+	used  map[*TrueTypeFont]map[uint16]rune // glyphs drawn in each embedded font, for its widths and ToUnicode
+}
+
+// RenderPDF writes the invoice as a PDF document
+func RenderPDF(w io.Writer, inv *Invoice) error {
+	d := &pdfDoc{used: make(map[*TrueTypeFont]map[uint16]rune)}
+	d.newPage()
+
+	d.text(colItem, 20, true, "INVOICE")
+	d.next(28)
+	for _, line := range []string{
+		"Invoice no. " + inv.Number,
+		"Date " + inv.IssuedAt.Format("2006-01-02"),
+		"Order " + inv.OrderID + " (" + inv.Status + ")",
+	} {
+		d.text(colItem, 10, false, line)
+		d.next(14)
+	}
+
+	d.next(10)
+	top := d.y
+	d.party(colItem, "From", inv.Seller)
+	bottom := d.y
+	d.y = top
+	d.party(300, "Bill to", inv.Buyer)
+	d.y = min(d.y, bottom)
+
+	d.next(16)
+	d.text(colItem, 10, true, "Item")
+	d.textRight(colQty, 10, true, "Qty")
+	d.textRight(colUnit, 10, true, "Unit price")
+	d.textRight(colAmount, 10, true, "Amount")
+	d.next(6)
+	d.rule()
+	d.next(14)
+	for _, line := range inv.Lines {
+		d.text(colItem, 10, false, line.Description)
+		d.textRight(colQty, 10, false, fmt.Sprint(line.Quantity))
+		d.textRight(colUnit, 10, false, line.UnitPrice.String())
+		d.textRight(colAmount, 10, false, line.Amount.String())
+		d.next(16)
+	}
+	d.rule()
+	d.next(16)
+
+	d.total("Subtotal", inv.Subtotal.String(), false)
+	if !inv.Discount.IsZero() {
+		label := "Discount"
+		if inv.CouponCode != "" {
+			label += " (" + inv.CouponCode + ")"
+		}
+		d.total(label, "-"+inv.Discount.String(), false)
+	}
+	for _, line := range inv.TaxLines {
+		d.total(TaxLabel(line), line.Tax.String(), false)
+	}
+	d.total("Total", inv.Total.String(), true)
+
+	return d.writeTo(w)
+}
+
+// ------------------------ Private Method ------------------------
+func (d *pdfDoc) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// next moves down by dy, onto a new page when the bottom margin is reached
+func (d *pdfDoc) next(dy float64) {
+	d.y -= dy
+	if d.y < margin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDoc) text(x float64, size float64, bold bool, s string) {
+	page := d.pages[len(d.pages)-1]
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	if Font == nil {
+		fmt.Fprintf(page, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfString(s))
+		return
+	}
+	if bold && BoldFont == nil {
+		// no bold face, the regular one is filled and stroked to thicken it
+		fmt.Fprintf(page, "q 0.3 w BT 2 Tr /%s %.0f Tf %.2f %.2f Td <%s> Tj ET Q\n", font, size, x, d.y, d.glyphString(Font, s))
+		return
+	}
+	fmt.Fprintf(page, "BT /%s %.0f Tf %.2f %.2f Td <%s> Tj ET\n", font, size, x, d.y, d.glyphString(face(bold), s))
+}
+
+func (d *pdfDoc) textRight(x float64, size float64, bold bool, s string) {
+	d.text(x-textWidth(s, size, bold), size, bold, s)
+}
+
+// glyphString is s as the hex glyph ids of f, recording each glyph drawn
+func (d *pdfDoc) glyphString(f *TrueTypeFont, s string) string {
+	used := d.used[f]
+	if used == nil {
+		used = make(map[uint16]rune)
+		d.used[f] = used
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		glyph := f.Glyph(r)
+		if _, ok := used[glyph]; !ok {
+			used[glyph] = r
+		}
+		fmt.Fprintf(&b, "%04X", glyph)
+	}
+	return b.String()
+}
+
+func (d *pdfDoc) rule() {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, d.y, pageWidth-margin, d.y)
+}
+
+func (d *pdfDoc) party(x float64, title string, p Party) {
+	d.text(x, 10, true, title)
+	d.next(14)
+	for _, line := range append([]string{p.Name, p.Email}, AddressLines(p.Address)...) {
+		d.text(x, 10, false, line)
+		d.next(14)
+	}
+}
+
+func (d *pdfDoc) total(label string, amount string, bold bool) {
+	d.textRight(colUnit, 10, bold, label)
+	d.textRight(colAmount, 10, bold, amount)
+	d.next(16)
+}
+
+// writeTo writes the catalog, the page tree, both fonts, then a page and its
+// content stream per page, then the parts of each embedded font, followed by
+// the xref table of their offsets
+func (d *pdfDoc) writeTo(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := ""
+	for i := range d.pages {
+		kids += fmt.Sprintf("%d 0 R ", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(d.pages)))
+
+	// an embedded font takes 4 objects after the pages, the bold face shares
+	// the regular one's when there is no BoldFont
+	faces := []*TrueTypeFont{}
+	if Font != nil {
+		faces = append(faces, Font)
+		if BoldFont != nil && BoldFont != Font {
+			faces = append(faces, BoldFont)
+		}
+	}
+	first := 5 + 2*len(d.pages)
+	for _, bold := range []bool{false, true} {
+		if Font == nil {
+			base := map[bool]string{false: "Helvetica", true: "Helvetica-Bold"}[bold]
+			object("<< /Type /Font /Subtype /Type1 /BaseFont /" + base + " /Encoding /WinAnsiEncoding >>")
+			continue
+		}
+		f := face(bold)
+		at := first + 4*slices.Index(faces, f)
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", f.Name, at, at+3))
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	for i, f := range faces {
+		at := first + 4*i
+		used := d.used[f]
+		object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>", f.Name, at+1, f.widths(used)))
+		object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			f.Name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]), f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), at+2))
+
+		var file bytes.Buffer
+		zw := zlib.NewWriter(&file)
+		if _, err := zw.Write(f.data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream", file.Len(), len(f.data), file.String()))
+
+		cmap := toUnicode(used)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(cmap), cmap))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfString escapes s for a literal string in Latin-1
+func pdfString(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xFF:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+// face is the embedded font text of that weight is drawn in
+func face(bold bool) *TrueTypeFont {
+	if bold && BoldFont != nil {
+		return BoldFont
+	}
+	return Font
+}
+
+// widths is the W array of a CID font, the width of each glyph drawn
+func (f *TrueTypeFont) widths(used map[uint16]rune) string {
+	glyphs := slices.Sorted(maps.Keys(used))
+	var b strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&b, "%d [%d] ", glyph, f.Advance(glyph))
+	}
+	return b.String()
+}
+
+// toUnicode is the CMap from each glyph drawn back to its character, so the
+// text of the PDF can be searched and copied
+func toUnicode(used map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// at most 100 entries to a bfchar block
+	// the missing glyph stands for no one character
+	glyphs := slices.DeleteFunc(slices.Sorted(maps.Keys(used)), func(glyph uint16) bool { return glyph == 0 })
+	for chunk := range slices.Chunk(glyphs, 100) {
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, glyph := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{used[glyph]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+// textWidth is the width of s at size in points, in the embedded font when
+// there is one
+func textWidth(s string, size float64, bold bool) float64 {
+	if Font != nil {
+		f := face(bold)
+		width := 0
+		for _, r := range s {
+			width += f.Advance(f.Glyph(r))
+		}
+		return float64(width) * size / 1000
+	}
+
+	width := 0.0
+	for _, r := range s {
+		w, ok := helveticaUpper[r]
+		if !ok {
+			w = 556
+		}
+		width += w
+	}
+	return width * size / 1000
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"errors"
 	appcore_config "go-rebuild/cmd/go-rebuild/config"
+	"io"
 	"strconv"
 
 	log "github.com/sirupsen/logrus"
@@ -61,6 +62,39 @@ func (s *mailService) SendEmail(msg string, subject string, to []string) error {
 	return nil
 }
 
+func (s *mailService) SendEmailWithAttachments(msg string, subject string, to []string, attachments []Attachment) error {
+	var baseLogFields = log.Fields{
+		"sendTo":    to[0],
+		"layer":     "mail_service",
+		"operation": "send_email_with_attachments",
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", appcore_config.Config.EmailSMTPFrom)
+	m.SetHeader("To", to[0])
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", msg)
+
+	for _, attachment := range attachments {
+		data := attachment.Data
+		m.Attach(attachment.Filename,
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		)
+	}
+
+	if err := s.mailClient.DialAndSend(m); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("dial and send")
+		return ErrSendMessage
+	}
+
+	log.Info("[gomail]: send email with attachments success")
+	return nil
+}
+
 func (s *mailService) SendWelcomeEmail(to []string) error {
 	var baseLogFields = log.Fields{
 		"sendTo":   to[0],
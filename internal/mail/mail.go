@@ -2,6 +2,13 @@ package mail
 
 type Mail interface {
 	SendEmail(msg string, subject string, to []string) error
+	SendEmailWithAttachments(msg string, subject string, to []string, attachments []Attachment) error
 	SendWelcomeEmail(to []string) error 
 }
 
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
@@ -20,6 +20,11 @@ var (
 	StockExchangeName = "stock_exchange"
 	StockExchangeType = "topic"
 	StockQueueName    = "stock_queue"
+
+	// notification queue
+	NotificationExchangeName = "notification_exchange"
+	NotificationExchangeType = "topic"
+	NotificationQueueName    = "notification_queue"
 )
 
 
@@ -31,6 +36,12 @@ type MessageBroker interface {
 type ConsumerService interface {
 	EmailConsuming(queueName string, tag string) error
 	StockConsuming(queueName string, tag string) error
+	NotificationConsuming(queueName string, tag string) error
+}
+
+// Notifier pushes data to an online user, realtime.Realtime satisfies it
+type Notifier interface {
+	SendTo(userID string, data any) error
 }
 
 type ProducerService interface {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	"go-rebuild/internal/mail"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
+	"net/url"
+	"strings"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	log "github.com/sirupsen/logrus"
@@ -18,10 +21,27 @@ type messageBroker struct {
 }
 
 type consumerService struct {
-	mailSvc  mail.Mail
-	stockSvc module.StockService
-	userCh   *amqp.Channel
-	stockCh  *amqp.Channel
+	mailSvc        mail.Mail
+	stockSvc       module.StockService
+	userSvc        module.UserService
+	productSvc     module.ProductService
+	invoiceSvc     module.InvoiceService
+	wishlistSvc    module.WishlistService
+	notifier       Notifier
+	userCh         *amqp.Channel
+	stockCh        *amqp.Channel
+	notificationCh *amqp.Channel
+}
+
+// ConsumerDeps groups the services the consumers call into
+type ConsumerDeps struct {
+	MailSvc     mail.Mail
+	StockSvc    module.StockService
+	UserSvc     module.UserService
+	ProductSvc  module.ProductService
+	InvoiceSvc  module.InvoiceService
+	WishlistSvc module.WishlistService
+	Notifier    Notifier
 }
 
 type producerService struct {
@@ -48,6 +68,10 @@ func (m *messageBroker) StockConsuming(queueName string, tag string) error {
 	return m.consumerService.StockConsuming(queueName, tag)
 }
 
+func (m *messageBroker) NotificationConsuming(queueName string, tag string) error {
+	return m.consumerService.NotificationConsuming(queueName, tag)
+}
+
 // ------------------------ Publisher ------------------------
 func NewProducer(ch *amqp.Channel) ProducerService {
 	return &producerService{
@@ -75,12 +99,18 @@ func (p *producerService) Publishing(ctx context.Context, mqConf *model.MQConfig
 }
 
 // ------------------------ Consumer ------------------------
-func NewConsumer(userCh *amqp.Channel, stockCh *amqp.Channel, mailSvc mail.Mail, stockSvc module.StockService) ConsumerService {
+func NewConsumer(userCh *amqp.Channel, stockCh *amqp.Channel, notificationCh *amqp.Channel, deps ConsumerDeps) ConsumerService {
 	return &consumerService{
-		mailSvc:  mailSvc,
-		stockSvc: stockSvc,
-		userCh:   userCh,
-		stockCh:  stockCh,
+		mailSvc:        deps.MailSvc,
+		stockSvc:       deps.StockSvc,
+		userSvc:        deps.UserSvc,
+		productSvc:     deps.ProductSvc,
+		invoiceSvc:     deps.InvoiceSvc,
+		wishlistSvc:    deps.WishlistSvc,
+		notifier:       deps.Notifier,
+		userCh:         userCh,
+		stockCh:        stockCh,
+		notificationCh: notificationCh,
 	}
 }
 
@@ -104,27 +134,51 @@ func (c *consumerService) EmailConsuming(queueName string, tag string) error {
 			var user model.User
 			if err := json.Unmarshal(msg.Body, &user); err != nil {
 				log.WithError(err).Error("fail to unmarshal user")
+				msg.Nack(false, false)
 				continue
 			}
 
 			log.Println("user email: ", user.Email)
 
+			// a failed email is tried once more, then dropped
 			switch msg.RoutingKey {
 			case "user.create":
 				email := []string{string(user.Email)}
 				if err = c.mailSvc.SendWelcomeEmail(email); err != nil {
 					log.WithError(err).Error("user consume created fail")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
+				// the welcome email is out, a failed link is not worth sending it
+				// twice for, the user can ask for the link again
+				if appcore_config.Config.EmailVerificationRequired {
+					if err := c.sendVerification(&user); err != nil {
+						log.WithError(err).Error("user consume verification email fail")
+					}
+				}
 				msg.Ack(false)
 				log.Printf("[Consume]: Received by Consumer '%s': user created\n", msg.ConsumerTag)
 
+			case "user.verify_email":
+				if !appcore_config.Config.EmailVerificationRequired {
+					msg.Ack(false)
+					continue
+				}
+				if err := c.sendVerification(&user); err != nil {
+					log.WithError(err).Error("user consume verification email fail")
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': verification email\n", msg.ConsumerTag)
+
 			case "user.update":
 				email := []string{string(user.Email)}
 				subject := "User Update"
 				message := fmt.Sprintf("Your account %s has updated in go-rebuild project At %v", user.Email, user.UpdatedAt)
 				if err = c.mailSvc.SendEmail(message, subject, email); err != nil {
 					log.WithError(err).Error("user consume updated fail")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
 
@@ -133,6 +187,7 @@ func (c *consumerService) EmailConsuming(queueName string, tag string) error {
 
 			default:
 				log.Printf("[Consume]: unsupported message type: %s\n", msg.RoutingKey)
+				msg.Nack(false, false)
 			}
 		}
 	}()
@@ -155,18 +210,22 @@ func (c *consumerService) StockConsuming(queueName string, tag string) error {
 		return err
 	}
 
+	// a message that can't be read or routed is dropped, a failed change is
+	// tried once more
 	go func() {
 		for msg := range msgs {
-			var stock model.Stock
+			var stock model.StockMessage
 			if err := json.Unmarshal(msg.Body, &stock); err != nil {
 				log.WithError(err).Error("fail to unmarshal stock")
+				msg.Nack(false, false)
 				continue
 			}
 
 			switch msg.RoutingKey {
 			case "stock.create":
-				if err := c.stockSvc.Save(context.Background(), stock.ProductID, stock.Quantity); err != nil {
+				if err := c.stockSvc.Save(context.Background(), stock.ProductID, stock.Quantity, stock.ActorID); err != nil {
 					log.WithError(err).Error("stock consume save failed")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
 				msg.Ack(false)
@@ -174,24 +233,37 @@ func (c *consumerService) StockConsuming(queueName string, tag string) error {
 
 			case "stock.update":
 				log.Printf("[Consume in update stock]: Received by Consumer '%s': stock updated", msg.ConsumerTag)
-				if err := c.stockSvc.Update(context.Background(), stock.ProductID, stock.Quantity); err != nil {
+				if err := c.stockSvc.Update(context.Background(), stock.ProductID, stock.Quantity, stock.ActorID); err != nil {
 					log.WithError(err).Error("stock consume update failed")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
 				msg.Ack(false)
 				log.Printf("[Consume]: Received by Consumer '%s': stock updated", msg.ConsumerTag)
 
 			case "stock.increase":
-				if err := c.stockSvc.IncreaseQuantity(context.Background(), stock.Quantity, stock.ProductID); err != nil {
+				if err := c.stockSvc.IncreaseQuantity(context.Background(), stock.Quantity, stock.ProductID, model.StockRef{
+					Reason:      model.StockReasonOrderCancelled,
+					ReferenceID: stock.ReferenceID,
+					ActorID:     stock.ActorID,
+					WarehouseID: stock.WarehouseID,
+				}); err != nil {
 					log.WithError(err).Error("stock consume increase failed")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
 				msg.Ack(false)
 				log.Printf("[Consume]: Received by Consumer '%s': stock increase", msg.ConsumerTag)
 
 			case "stock.decrease":
-				if err := c.stockSvc.DecreaseQuantity(context.Background(), stock.Quantity, stock.ProductID); err != nil {
+				if err := c.stockSvc.DecreaseQuantity(context.Background(), stock.Quantity, stock.ProductID, model.StockRef{
+					Reason:      model.StockReasonOrderPlaced,
+					ReferenceID: stock.ReferenceID,
+					ActorID:     stock.ActorID,
+					WarehouseID: stock.WarehouseID,
+				}); err != nil {
 					log.WithError(err).Error("stock consume decrease failed")
+					msg.Nack(false, !msg.Redelivered)
 					continue
 				}
 				msg.Ack(false)
@@ -199,9 +271,248 @@ func (c *consumerService) StockConsuming(queueName string, tag string) error {
 
 			default:
 				log.Printf("[Consume]: Unsupported message type: %s", msg.RoutingKey)
+				msg.Nack(false, false)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *consumerService) NotificationConsuming(queueName string, tag string) error {
+	log.Printf("[Consume]: %s called", tag)
+	msgs, err := c.notificationCh.Consume(
+		queueName,
+		tag,
+		false, // autoAck
+		false, // exclusive
+		false, // noLocal
+		false, // noWait
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	// a message that can't be read or routed is dropped, a failed notification
+	// is tried once more
+	go func() {
+		for msg := range msgs {
+			switch msg.RoutingKey {
+			case "stock.low":
+				var alert model.StockAlert
+				if err := json.Unmarshal(msg.Body, &alert); err != nil {
+					log.WithError(err).Error("fail to unmarshal stock alert")
+					msg.Nack(false, false)
+					continue
+				}
+				if err := c.notifyLowStock(context.Background(), &alert); err != nil {
+					log.WithError(err).Error("notification consume stock low failed")
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': stock low", msg.ConsumerTag)
+
+			case "stock.back_in_stock":
+				var alert model.StockAlert
+				if err := json.Unmarshal(msg.Body, &alert); err != nil {
+					log.WithError(err).Error("fail to unmarshal stock alert")
+					msg.Nack(false, false)
+					continue
+				}
+				if err := c.notifyBackInStock(context.Background(), &alert); err != nil {
+					log.WithError(err).Error("notification consume back in stock failed")
+					// retry once, the subscribers already told are marked and skipped
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': back in stock", msg.ConsumerTag)
+
+			case "shipment.updated":
+				var notice model.ShipmentNotice
+				if err := json.Unmarshal(msg.Body, &notice); err != nil {
+					log.WithError(err).Error("fail to unmarshal shipment notice")
+					msg.Nack(false, false)
+					continue
+				}
+				if err := c.notifyShipment(context.Background(), &notice); err != nil {
+					log.WithError(err).Error("notification consume shipment updated failed")
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': shipment updated", msg.ConsumerTag)
+
+			case "product.price_changed":
+				var event model.PriceChangedEvent
+				if err := json.Unmarshal(msg.Body, &event); err != nil {
+					log.WithError(err).Error("fail to unmarshal price changed event")
+					msg.Nack(false, false)
+					continue
+				}
+				if err := c.notifyPriceDrop(context.Background(), &event); err != nil {
+					log.WithError(err).Error("notification consume price changed failed")
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': price changed", msg.ConsumerTag)
+
+			case "order.paid":
+				var event model.OrderEvent
+				if err := json.Unmarshal(msg.Body, &event); err != nil {
+					log.WithError(err).Error("fail to unmarshal order event")
+					msg.Nack(false, false)
+					continue
+				}
+				if err := c.invoiceSvc.SendPaid(context.Background(), event.OrderID); err != nil {
+					log.WithError(err).Error("notification consume order paid failed")
+					msg.Nack(false, !msg.Redelivered)
+					continue
+				}
+				msg.Ack(false)
+				log.Printf("[Consume]: Received by Consumer '%s': order paid", msg.ConsumerTag)
+
+			default:
+				log.Printf("[Consume]: Unsupported message type: %s", msg.RoutingKey)
+				msg.Nack(false, false)
 			}
 		}
 	}()
 
 	return nil
 }
+
+// notifyLowStock emails the seller who owns the product and pushes the alert
+// if they are online
+func (c *consumerService) notifyLowStock(ctx context.Context, alert *model.StockAlert) error {
+	product, err := c.productSvc.GetByID(ctx, alert.ProductID)
+	if err != nil {
+		return err
+	}
+
+	seller, err := c.userSvc.GetByID(ctx, product.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	subject := "Low stock: " + product.Title
+	message := fmt.Sprintf("Your product %s has %d item(s) left, at or below the reorder threshold of %d.", product.Title, alert.Quantity, alert.ReorderThreshold)
+	if err := c.mailSvc.SendEmail(message, subject, []string{seller.Email}); err != nil {
+		return err
+	}
+
+	c.push(seller.ID, model.NotificationStockLow, alert)
+	return nil
+}
+
+// notifyBackInStock tells every subscriber the product is available again and
+// marks their subscription sent. A subscriber that fails is logged and left
+// unmarked for the next alert, the error is only for the ones that reach nobody.
+func (c *consumerService) notifyBackInStock(ctx context.Context, alert *model.StockAlert) error {
+	product, err := c.productSvc.GetByID(ctx, alert.ProductID)
+	if err != nil {
+		return err
+	}
+
+	subs, err := c.stockSvc.GetSubscriptions(ctx, alert.StockID())
+	if err != nil {
+		return err
+	}
+
+	subject := "Back in stock: " + product.Title
+	message := fmt.Sprintf("%s is back in stock.", product.Title)
+	for _, sub := range subs {
+		if sub.IsNotified() {
+			continue
+		}
+
+		user, err := c.userSvc.GetByID(ctx, sub.UserID)
+		if err != nil {
+			log.WithError(err).Warnf("[Consume]: subscriber {%s} not found", sub.UserID)
+			continue
+		}
+
+		if err := c.mailSvc.SendEmail(message, subject, []string{user.Email}); err != nil {
+			log.WithError(err).Errorf("[Consume]: back in stock email to subscriber {%s}", sub.UserID)
+			continue
+		}
+		c.push(user.ID, model.NotificationBackInStock, alert)
+
+		if err := c.stockSvc.MarkNotified(ctx, sub.ID); err != nil {
+			log.WithError(err).Errorf("[Consume]: mark subscription {%s} notified", sub.ID)
+		}
+	}
+
+	return nil
+}
+
+// notifyShipment emails the buyer the tracking update and pushes it if they are online
+func (c *consumerService) notifyShipment(ctx context.Context, notice *model.ShipmentNotice) error {
+	buyer, err := c.userSvc.GetByID(ctx, notice.UserID)
+	if err != nil {
+		return err
+	}
+
+	subject := "Your order " + notice.OrderID + " is " + strings.ToLower(strings.ReplaceAll(notice.Status, "_", " "))
+	message := fmt.Sprintf("Order %s: %s with %s, tracking number %s.", notice.OrderID, notice.Status, notice.Carrier, notice.TrackingNumber)
+	if notice.Location != "" {
+		message += " Last seen at " + notice.Location + "."
+	}
+	if err := c.mailSvc.SendEmail(message, subject, []string{buyer.Email}); err != nil {
+		return err
+	}
+
+	c.push(buyer.ID, model.NotificationShipment, notice)
+	return nil
+}
+
+// sendVerification emails the user a link that verifies their email
+func (c *consumerService) sendVerification(user *model.User) error {
+	token, err := c.userSvc.NewVerificationToken(user)
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimRight(appcore_config.Config.PublicURL, "/") + "/auth/verify-email?token=" + url.QueryEscape(token)
+	subject := "Verify your email"
+	message := fmt.Sprintf(`Please verify your email for go-rebuild project: <a href="%s">%s</a><br>The link expires in %s.`, link, link, appcore_config.Config.EmailVerificationTTL)
+	return c.mailSvc.SendEmail(message, subject, []string{user.Email})
+}
+
+// notifyPriceDrop tells everyone who wishlisted the product it got cheaper,
+// the wishlist is kept so a later drop is sent too. A user that fails is
+// logged and skipped, a retry would email the others twice.
+func (c *consumerService) notifyPriceDrop(ctx context.Context, event *model.PriceChangedEvent) error {
+	items, err := c.wishlistSvc.GetByProductID(ctx, event.ProductID)
+	if err != nil {
+		return err
+	}
+
+	subject := "Price drop: " + event.Title
+	message := fmt.Sprintf("%s on your wishlist is now %s, down from %s.", event.Title, event.NewPrice, event.OldPrice)
+	for _, item := range items {
+		user, err := c.userSvc.GetByID(ctx, item.UserID)
+		if err != nil {
+			log.WithError(err).Warnf("[Consume]: wishlist user {%s} not found", item.UserID)
+			continue
+		}
+
+		if err := c.mailSvc.SendEmail(message, subject, []string{user.Email}); err != nil {
+			log.WithError(err).Errorf("[Consume]: price drop email to wishlist user {%s}", item.UserID)
+			continue
+		}
+		c.push(user.ID, model.NotificationPriceDrop, event)
+	}
+
+	return nil
+}
+
+// push sends a realtime notification, users who are offline only get the email
+func (c *consumerService) push(userID string, notificationType string, payload any) {
+	if err := c.notifier.SendTo(userID, model.Notification{Type: notificationType, Payload: payload}); err != nil {
+		log.Infof("[Consume]: skip realtime %s for user {%s}: %v", notificationType, userID, err)
+	}
+}
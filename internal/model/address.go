@@ -0,0 +1,106 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAddressRequired = errors.New("address needs a name, line1, city and country")
+)
+
+// Address is an entry of a user's address book, orders ship to the default
+// one unless another is picked
+type Address struct {
+	ID         string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	UserID     string    `gorm:"column:user_id;index" bson:"user_id" json:"user_id"`
+	Name       string    `gorm:"column:name" bson:"name" json:"name"`
+	Phone      string    `gorm:"column:phone" bson:"phone" json:"phone"`
+	Line1      string    `gorm:"column:line1" bson:"line1" json:"line1"`
+	Line2      string    `gorm:"column:line2" bson:"line2" json:"line2"`
+	City       string    `gorm:"column:city" bson:"city" json:"city"`
+	Region     string    `gorm:"column:region" bson:"region" json:"region"`
+	PostalCode string    `gorm:"column:postal_code" bson:"postal_code" json:"postal_code"`
+	Country    string    `gorm:"column:country" bson:"country" json:"country"`
+	IsDefault  bool      `gorm:"column:is_default" bson:"is_default" json:"is_default"`
+	CreatedAt  time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type AddressReq struct {
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+// ShippingAddress is the copy of an address kept on an order, later edits
+// to the address book don't change where an order went
+type ShippingAddress struct {
+	Name       string `bson:"name" json:"name"`
+	Phone      string `bson:"phone" json:"phone"`
+	Line1      string `bson:"line1" json:"line1"`
+	Line2      string `bson:"line2" json:"line2"`
+	City       string `bson:"city" json:"city"`
+	Region     string `bson:"region" json:"region"`
+	PostalCode string `bson:"postal_code" json:"postal_code"`
+	Country    string `bson:"country" json:"country"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *AddressReq) Verify() error {
+	for _, field := range []string{req.Name, req.Line1, req.City, req.Country} {
+		if strings.TrimSpace(field) == "" {
+			return ErrAddressRequired
+		}
+	}
+	return nil
+}
+
+func (req *AddressReq) ToAddress(userID string) *Address {
+	now := time.Now()
+	a := &Address{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		CreatedAt: now,
+	}
+	a.Apply(req)
+	a.IsDefault = req.IsDefault
+	return a
+}
+
+// Apply copies the request onto the address, a default address stays the
+// default until another one takes over
+func (a *Address) Apply(req *AddressReq) {
+	a.Name = strings.TrimSpace(req.Name)
+	a.Phone = strings.TrimSpace(req.Phone)
+	a.Line1 = strings.TrimSpace(req.Line1)
+	a.Line2 = strings.TrimSpace(req.Line2)
+	a.City = strings.TrimSpace(req.City)
+	a.Region = strings.TrimSpace(req.Region)
+	a.PostalCode = strings.TrimSpace(req.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	a.IsDefault = a.IsDefault || req.IsDefault
+	a.UpdatedAt = time.Now()
+}
+
+func (a *Address) ToShippingAddress() *ShippingAddress {
+	return &ShippingAddress{
+		Name:       a.Name,
+		Phone:      a.Phone,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
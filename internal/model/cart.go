@@ -0,0 +1,230 @@
+package model
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrCartEmpty    = errors.New("cart is empty")
+	ErrCartLine     = errors.New("cart line not found")
+	ErrCartFull     = errors.New("cart holds at most 50 lines")
+	ErrCartQuantity = errors.New("cart quantity must be between 1 and 99")
+	ErrCartProduct  = errors.New("product id is required")
+	ErrCartGuest    = errors.New("sign in or send the X-Cart-ID of a guest cart")
+	ErrCartChanged  = errors.New("cart changed since it was last shown, review it before checkout")
+)
+
+const (
+	CartMaxLines    = 50
+	CartMaxQuantity = 99
+	CartGuestIDSize = 16 // random bytes of a guest id, sent as hex
+
+	// problems a line can have when the cart is read
+	CartLineUnavailable = "UNAVAILABLE"  // the product or variant is gone
+	CartLineOutOfStock  = "OUT_OF_STOCK" // less stock than the line asks for
+)
+
+// CartOwner is the user of a cart, or the token of a guest cart before sign in
+type CartOwner struct {
+	UserID  string
+	GuestID string
+}
+
+// Cart is kept in Redis, not in the database, and expires when left alone
+type Cart struct {
+	Lines     []CartLine `json:"lines"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartLine keeps the price last shown to the buyer, so a change is pointed out
+type CartLine struct {
+	ProductID string    `json:"product_id"`
+	VariantID string    `json:"variant_id,omitempty"`
+	Quantity  int       `json:"quantity"`
+	Price     Money     `json:"price"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type CartLineReq struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type CartQuantityReq struct {
+	Quantity int `json:"quantity"` // 0 removes the line
+}
+
+// CheckoutReq is applied to every order of the cart, coupons go on single orders
+type CheckoutReq struct {
+	AddressID string `json:"address_id"`
+	Region    string `json:"region"`
+}
+
+type CartResp struct {
+	GuestID   string         `json:"guest_id,omitempty"` // send back as X-Cart-ID until signed in
+	Lines     []CartLineResp `json:"lines"`
+	Totals    []Money        `json:"totals"` // one amount per currency
+	Valid     bool           `json:"valid"`  // no line has a problem, the cart can be checked out
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type CartLineResp struct {
+	ID            string `json:"id"` // the variant id, or the product id without variants
+	ProductID     string `json:"product_id"`
+	VariantID     string `json:"variant_id,omitempty"`
+	Title         string `json:"title"`
+	Quantity      int    `json:"quantity"`
+	Price         Money  `json:"price"`
+	PreviousPrice *Money `json:"previous_price,omitempty"` // set once when the price changed since it was last shown
+	Subtotal      Money  `json:"subtotal"`
+	Available     int    `json:"available"`
+	Problem       string `json:"problem,omitempty"`
+}
+
+type CheckoutResp struct {
+	Orders []OrderResp `json:"orders"`
+}
+
+// ------------------------ Public Method ------------------------
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == ""
+}
+
+// Verify checks a guest id is one we handed out, users are always valid
+func (o CartOwner) Verify() error {
+	if !o.IsGuest() {
+		return nil
+	}
+	if b, err := hex.DecodeString(o.GuestID); err != nil || len(b) != CartGuestIDSize {
+		return ErrCartGuest
+	}
+	return nil
+}
+
+// Key names the cart in the cache, users and guests apart
+func (o CartOwner) Key() string {
+	if o.IsGuest() {
+		return "guest:" + o.GuestID
+	}
+	return "user:" + o.UserID
+}
+
+func (req *CartLineReq) Verify() error {
+	if req.ProductID == "" {
+		return ErrCartProduct
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 1 || req.Quantity > CartMaxQuantity {
+		return ErrCartQuantity
+	}
+	return nil
+}
+
+func (req *CartQuantityReq) Verify() error {
+	if req.Quantity < 0 || req.Quantity > CartMaxQuantity {
+		return ErrCartQuantity
+	}
+	return nil
+}
+
+// ID is the key of a line, the same as the stock it takes from
+func (l *CartLine) ID() string {
+	if l.VariantID != "" {
+		return l.VariantID
+	}
+	return l.ProductID
+}
+
+func (l *CartLine) ToOrderReq(req *CheckoutReq) *OrderReq {
+	return &OrderReq{
+		ProductID: l.ProductID,
+		VariantID: l.VariantID,
+		Quantity:  l.Quantity,
+		Region:    req.Region,
+		AddressID: req.AddressID,
+	}
+}
+
+func (c *Cart) IsEmpty() bool {
+	return len(c.Lines) == 0
+}
+
+// Add puts the item in the cart at price, adding to the line already there
+func (c *Cart) Add(req *CartLineReq, price Money) error {
+	line := CartLine{ProductID: req.ProductID, VariantID: req.VariantID, Quantity: req.Quantity, Price: price, AddedAt: time.Now()}
+	if i := c.find(line.ID()); i >= 0 {
+		quantity := c.Lines[i].Quantity + req.Quantity
+		if quantity > CartMaxQuantity {
+			return ErrCartQuantity
+		}
+		c.Lines[i].Quantity = quantity
+		c.Lines[i].Price = price
+		c.touch()
+		return nil
+	}
+
+	if len(c.Lines) >= CartMaxLines {
+		return ErrCartFull
+	}
+	c.Lines = append(c.Lines, line)
+	c.touch()
+	return nil
+}
+
+// SetQuantity changes the quantity of a line, 0 removes it
+func (c *Cart) SetQuantity(id string, quantity int) error {
+	i := c.find(id)
+	if i < 0 {
+		return ErrCartLine
+	}
+	if quantity == 0 {
+		return c.Remove(id)
+	}
+	c.Lines[i].Quantity = quantity
+	c.touch()
+	return nil
+}
+
+func (c *Cart) Remove(id string) error {
+	i := c.find(id)
+	if i < 0 {
+		return ErrCartLine
+	}
+	c.Lines = append(c.Lines[:i], c.Lines[i+1:]...)
+	c.touch()
+	return nil
+}
+
+// Merge adds the lines of a guest cart, quantities of the same item are
+// summed up to the maximum and lines past the limit are dropped
+func (c *Cart) Merge(guest *Cart) {
+	for _, line := range guest.Lines {
+		if i := c.find(line.ID()); i >= 0 {
+			c.Lines[i].Quantity = min(c.Lines[i].Quantity+line.Quantity, CartMaxQuantity)
+			continue
+		}
+		if len(c.Lines) < CartMaxLines {
+			c.Lines = append(c.Lines, line)
+		}
+	}
+	c.touch()
+}
+
+// ------------------------ Private Method ------------------------
+func (c *Cart) find(id string) int {
+	for i := range c.Lines {
+		if c.Lines[i].ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Cart) touch() {
+	c.UpdatedAt = time.Now()
+}
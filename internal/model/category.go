@@ -0,0 +1,165 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCategoryName   = errors.New("category name is required")
+	ErrCategorySlug   = errors.New("category slug may only hold a-z, 0-9 and '-'")
+	ErrCategoryParent = errors.New("category can't be placed under itself or its descendants")
+)
+
+// Category is a node of the catalog taxonomy, a root has an empty ParentID
+type Category struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	ParentID  string    `gorm:"column:parent_id;index" bson:"parent_id" json:"parent_id"`
+	Name      string    `gorm:"column:name" bson:"name" json:"name"`
+	Slug      string    `gorm:"column:slug;uniqueIndex" bson:"slug" json:"slug"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type CategoryReq struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"` // generated from the name when empty
+	ParentID string `json:"parent_id"`
+}
+
+type CategoryNode struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Slug     string         `json:"slug"`
+	Children []CategoryNode `json:"children"`
+}
+
+// ProductCategory assigns a product to a category, a product can sit in many
+type ProductCategory struct {
+	ID         string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	ProductID  string    `gorm:"column:product_id;uniqueIndex:idx_product_category" bson:"product_id"`
+	CategoryID string    `gorm:"column:category_id;uniqueIndex:idx_product_category;index" bson:"category_id"`
+	CreatedAt  time.Time `gorm:"column:created_at" bson:"created_at"`
+}
+
+type ProductCategoryReq struct {
+	CategoryIDs []string `json:"category_ids"`
+}
+
+// ------------------------ Public Method ------------------------
+// Verify checks the name and fills or normalizes the slug
+func (req *CategoryReq) Verify() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return ErrCategoryName
+	}
+
+	if req.Slug == "" {
+		req.Slug = Slugify(req.Name)
+	}
+	if req.Slug == "" || req.Slug != Slugify(req.Slug) {
+		return ErrCategorySlug
+	}
+	return nil
+}
+
+func (req *CategoryReq) ToCategory() *Category {
+	now := time.Now()
+	return &Category{
+		ID:        primitive.NewObjectID().Hex(),
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (c *Category) Apply(req *CategoryReq) {
+	c.Name = req.Name
+	c.Slug = req.Slug
+	c.ParentID = req.ParentID
+	c.UpdatedAt = time.Now()
+}
+
+func NewProductCategory(productID string, categoryID string) *ProductCategory {
+	return &ProductCategory{
+		ID:         primitive.NewObjectID().Hex(),
+		ProductID:  productID,
+		CategoryID: categoryID,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// Slugify lower-cases s and joins its letters and digits with single dashes
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// BuildCategoryTree nests a flat list of categories under their parents. A
+// category whose parent is missing is shown as a root.
+func BuildCategoryTree(categories []Category) []CategoryNode {
+	known := make(map[string]bool, len(categories))
+	children := make(map[string][]Category, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	for _, c := range categories {
+		parentID := c.ParentID
+		if !known[parentID] {
+			parentID = ""
+		}
+		children[parentID] = append(children[parentID], c)
+	}
+
+	var build func(parentID string) []CategoryNode
+	build = func(parentID string) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(children[parentID]))
+		for _, c := range children[parentID] {
+			nodes = append(nodes, CategoryNode{
+				ID:       c.ID,
+				Name:     c.Name,
+				Slug:     c.Slug,
+				Children: build(c.ID),
+			})
+		}
+		return nodes
+	}
+	return build("")
+}
+
+// DescendantIDs returns rootID and the ids of every category below it
+func DescendantIDs(categories []Category, rootID string) []string {
+	children := make(map[string][]string, len(categories))
+	for _, c := range categories {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+	}
+
+	ids := []string{rootID}
+	seen := map[string]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, childID := range children[ids[i]] {
+			if !seen[childID] {
+				seen[childID] = true
+				ids = append(ids, childID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Home & Garden", want: "home-garden"},
+		{in: "  Phones  ", want: "phones"},
+		{in: "4K TVs!", want: "4k-tvs"},
+		{in: "--already-a-slug--", want: "already-a-slug"},
+		{in: "เสื้อผ้า", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	categories := []Category{
+		{ID: "electronics", Slug: "electronics"},
+		{ID: "phones", ParentID: "electronics", Slug: "phones"},
+		{ID: "cases", ParentID: "phones", Slug: "cases"},
+		{ID: "laptops", ParentID: "electronics", Slug: "laptops"},
+		{ID: "books", Slug: "books"},
+		{ID: "orphan", ParentID: "deleted", Slug: "orphan"},
+	}
+
+	got := BuildCategoryTree(categories)
+	want := []CategoryNode{
+		{ID: "electronics", Slug: "electronics", Children: []CategoryNode{
+			{ID: "phones", Slug: "phones", Children: []CategoryNode{
+				{ID: "cases", Slug: "cases", Children: []CategoryNode{}},
+			}},
+			{ID: "laptops", Slug: "laptops", Children: []CategoryNode{}},
+		}},
+		{ID: "books", Slug: "books", Children: []CategoryNode{}},
+		{ID: "orphan", Slug: "orphan", Children: []CategoryNode{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tree\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDescendantIDs(t *testing.T) {
+	categories := []Category{
+		{ID: "electronics"},
+		{ID: "phones", ParentID: "electronics"},
+		{ID: "cases", ParentID: "phones"},
+		{ID: "books"},
+		{ID: "loop_a", ParentID: "loop_b"},
+		{ID: "loop_b", ParentID: "loop_a"},
+	}
+
+	tests := []struct {
+		name   string
+		rootID string
+		want   []string
+	}{
+		{name: "whole branch", rootID: "electronics", want: []string{"electronics", "phones", "cases"}},
+		{name: "leaf", rootID: "cases", want: []string{"cases"}},
+		{name: "unknown", rootID: "missing", want: []string{"missing"}},
+		{name: "loop ends", rootID: "loop_a", want: []string{"loop_a", "loop_b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescendantIDs(categories, tt.rootID); !slices.Equal(got, tt.want) {
+				t.Errorf("DescendantIDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package model
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	Email string // Custom claim
+	Email     string // Custom claim
+	SessionID string `json:"sid,omitempty"` // session of the refresh token it was issued with
 	jwt.RegisteredClaims
 }
 
@@ -14,3 +17,12 @@ type Claims struct {
 // Exp   time.Time // Expiration. expire in 1 hour
 // Iat   time.Time // Issued at
 // Iss   string    // Issuer
+// Jti   string    // ID, checked against the revoked tokens
+
+// Expiry is when the token runs out, zero for a token without exp
+func (c *Claims) Expiry() time.Time {
+	if c.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return c.ExpiresAt.Time
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCouponCode     = errors.New("coupon code is required")
+	ErrCouponType     = errors.New("coupon type must be PERCENT or FIXED")
+	ErrCouponValue    = errors.New("coupon value must be greater than zero, and at most 100 for PERCENT")
+	ErrCouponLimit    = errors.New("coupon limits and min spend can't be under zero")
+	ErrCouponWindow   = errors.New("coupon must end after it starts")
+	ErrCouponInactive = errors.New("coupon is not active")
+	ErrCouponExpired  = errors.New("coupon is not valid at this time")
+	ErrCouponScope    = errors.New("coupon does not apply to this product")
+	ErrCouponMinSpend = errors.New("order does not reach the coupon min spend")
+	ErrCouponUsedUp   = errors.New("coupon has no uses left")
+	ErrCouponUserUsed = errors.New("coupon was already used the allowed number of times")
+	ErrCouponCurrency = errors.New("coupon is for another currency")
+	// ErrCouponRedeem is a redemption that failed on our side, not on the coupon
+	ErrCouponRedeem = errors.New("fail to redeem coupon")
+)
+
+const (
+	CouponPercent = "PERCENT"
+	CouponFixed   = "FIXED"
+)
+
+// Coupon is a discount code. A seller or product scope limits it to orders of
+// that seller or product, MaxUses and MaxUsesPerUser of zero mean no limit.
+// A FIXED value and MinSpend are minor units of Currency.
+type Coupon struct {
+	ID             string     `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	Code           string     `gorm:"column:code;uniqueIndex" bson:"code" json:"code"`
+	Type           string     `gorm:"column:type" bson:"type" json:"type"`
+	Value          int64      `gorm:"column:value" bson:"value" json:"value"` // percent for PERCENT, amount off for FIXED
+	MinSpend       int64      `gorm:"column:min_spend" bson:"min_spend" json:"min_spend"`
+	Currency       string     `gorm:"column:currency" bson:"currency" json:"currency"`
+	MaxUses        int        `gorm:"column:max_uses" bson:"max_uses" json:"max_uses"`
+	MaxUsesPerUser int        `gorm:"column:max_uses_per_user" bson:"max_uses_per_user" json:"max_uses_per_user"`
+	Used           int        `gorm:"column:used" bson:"used" json:"used"`
+	SellerID       string     `gorm:"column:seller_id" bson:"seller_id" json:"seller_id,omitempty"`
+	ProductID      string     `gorm:"column:product_id" bson:"product_id" json:"product_id,omitempty"`
+	StartsAt       *time.Time `gorm:"column:starts_at" bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt         *time.Time `gorm:"column:ends_at" bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Active         bool       `gorm:"column:active" bson:"active" json:"active"`
+	CreatedAt      time.Time  `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type CouponReq struct {
+	Code           string     `json:"code"`
+	Type           string     `json:"type"`
+	Value          int64      `json:"value"`
+	MinSpend       int64      `json:"min_spend"`
+	Currency       string     `json:"currency"` // empty is the default currency
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	SellerID       string     `json:"seller_id"`
+	ProductID      string     `json:"product_id"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Active         bool       `json:"active"`
+}
+
+// CouponRedemption is one use of a coupon by an order, it is removed again
+// when the unpaid order is cancelled. Under a per-user limit each use takes one
+// of the user's numbered slots, the unique index lets two orders never share one.
+type CouponRedemption struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	CouponID  string    `gorm:"column:coupon_id;index;uniqueIndex:idx_coupon_redemption_slot" bson:"coupon_id"`
+	UserID    string    `gorm:"column:user_id;index;uniqueIndex:idx_coupon_redemption_slot" bson:"user_id"`
+	Slot      *int      `gorm:"column:slot;uniqueIndex:idx_coupon_redemption_slot" bson:"slot,omitempty"`
+	OrderID   string    `gorm:"column:order_id;uniqueIndex" bson:"order_id"`
+	Discount  Money     `gorm:"embedded;embeddedPrefix:discount_" bson:"discount"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at"`
+}
+
+// ------------------------ Public Method ------------------------
+// NormalizeCouponCode makes codes case insensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (req *CouponReq) Verify() error {
+	req.Code = NormalizeCouponCode(req.Code)
+	if req.Code == "" {
+		return ErrCouponCode
+	}
+
+	switch req.Type {
+	case CouponPercent:
+		if req.Value <= 0 || req.Value > 100 {
+			return ErrCouponValue
+		}
+	case CouponFixed:
+		if req.Value <= 0 {
+			return ErrCouponValue
+		}
+	default:
+		return ErrCouponType
+	}
+
+	if req.MinSpend < 0 || req.MaxUses < 0 || req.MaxUsesPerUser < 0 {
+		return ErrCouponLimit
+	}
+
+	if req.Currency == "" {
+		req.Currency = DefaultCurrency
+	}
+	if err := NewMoney(req.MinSpend, req.Currency).Verify(); err != nil {
+		return err
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return ErrCouponWindow
+	}
+	return nil
+}
+
+func (req *CouponReq) ToCoupon() *Coupon {
+	now := time.Now()
+	coupon := &Coupon{
+		ID:        primitive.NewObjectID().Hex(),
+		CreatedAt: now,
+	}
+	coupon.Apply(req)
+	return coupon
+}
+
+// Apply copies every field of req, Used is kept
+func (c *Coupon) Apply(req *CouponReq) {
+	c.Code = req.Code
+	c.Type = req.Type
+	c.Value = req.Value
+	c.MinSpend = req.MinSpend
+	c.Currency = req.Currency
+	c.MaxUses = req.MaxUses
+	c.MaxUsesPerUser = req.MaxUsesPerUser
+	c.SellerID = req.SellerID
+	c.ProductID = req.ProductID
+	c.StartsAt = req.StartsAt
+	c.EndsAt = req.EndsAt
+	c.Active = req.Active
+	c.UpdatedAt = time.Now()
+}
+
+// Settings are the columns an admin edits, Used is only moved by redemptions
+func (c *Coupon) Settings() map[string]any {
+	return map[string]any{
+		"code":              c.Code,
+		"type":              c.Type,
+		"value":             c.Value,
+		"min_spend":         c.MinSpend,
+		"currency":          c.Currency,
+		"max_uses":          c.MaxUses,
+		"max_uses_per_user": c.MaxUsesPerUser,
+		"seller_id":         c.SellerID,
+		"product_id":        c.ProductID,
+		"starts_at":         c.StartsAt,
+		"ends_at":           c.EndsAt,
+		"active":            c.Active,
+		"updated_at":        c.UpdatedAt,
+	}
+}
+
+// Check tells whether the coupon can be used at now on a subtotal of a product
+// of sellerID. The per-user limit needs the redemptions and is checked apart.
+func (c *Coupon) Check(now time.Time, productID string, sellerID string, subtotal Money) error {
+	if !c.Active {
+		return ErrCouponInactive
+	}
+	if (c.StartsAt != nil && now.Before(*c.StartsAt)) || (c.EndsAt != nil && !now.Before(*c.EndsAt)) {
+		return ErrCouponExpired
+	}
+	if (c.SellerID != "" && c.SellerID != sellerID) || (c.ProductID != "" && c.ProductID != productID) {
+		return ErrCouponScope
+	}
+	// a PERCENT coupon without a min spend works in any currency
+	if (c.Type == CouponFixed || c.MinSpend > 0) && subtotal.Currency != c.Currency {
+		return ErrCouponCurrency
+	}
+	if subtotal.Amount < c.MinSpend {
+		return ErrCouponMinSpend
+	}
+	if c.MaxUses > 0 && c.Used >= c.MaxUses {
+		return ErrCouponUsedUp
+	}
+	return nil
+}
+
+// Discount is what the coupon takes off subtotal, never more than subtotal.
+// A percentage rounds down, see Rounding.
+func (c *Coupon) Discount(subtotal Money) Money {
+	if c.Type == CouponPercent {
+		return subtotal.PercentOff(c.Value)
+	}
+	return NewMoney(c.Value, subtotal.Currency).Min(subtotal)
+}
+
+// RedemptionSlot picks the slot of userID's next use among the redemptions of
+// the coupon, the lowest one free. ok is false when they used every use they
+// have, redemptions from before slots count against the limit too.
+func (c *Coupon) RedemptionSlot(redemptions []CouponRedemption, userID string) (slot int, ok bool) {
+	taken := make(map[int]bool)
+	used := 0
+	for _, redemption := range redemptions {
+		if redemption.UserID != userID {
+			continue
+		}
+		used++
+		if redemption.Slot != nil {
+			taken[*redemption.Slot] = true
+		}
+	}
+	if used >= c.MaxUsesPerUser {
+		return 0, false
+	}
+
+	for slot = 1; slot <= c.MaxUsesPerUser; slot++ {
+		if !taken[slot] {
+			return slot, true
+		}
+	}
+	return 0, false
+}
+
+func NewCouponRedemption(couponID string, userID string, orderID string, discount Money) *CouponRedemption {
+	return &CouponRedemption{
+		ID:        primitive.NewObjectID().Hex(),
+		CouponID:  couponID,
+		UserID:    userID,
+		OrderID:   orderID,
+		Discount:  discount,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCouponCheck(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	base := Coupon{Type: CouponPercent, Value: 10, Currency: "THB", Active: true}
+
+	tests := []struct {
+		name     string
+		edit     func(c *Coupon)
+		subtotal Money
+		wantErr  error
+	}{
+		{name: "usable", edit: func(c *Coupon) {}, subtotal: NewMoney(1000, "THB")},
+		{name: "inactive", edit: func(c *Coupon) { c.Active = false }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponInactive},
+		{name: "not started", edit: func(c *Coupon) { c.StartsAt = &after }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponExpired},
+		{name: "ended", edit: func(c *Coupon) { c.EndsAt = &before }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponExpired},
+		{name: "ends right now", edit: func(c *Coupon) { c.EndsAt = &now }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponExpired},
+		{name: "another seller", edit: func(c *Coupon) { c.SellerID = "seller_2" }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponScope},
+		{name: "another product", edit: func(c *Coupon) { c.ProductID = "product_2" }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponScope},
+		{name: "under min spend", edit: func(c *Coupon) { c.MinSpend = 1001 }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponMinSpend},
+		{name: "min spend in another currency", edit: func(c *Coupon) { c.MinSpend = 100 }, subtotal: NewMoney(1000, "USD"), wantErr: ErrCouponCurrency},
+		{name: "fixed in another currency", edit: func(c *Coupon) { c.Type, c.Value = CouponFixed, 100 }, subtotal: NewMoney(1000, "USD"), wantErr: ErrCouponCurrency},
+		{name: "percent in any currency", edit: func(c *Coupon) {}, subtotal: NewMoney(1000, "USD")},
+		{name: "used up", edit: func(c *Coupon) { c.MaxUses, c.Used = 3, 3 }, subtotal: NewMoney(1000, "THB"), wantErr: ErrCouponUsedUp},
+		{name: "one use left", edit: func(c *Coupon) { c.MaxUses, c.Used = 3, 2 }, subtotal: NewMoney(1000, "THB")},
+		{name: "no use limit", edit: func(c *Coupon) { c.Used = 1000 }, subtotal: NewMoney(1000, "THB")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := base
+			tt.edit(&coupon)
+			if err := coupon.Check(now, "product_1", "seller_1", tt.subtotal); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		subtotal int64
+		want     int64
+	}{
+		{name: "percent rounds down", coupon: Coupon{Type: CouponPercent, Value: 15}, subtotal: 999, want: 149},
+		{name: "fixed", coupon: Coupon{Type: CouponFixed, Value: 300}, subtotal: 1000, want: 300},
+		{name: "fixed never more than the subtotal", coupon: Coupon{Type: CouponFixed, Value: 3000}, subtotal: 1000, want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.Discount(NewMoney(tt.subtotal, "THB")); got != NewMoney(tt.want, "THB") {
+				t.Errorf("Discount = %+v, want %d THB", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCouponRedemptionSlot(t *testing.T) {
+	slot := func(n int) *int { return &n }
+	redemption := func(userID string, n *int) CouponRedemption {
+		return CouponRedemption{UserID: userID, Slot: n}
+	}
+
+	tests := []struct {
+		name        string
+		perUser     int
+		redemptions []CouponRedemption
+		wantSlot    int
+		wantOK      bool
+	}{
+		{name: "first use", perUser: 2, wantSlot: 1, wantOK: true},
+		{name: "next free slot", perUser: 2, redemptions: []CouponRedemption{redemption("u1", slot(1))}, wantSlot: 2, wantOK: true},
+		{name: "released slot is reused", perUser: 2, redemptions: []CouponRedemption{redemption("u1", slot(2))}, wantSlot: 1, wantOK: true},
+		{name: "every use taken", perUser: 2, redemptions: []CouponRedemption{redemption("u1", slot(1)), redemption("u1", slot(2))}},
+		{name: "other users don't count", perUser: 1, redemptions: []CouponRedemption{redemption("u2", slot(1))}, wantSlot: 1, wantOK: true},
+		{name: "use from before slots counts", perUser: 1, redemptions: []CouponRedemption{redemption("u1", nil)}},
+		{name: "use from before slots leaves a slot", perUser: 2, redemptions: []CouponRedemption{redemption("u1", nil)}, wantSlot: 1, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := Coupon{MaxUsesPerUser: tt.perUser}
+			got, ok := coupon.RedemptionSlot(tt.redemptions, "u1")
+			if got != tt.wantSlot || ok != tt.wantOK {
+				t.Errorf("RedemptionSlot = %d, %v, want %d, %v", got, ok, tt.wantSlot, tt.wantOK)
+			}
+		})
+	}
+}
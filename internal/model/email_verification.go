@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrVerificationToken  = errors.New("invalid or expired verification link")
+	ErrVerificationSecret = errors.New("email verification needs SECRET_KEY to be set")
+	ErrEmailVerified      = errors.New("email is already verified")
+	ErrEmailNotVerified   = errors.New("verify your email before signing in")
+)
+
+// EmailVerification is what a verification link carries, signed so it cannot
+// be made up. It is spent once the email is verified, and a link sent to an
+// email the user has since changed does not verify the new one.
+type EmailVerification struct {
+	UserID    string `json:"uid"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
+}
+
+// ------------------------ Constructor ------------------------
+func NewEmailVerification(user *User, ttl time.Duration) *EmailVerification {
+	return &EmailVerification{
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	}
+}
+
+// ------------------------ Public Method ------------------------
+func (v *EmailVerification) IsExpired(now time.Time) bool {
+	return now.Unix() > v.ExpiresAt
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type DBStats struct {
+	Driver             string        `json:"driver"`
+	Up                 bool          `json:"up"`
+	PingLatency        time.Duration `json:"-"`
+	PingLatencyMs      float64       `json:"ping_latency_ms"`
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"-"`
+	WaitDurationMs     float64       `json:"wait_duration_ms"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// ------------------------ Public Method ------------------------
+func (s *DBStats) SetPingLatency(d time.Duration) {
+	s.PingLatency = d
+	s.PingLatencyMs = float64(d) / float64(time.Millisecond)
+}
+
+func (s *DBStats) SetWaitDuration(d time.Duration) {
+	s.WaitDuration = d
+	s.WaitDurationMs = float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var (
+	ErrCurrency         = errors.New("currency must be a 3 letter ISO 4217 code")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrMoneyAmount      = errors.New("amount is not a number in major units of its currency")
+)
+
+// DefaultCurrency is given to amounts that arrive as a bare number, from
+// clients or from rows written before Money existed. Those numbers are in
+// major units, 19.99 is 1999 satang. main sets it from config.
+var DefaultCurrency = "THB"
+
+// currencies whose minor unit is not a hundredth, the rest have 2 decimals
+var oddDecimals = map[string]int{
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3, "JOD": 3, "TND": 3,
+}
+
+// Rounding picks how a fraction of a minor unit is resolved. The rules used
+// across the shop:
+//   - discounts round down, a percentage never takes off more than it says
+//   - tax rounds half up on each order line, before lines are summed
+//   - nothing is rounded twice, totals only add amounts already rounded
+type Rounding int
+
+const (
+	RoundDown Rounding = iota
+	RoundHalfUp
+)
+
+// Money is an amount in the minor unit of its currency (cents, satang), so
+// sums never lose precision. In postgres it is embedded as two columns,
+// <prefix>amount and <prefix>currency.
+type Money struct {
+	Amount   int64  `gorm:"column:amount" bson:"amount" json:"amount"`
+	Currency string `gorm:"column:currency" bson:"currency" json:"currency"`
+}
+
+// ------------------------ Public Method ------------------------
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MinorUnits is how many minor units make one major unit of currency, 100 for THB
+func MinorUnits(currency string) int64 {
+	unit := int64(1)
+	for range currencyDecimals(currency) {
+		unit *= 10
+	}
+	return unit
+}
+
+// ParseMoney reads an amount in major units, "19.99" or "-5", exactly. More
+// decimals than the currency has are an error rather than rounded away.
+func ParseMoney(major string, currency string) (Money, error) {
+	sign, digits := int64(1), major
+	if rest, ok := strings.CutPrefix(digits, "-"); ok {
+		sign, digits = -1, rest
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	decimals := currencyDecimals(currency)
+	if whole == "" || len(fraction) > decimals || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, ErrMoneyAmount
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrMoneyAmount
+	}
+	return NewMoney(sign*amount, currency), nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Verify checks the currency code and that the amount is not negative
+func (m Money) Verify() error {
+	if len(m.Currency) != 3 || strings.ToUpper(m.Currency) != m.Currency {
+		return ErrCurrency
+	}
+	for _, r := range m.Currency {
+		if r < 'A' || r > 'Z' {
+			return ErrCurrency
+		}
+	}
+	if m.Amount < 0 {
+		return ErrQuantity
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return NewMoney(m.Amount-other.Amount, m.Currency), nil
+}
+
+func (m Money) Mul(n int64) Money {
+	return NewMoney(m.Amount*n, m.Currency)
+}
+
+// MulRat returns m * num / den rounded with r
+func (m Money) MulRat(num int64, den int64, r Rounding) Money {
+	product := m.Amount * num
+	amount := product / den
+	if r == RoundHalfUp && 2*(product%den) >= den {
+		amount++
+	}
+	return NewMoney(amount, m.Currency)
+}
+
+// PercentOff is the discount of percent on m, rounded down
+func (m Money) PercentOff(percent int64) Money {
+	return m.MulRat(percent, 100, RoundDown)
+}
+
+// TaxAt is the tax on m at a rate in basis points (700 is 7%), rounded half up
+func (m Money) TaxAt(basisPoints int64) Money {
+	return m.MulRat(basisPoints, 10000, RoundHalfUp)
+}
+
+// Min returns the smaller of two amounts of the same currency
+func (m Money) Min(other Money) Money {
+	if other.Amount < m.Amount {
+		return other
+	}
+	return m
+}
+
+// SumMoney adds amounts that have to share a currency, as the lines of one order
+func SumMoney(amounts []Money) (Money, error) {
+	if len(amounts) == 0 {
+		return Money{Currency: DefaultCurrency}, nil
+	}
+
+	total := NewMoney(0, amounts[0].Currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// String formats the amount in major units, "19.99 THB"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal is the amount in major units without the currency, "19.99"
+func (m Money) Decimal() string {
+	decimals := currencyDecimals(m.Currency)
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if decimals == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := MinorUnits(m.Currency)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, decimals, amount%unit)
+}
+
+// UnmarshalJSON takes {"amount":1999,"currency":"THB"} in minor units or, as
+// older clients and cached rows send it, a bare number in major units of the
+// default currency, 19.99
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] != '{' {
+		var major json.Number
+		if err := json.Unmarshal(data, &major); err != nil {
+			return err
+		}
+		money, err := ParseMoney(major.String(), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = money
+		return nil
+	}
+
+	type plain Money
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = Money(p)
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
+
+// UnmarshalBSONValue reads the embedded document, or a bare number in major
+// units left by documents written before Money existed
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		*m = Money{}
+		return nil
+	case bson.TypeInt32, bson.TypeInt64:
+		amount, _ := raw.AsInt64OK()
+		*m = NewMoney(amount*MinorUnits(DefaultCurrency), DefaultCurrency)
+		return nil
+	case bson.TypeDouble:
+		money, err := ParseMoney(strconv.FormatFloat(raw.Double(), 'f', -1, 64), DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("money: can't read %v as an amount: %w", raw.Double(), err)
+		}
+		*m = money
+		return nil
+	}
+
+	type plain Money
+	var p plain
+	if err := raw.Unmarshal(&p); err != nil {
+		return err
+	}
+	*m = Money(p)
+	return nil
+}
+
+// ------------------------ Private Method ------------------------
+func currencyDecimals(currency string) int {
+	if decimals, ok := oddDecimals[currency]; ok {
+		return decimals
+	}
+	return 2
+}
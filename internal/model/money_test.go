@@ -0,0 +1,164 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		major    string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{name: "two decimals", major: "19.99", currency: "THB", want: 1999},
+		{name: "one decimal", major: "19.9", currency: "THB", want: 1990},
+		{name: "whole number", major: "19", currency: "THB", want: 1900},
+		{name: "negative", major: "-5.05", currency: "USD", want: -505},
+		{name: "no minor unit", major: "1500", currency: "JPY", want: 1500},
+		{name: "three decimals", major: "1.234", currency: "KWD", want: 1234},
+		{name: "more decimals than the currency has", major: "19.999", currency: "THB", wantErr: ErrMoneyAmount},
+		{name: "decimals on a currency without them", major: "1500.5", currency: "JPY", wantErr: ErrMoneyAmount},
+		{name: "no whole part", major: ".5", currency: "THB", wantErr: ErrMoneyAmount},
+		{name: "sign after the point", major: "1.-5", currency: "THB", wantErr: ErrMoneyAmount},
+		{name: "exponent", major: "1e3", currency: "THB", wantErr: ErrMoneyAmount},
+		{name: "empty", major: "", currency: "THB", wantErr: ErrMoneyAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.major, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != NewMoney(tt.want, tt.currency) {
+				t.Errorf("ParseMoney(%q) = %+v, want %d %s", tt.major, got, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want int64
+	}{
+		{name: "half up rounds a half up", got: NewMoney(5, "THB").MulRat(1, 2, RoundHalfUp), want: 3},
+		{name: "half up rounds below a half down", got: NewMoney(4, "THB").MulRat(1, 3, RoundHalfUp), want: 1},
+		{name: "down drops the fraction", got: NewMoney(5, "THB").MulRat(1, 2, RoundDown), want: 2},
+		{name: "percent off never takes more", got: NewMoney(999, "THB").PercentOff(15), want: 149},
+		{name: "percent off exact", got: NewMoney(1000, "THB").PercentOff(15), want: 150},
+		{name: "tax at 7% rounds half up", got: NewMoney(1050, "THB").TaxAt(700), want: 74},
+		{name: "tax at 7% rounds down below a half", got: NewMoney(1049, "THB").TaxAt(700), want: 73},
+		{name: "tax on nothing", got: NewMoney(0, "THB").TaxAt(700), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.Amount != tt.want || tt.got.Currency != "THB" {
+				t.Errorf("got %+v, want %d THB", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(1999, "THB"), want: "19.99"},
+		{money: NewMoney(5, "THB"), want: "0.05"},
+		{money: NewMoney(-505, "USD"), want: "-5.05"},
+		{money: NewMoney(1500, "JPY"), want: "1500"},
+		{money: NewMoney(1234, "KWD"), want: "1.234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.money.Currency, func(t *testing.T) {
+			if got := tt.money.Decimal(); got != tt.want {
+				t.Errorf("Decimal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{name: "object in minor units", data: `{"amount":1999,"currency":"USD"}`, want: NewMoney(1999, "USD")},
+		{name: "object without currency", data: `{"amount":1999}`, want: NewMoney(1999, DefaultCurrency)},
+		{name: "bare number in major units", data: `19.99`, want: NewMoney(1999, DefaultCurrency)},
+		{name: "bare whole number in major units", data: `20`, want: NewMoney(2000, DefaultCurrency)},
+		{name: "bare number with too many decimals", data: `19.999`, wantErr: true},
+		{name: "null", data: `null`, want: Money{}},
+		{name: "quoted number in major units", data: `"19.99"`, want: NewMoney(1999, DefaultCurrency)},
+		{name: "text", data: `"free"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalBSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		price any
+		want  Money
+	}{
+		{name: "embedded document", price: bson.M{"amount": int64(1999), "currency": "USD"}, want: NewMoney(1999, "USD")},
+		{name: "legacy int in major units", price: int32(20), want: NewMoney(2000, DefaultCurrency)},
+		{name: "legacy double in major units", price: 19.99, want: NewMoney(1999, DefaultCurrency)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"price": tt.price})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var doc struct {
+				Price Money `bson:"price"`
+			}
+			if err := bson.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Price != tt.want {
+				t.Errorf("got %+v, want %+v", doc.Price, tt.want)
+			}
+		})
+	}
+}
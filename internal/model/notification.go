@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationStockLow    = "STOCK_LOW"
+	NotificationBackInStock = "BACK_IN_STOCK"
+	NotificationShipment    = "SHIPMENT_UPDATED"
+)
+
+// Notification is the frame pushed to a user over the realtime channel
+type Notification struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
+}
+
+// StockAlert is the body of the stock.low and stock.back_in_stock events
+type StockAlert struct {
+	ProductID        string `json:"product_id"`
+	VariantID        string `json:"variant_id,omitempty"`
+	Quantity         int    `json:"quantity"`
+	ReorderThreshold int    `json:"reorder_threshold"`
+}
+
+// StockSubscription is a buyer waiting for a "back in stock" alert, NotifiedAt
+// is set once the alert went out and cleared when they subscribe again
+type StockSubscription struct {
+	ID         string     `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	ProductID  string     `gorm:"column:product_id;index" bson:"product_id" json:"product_id"`
+	UserID     string     `gorm:"column:user_id;index" bson:"user_id" json:"user_id"`
+	NotifiedAt *time.Time `gorm:"column:notified_at" bson:"notified_at" json:"notified_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+}
+
+// ------------------------ Public Method ------------------------
+// StockID is the key of the stock the alert is about, the variant when there is one
+func (a *StockAlert) StockID() string {
+	if a.VariantID != "" {
+		return a.VariantID
+	}
+	return a.ProductID
+}
+
+func (s *StockSubscription) IsNotified() bool {
+	return s.NotifiedAt != nil
+}
+
+func NewStockSubscription(productID string, userID string) *StockSubscription {
+	return &StockSubscription{
+		ID:        primitive.NewObjectID().Hex(),
+		ProductID: productID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+}
@@ -10,47 +10,109 @@ import (
 var (
 	ErrNilUserID    = errors.New("user id is nil")
 	ErrNilProductID = errors.New("product id is nil")
+
+	// what the order service returns is told apart by these, the handler
+	// answers 404, 403 and 400 for them
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderAccess   = errors.New("no permission for this order")
+	ErrOrderRequest  = errors.New("invalid order request")
+	ErrOrderStatus   = errors.New("order status must be PENDING, PAID, SHIPPED, DELIVERED, CANCELLED or REFUNDED")
+)
+
+const (
+	OrderStatusPending   = "PENDING"
+	OrderStatusPaid      = "PAID"
+	OrderStatusShipped   = "SHIPPED"
+	OrderStatusDelivered = "DELIVERED"
+	OrderStatusCancelled = "CANCELLED"
+	OrderStatusRefunded  = "REFUNDED"
 )
 
+var OrderStatuses = []string{
+	OrderStatusPending, OrderStatusPaid, OrderStatusShipped,
+	OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded,
+}
+
 type Order struct {
-	ID        string     `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
-	UserID    string     `gorm:"column:user_id" bson:"user_id"`
-	ProductID string     `gorm:"column:product_id" bson:"product_id"`
-	Quantity  int        `gorm:"quantity" bson:"quantity"`
-	Price     int        `gorm:"column:price" bson:"price"`
-	Status    string     `gorm:"column:status" bson:"status"`
-	Amount    int        `gorm:"column:amount" bson:"amount"`
-	CreatedAt time.Time  `gorm:"column:created_at" bson:"created_at"`
-	UpdatedAt time.Time  `gorm:"column:updated_at" bson:"updated_at"`
-	DeletedAt *time.Time `gorm:"column:deleted_at;index" bson:"deleted_at,omitempty"`
+	ID              string           `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	UserID          string           `gorm:"column:user_id" bson:"user_id"`
+	ProductID       string           `gorm:"column:product_id" bson:"product_id"`
+	VariantID       string           `gorm:"column:variant_id" bson:"variant_id,omitempty"`
+	Quantity        int              `gorm:"quantity" bson:"quantity"`
+	Price           Money            `gorm:"embedded;embeddedPrefix:price_" bson:"price"`
+	Status          string           `gorm:"column:status" bson:"status"`
+	Subtotal        Money            `gorm:"embedded;embeddedPrefix:subtotal_" bson:"subtotal"`
+	Discount        Money            `gorm:"embedded;embeddedPrefix:discount_" bson:"discount"`
+	CouponCode      string           `gorm:"column:coupon_code" bson:"coupon_code,omitempty"`
+	Tax             Money            `gorm:"embedded;embeddedPrefix:tax_" bson:"tax"`
+	TaxLines        []TaxLine        `gorm:"column:tax_lines;serializer:json" bson:"tax_lines"`
+	Amount          Money            `gorm:"embedded;embeddedPrefix:total_" bson:"amount"` // total to pay, subtotal less discount plus tax not in the price
+	ShippingAddress *ShippingAddress `gorm:"column:shipping_address;serializer:json" bson:"shipping_address,omitempty"`
+	CreatedAt       time.Time        `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt       time.Time        `gorm:"column:updated_at" bson:"updated_at"`
+	DeletedAt       *time.Time       `gorm:"column:deleted_at;index" bson:"deleted_at,omitempty"`
 }
 
 type OrderReq struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID  string `json:"product_id"`
+	VariantID  string `json:"variant_id"` // required when the product is sold in variants
+	Quantity   int    `json:"quantity"`
+	Region     string `json:"region"` // optional, defaults to the region of the shipping address
+	CouponCode string `json:"coupon_code"`
+	AddressID  string `json:"address_id"` // empty ships to the default address
 }
 
 type OrderResp struct {
-	UserID    string    `json:"user_id"`
-	ProductID string    `json:"product_id"`
-	Quantity  int       `json:"quantity"`
-	Price     int       `json:"price"`
-	Status    string    `json:"status"`
-	Amount    int       `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              string           `json:"id"`
+	UserID          string           `json:"user_id"`
+	ProductID       string           `json:"product_id"`
+	VariantID       string           `json:"variant_id,omitempty"`
+	Quantity        int              `json:"quantity"`
+	Price           Money            `json:"price"`
+	Status          string           `json:"status"`
+	Subtotal        Money            `json:"subtotal"`
+	Discount        Money            `json:"discount"`
+	CouponCode      string           `json:"coupon_code,omitempty"`
+	Tax             Money            `json:"tax"`
+	TaxLines        []TaxLine        `json:"tax_lines"`
+	Amount          Money            `json:"amount"`
+	ShippingAddress *ShippingAddress `json:"shipping_address"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// SellerOrderResp is an order of one of the seller's products with who bought it
+type SellerOrderResp struct {
+	OrderResp
+	Buyer *OrderBuyer `json:"buyer"`
+}
+
+type OrderBuyer struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// OrderEvent is the body of the order.paid event
+type OrderEvent struct {
+	OrderID string `json:"order_id"`
+	UserID  string `json:"user_id"`
 }
 
 // ------------------------ Public Method ------------------------
-func (oReq *OrderReq) ToOrder(userID string, product *ProductResp) *Order {
+func (oReq *OrderReq) ToOrder(userID string, price Money) *Order {
 	return &Order{
 		ID:        primitive.NewObjectID().Hex(),
 		UserID:    userID,
 		ProductID: oReq.ProductID,
+		VariantID: oReq.VariantID,
 		Quantity:  oReq.Quantity,
-		Price:     product.Price,
-		Amount:    product.Price * oReq.Quantity,
-		Status:    "PENDING",
+		Price:     price,
+		Subtotal:  price.Mul(int64(oReq.Quantity)),
+		Discount:  NewMoney(0, price.Currency),
+		Tax:       NewMoney(0, price.Currency),
+		Amount:    price.Mul(int64(oReq.Quantity)),
+		Status:    OrderStatusPending,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -58,14 +120,114 @@ func (oReq *OrderReq) ToOrder(userID string, product *ProductResp) *Order {
 
 func (o *Order) ToOrderResp() *OrderResp {
 	return &OrderResp{
-		UserID:    o.UserID,
-		ProductID: o.ProductID,
-		Quantity:  o.Quantity,
-		Status:    o.Status,
-		Amount:    o.Amount,
-		CreatedAt: o.CreatedAt,
-		UpdatedAt: o.UpdatedAt,
+		ID:              o.ID,
+		UserID:          o.UserID,
+		ProductID:       o.ProductID,
+		VariantID:       o.VariantID,
+		Quantity:        o.Quantity,
+		Price:           o.Price,
+		Status:          o.Status,
+		Subtotal:        o.Subtotal,
+		Discount:        o.Discount,
+		CouponCode:      o.CouponCode,
+		Tax:             o.Tax,
+		TaxLines:        o.TaxLines,
+		Amount:          o.Amount,
+		ShippingAddress: o.ShippingAddress,
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+	}
+}
+
+// ApplyDiscount takes a coupon discount off the subtotal, the discount has
+// to be in the currency of the order
+func (o *Order) ApplyDiscount(couponCode string, discount Money) error {
+	total, err := o.Subtotal.Sub(discount.Min(o.Subtotal))
+	if err != nil {
+		return err
+	}
+
+	o.CouponCode = couponCode
+	o.Discount = discount.Min(o.Subtotal)
+	o.Amount = total
+	return nil
+}
+
+// Taxable is what tax is charged on, the subtotal after discount
+func (o *Order) Taxable() Money {
+	taxable, _ := o.Subtotal.Sub(o.Discount)
+	return taxable
+}
+
+// ApplyTax stores the tax lines and adds the tax not already in the prices
+// to the amount. It goes after ApplyDiscount, tax is on the discounted amount.
+func (o *Order) ApplyTax(lines []TaxLine) error {
+	tax := NewMoney(0, o.Subtotal.Currency)
+	added := NewMoney(0, o.Subtotal.Currency)
+	for _, line := range lines {
+		var err error
+		if tax, err = tax.Add(line.Tax); err != nil {
+			return err
+		}
+		if !line.Inclusive {
+			if added, err = added.Add(line.Tax); err != nil {
+				return err
+			}
+		}
+	}
+
+	total, err := o.Taxable().Add(added)
+	if err != nil {
+		return err
+	}
+
+	o.Tax = tax
+	o.TaxLines = lines
+	o.Amount = total
+	return nil
+}
+
+// VerifyCurrency checks that every amount of the order is in one currency
+func (o *Order) VerifyCurrency() error {
+	if err := o.Price.Verify(); err != nil {
+		return err
+	}
+	for _, amount := range []Money{o.Subtotal, o.Discount, o.Tax, o.Amount} {
+		if amount.Currency != o.Price.Currency {
+			return ErrCurrencyMismatch
+		}
+	}
+	return nil
+}
+
+// IsPaid is true from payment until delivery, a refunded order is no longer paid
+func (o *Order) IsPaid() bool {
+	switch o.Status {
+	case OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered:
+		return true
 	}
+	return false
+}
+
+func (o *Order) ToSellerOrderResp(buyer *User) *SellerOrderResp {
+	resp := &SellerOrderResp{OrderResp: *o.ToOrderResp()}
+	if buyer != nil {
+		resp.Buyer = &OrderBuyer{ID: buyer.ID, Username: buyer.Username, Email: buyer.Email}
+	}
+	return resp
+}
+
+// CanBeReturned is true once the order is paid, a fully refunded order is done
+func (o *Order) CanBeReturned() bool {
+	return o.IsPaid()
+}
+
+// StockID is the key of the stock the order takes from, the variant when there is one
+func (o *Order) StockID() string {
+	if o.VariantID != "" {
+		return o.VariantID
+	}
+	return o.ProductID
 }
 
 func (o *Order) VerifyNil(order Order) error {
@@ -79,3 +241,12 @@ func (o *Order) VerifyNil(order Order) error {
 
 // ------------------------ Private Method ------------------------
 // status check or someting
+
+func IsOrderStatus(status string) bool {
+	for _, s := range OrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
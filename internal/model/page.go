@@ -0,0 +1,47 @@
+package model
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Page is the page a list endpoint asks for, numbered from 1
+type Page struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
+}
+
+type PageResp[T any] struct {
+	Items []T   `json:"items"`
+	Page  int   `json:"page"`
+	Size  int   `json:"size"`
+	Total int64 `json:"total"`
+}
+
+// ------------------------ Public Method ------------------------
+// Normalize falls back to the first page and keeps the size within bounds
+func (p *Page) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Size < 1 {
+		p.Size = DefaultPageSize
+	}
+	p.Size = min(p.Size, MaxPageSize)
+}
+
+func (p Page) Offset() int {
+	return (p.Page - 1) * p.Size
+}
+
+func NewPageResp[T any](items []T, page Page, total int64) *PageResp[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+	return &PageResp[T]{
+		Items: items,
+		Page:  page.Page,
+		Size:  page.Size,
+		Total: total,
+	}
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrRefundAmount = errors.New("refund must be above zero and at most what is left of the payment")
+)
+
+const (
+	PaymentStatusPending   = "PENDING"
+	PaymentStatusSucceeded = "SUCCEEDED"
+	PaymentStatusFailed    = "FAILED"
+	PaymentStatusRefunded  = "REFUNDED"
+)
+
+// Payment is one attempt to pay an order through a provider, ProviderRef is
+// the provider's id of the intent. A failed attempt stays as it is and the
+// buyer pays again with a new one.
+type Payment struct {
+	ID            string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	OrderID       string    `gorm:"column:order_id;index" bson:"order_id"`
+	UserID        string    `gorm:"column:user_id;index" bson:"user_id"`
+	Provider      string    `gorm:"column:provider" bson:"provider"`
+	ProviderRef   string    `gorm:"column:provider_ref;index" bson:"provider_ref"`
+	Attempt       int       `gorm:"column:attempt" bson:"attempt"`
+	Amount        Money     `gorm:"embedded;embeddedPrefix:amount_" bson:"amount"`
+	Refunded      Money     `gorm:"embedded;embeddedPrefix:refunded_" bson:"refunded"`
+	Status        string    `gorm:"column:status" bson:"status"`
+	FailureReason string    `gorm:"column:failure_reason" bson:"failure_reason,omitempty"`
+	CreatedAt     time.Time `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" bson:"updated_at"`
+}
+
+// PaymentEvent is a provider webhook already handled, kept so a redelivered
+// event is not applied twice
+type PaymentEvent struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	Provider  string    `gorm:"column:provider;uniqueIndex:idx_payment_event" bson:"provider"`
+	EventID   string    `gorm:"column:event_id;uniqueIndex:idx_payment_event" bson:"event_id"`
+	Type      string    `gorm:"column:type" bson:"type"`
+	PaymentID string    `gorm:"column:payment_id;index" bson:"payment_id"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at"`
+}
+
+type PaymentRefundReq struct {
+	Amount *Money `json:"amount"` // empty refunds what is left
+}
+
+type PaymentResp struct {
+	ID            string    `json:"id"`
+	OrderID       string    `json:"order_id"`
+	Provider      string    `json:"provider"`
+	Attempt       int       `json:"attempt"`
+	Amount        Money     `json:"amount"`
+	Refunded      Money     `json:"refunded"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	ClientSecret  string    `json:"client_secret,omitempty"` // only on the attempt just created, for the buyer to confirm with the provider
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ------------------------ Public Method ------------------------
+func NewPayment(order *Order, provider string, attempt int) *Payment {
+	now := time.Now()
+	return &Payment{
+		ID:        primitive.NewObjectID().Hex(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Provider:  provider,
+		Attempt:   attempt,
+		Amount:    order.Amount,
+		Refunded:  NewMoney(0, order.Amount.Currency),
+		Status:    PaymentStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewPaymentEvent(provider string, eventID string, eventType string, paymentID string) *PaymentEvent {
+	return &PaymentEvent{
+		ID:        primitive.NewObjectID().Hex(),
+		Provider:  provider,
+		EventID:   eventID,
+		Type:      eventType,
+		PaymentID: paymentID,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (p *Payment) ToPaymentResp() *PaymentResp {
+	return &PaymentResp{
+		ID:            p.ID,
+		OrderID:       p.OrderID,
+		Provider:      p.Provider,
+		Attempt:       p.Attempt,
+		Amount:        p.Amount,
+		Refunded:      p.Refunded,
+		Status:        p.Status,
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+func (p *Payment) IsPending() bool {
+	return p.Status == PaymentStatusPending
+}
+
+func (p *Payment) MarkSucceeded() {
+	p.Status = PaymentStatusSucceeded
+	p.FailureReason = ""
+	p.UpdatedAt = time.Now()
+}
+
+func (p *Payment) MarkFailed(reason string) {
+	p.Status = PaymentStatusFailed
+	p.FailureReason = reason
+	p.UpdatedAt = time.Now()
+}
+
+// Refundable is what is left of a succeeded payment to give back
+func (p *Payment) Refundable() Money {
+	if p.Status != PaymentStatusSucceeded {
+		return NewMoney(0, p.Amount.Currency)
+	}
+	left, _ := p.Amount.Sub(p.Refunded)
+	return left
+}
+
+// Refund records amount as given back, the payment is REFUNDED once nothing is left
+func (p *Payment) Refund(amount Money) error {
+	left := p.Refundable()
+	if amount.Amount <= 0 || amount.Currency != left.Currency || amount.Amount > left.Amount {
+		return ErrRefundAmount
+	}
+
+	refunded, err := p.Refunded.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	p.Refunded = refunded
+	if refunded.Amount == p.Amount.Amount {
+		p.Status = PaymentStatusRefunded
+	}
+	p.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSchedulePrice = errors.New("scheduled price must be greater than zero")
+	ErrScheduleTime  = errors.New("scheduled price must take effect in the future")
+)
+
+const (
+	PriceChangeScheduled = "SCHEDULED"
+	PriceChangeApplying  = "APPLYING"
+	PriceChangeApplied   = "APPLIED"
+	PriceChangeCancelled = "CANCELLED"
+)
+
+// PriceChange is one entry of a product's price history. A change scheduled
+// for later waits as SCHEDULED, is APPLYING while the scheduler that claimed it
+// writes the price and gets its OldPrice when it is applied.
+type PriceChange struct {
+	ID          string     `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	ProductID   string     `gorm:"column:product_id;index" bson:"product_id" json:"product_id"`
+	OldPrice    Money      `gorm:"embedded;embeddedPrefix:old_price_" bson:"old_price" json:"old_price"`
+	NewPrice    Money      `gorm:"embedded;embeddedPrefix:new_price_" bson:"new_price" json:"new_price"`
+	Status      string     `gorm:"column:status;index" bson:"status" json:"status"`
+	EffectiveAt time.Time  `gorm:"column:effective_at" bson:"effective_at" json:"effective_at"`
+	AppliedAt   *time.Time `gorm:"column:applied_at" bson:"applied_at,omitempty" json:"applied_at,omitempty"`
+	ChangedBy   string     `gorm:"column:changed_by" bson:"changed_by" json:"changed_by"`
+	CreatedAt   time.Time  `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type PriceScheduleReq struct {
+	Price       Money     `json:"price"`
+	EffectiveAt time.Time `json:"effective_at"` // RFC 3339
+}
+
+// ------------------------ Public Method ------------------------
+func (req *PriceScheduleReq) Verify() error {
+	if req.Price.Amount <= 0 {
+		return ErrSchedulePrice
+	}
+	if err := req.Price.Verify(); err != nil {
+		return err
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		return ErrScheduleTime
+	}
+	return nil
+}
+
+func (req *PriceScheduleReq) ToPriceChange(productID string, userID string) *PriceChange {
+	now := time.Now()
+	return &PriceChange{
+		ID:          primitive.NewObjectID().Hex(),
+		ProductID:   productID,
+		NewPrice:    req.Price,
+		Status:      PriceChangeScheduled,
+		EffectiveAt: req.EffectiveAt,
+		ChangedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// NewAppliedPriceChange records a price that changed right away
+func NewAppliedPriceChange(productID string, oldPrice Money, newPrice Money, userID string) *PriceChange {
+	now := time.Now()
+	return &PriceChange{
+		ID:          primitive.NewObjectID().Hex(),
+		ProductID:   productID,
+		OldPrice:    oldPrice,
+		NewPrice:    newPrice,
+		Status:      PriceChangeApplied,
+		EffectiveAt: now,
+		AppliedAt:   &now,
+		ChangedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func (pc *PriceChange) IsDue(now time.Time) bool {
+	return pc.Status == PriceChangeScheduled && !pc.EffectiveAt.After(now)
+}
+
+func (pc *PriceChange) MarkApplied(oldPrice Money) {
+	now := time.Now()
+	pc.OldPrice = oldPrice
+	pc.Status = PriceChangeApplied
+	pc.AppliedAt = &now
+	pc.UpdatedAt = now
+}
+
+func (pc *PriceChange) MarkCancelled() {
+	pc.Status = PriceChangeCancelled
+	pc.UpdatedAt = time.Now()
+}
@@ -8,7 +8,7 @@ import (
 type Product struct { // parse to db
 	ID        string     `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
 	Title     string     `gorm:"column:title" bson:"title"`
-	Price     int        `gorm:"column:price" bson:"price"`
+	Price     Money      `gorm:"embedded;embeddedPrefix:price_" bson:"price"`
 	Detail    string     `gorm:"column:detail" bson:"detail"`
 	CreatedBy string     `gorm:"column:created_by" bson:"created_by"`
 	CreatedAt time.Time  `gorm:"column:created_at" bson:"created_at"`
@@ -17,24 +17,33 @@ type Product struct { // parse to db
 }
 
 type ProductReq struct { // input form user
-	Title     string `json:"title"`
-	Price     int    `json:"price"`
-	Detail    string `json:"detail"`
-	Quantity  int    `json:"quantity"`
-	CreatedBy string `json:"created_by"`
+	Title     string              `json:"title"`
+	Price     Money               `json:"price"` // a bare number is major units of the default currency
+	Detail    string              `json:"detail"`
+	Quantity  int                 `json:"quantity"` // ignored when the product has variants
+	CreatedBy string              `json:"created_by"`
+	Variants  []ProductVariantReq `json:"variants"`
 }
 
 type ProductResp struct { // show output to user
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Price     int       `json:"price"`
-	Detail    string    `json:"detail"`
-	CreatedBy string    `json:"created_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_by"`
+	ID        string               `json:"id"`
+	Title     string               `json:"title"`
+	Price     Money                `json:"price"`
+	Detail    string               `json:"detail"`
+	CreatedBy string               `json:"created_by"`
+	Variants  []ProductVariantResp `json:"variants,omitempty"`
+	Images    []ProductImageResp   `json:"images"`
+	Rating    float64              `json:"rating"`
+	Reviews   int                  `json:"review_count"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_by"`
 }
 
 func (pReq *ProductReq) ToProduct() *Product {
+	if pReq.Price.Currency == "" {
+		pReq.Price.Currency = DefaultCurrency
+	}
+
 	product := Product{
 		Title : pReq.Title,
 		Price : pReq.Price,
@@ -64,10 +73,13 @@ func (p *Product) Verify() error {
 			return errors.New("product title is too short. It must be at least 2 characters long")
 		}
 	}
-	if p.Price != 0 {
+	if !p.Price.IsZero() {
 		if !p.isValidPrice() {
 			return errors.New("product price is invalid. It must be greater than zero")
 		}
+		if err := p.Price.Verify(); err != nil {
+			return err
+		}
 	}
 
 	if p.Detail != "" {
@@ -83,7 +95,7 @@ func (p *Product) UpdateNotNilField(pReq *ProductReq) {
 		p.Title = pReq.Title
 	}
 
-	if pReq.Price != 0 {
+	if !pReq.Price.IsZero() {
 		p.Price = pReq.Price
 	}
 
@@ -100,7 +112,7 @@ func (p *Product) isValidTitle() bool {
 }
 
 func (p *Product) isValidPrice() bool {
-	return p.Price > 0
+	return p.Price.Amount > 0
 }
 
 func (p *Product) isValidDetail() bool {
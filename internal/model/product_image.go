@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrImageType   = errors.New("image must be a JPEG, PNG or GIF")
+	ErrImageSize   = errors.New("image is larger than the upload limit")
+	ErrImagePixels = errors.New("image has more pixels than the upload limit")
+	ErrImageEmpty  = errors.New("image file is empty")
+)
+
+// image types accepted on upload and the extension they are stored with
+var productImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductImage points at an uploaded image and its thumbnail in object storage
+type ProductImage struct {
+	ID           string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	ProductID    string    `gorm:"column:product_id;index" bson:"product_id"`
+	Key          string    `gorm:"column:key" bson:"key"`
+	ThumbnailKey string    `gorm:"column:thumbnail_key" bson:"thumbnail_key"`
+	ContentType  string    `gorm:"column:content_type" bson:"content_type"`
+	Size         int64     `gorm:"column:size" bson:"size"`
+	CreatedAt    time.Time `gorm:"column:created_at" bson:"created_at"`
+}
+
+type ProductImageResp struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// ------------------------ Public Method ------------------------
+// NewProductImage names the objects of an upload after the product and image id
+func NewProductImage(productID string, contentType string, size int64) (*ProductImage, error) {
+	ext, ok := productImageTypes[contentType]
+	if !ok {
+		return nil, ErrImageType
+	}
+
+	id := primitive.NewObjectID().Hex()
+	return &ProductImage{
+		ID:           id,
+		ProductID:    productID,
+		Key:          "products/" + productID + "/" + id + ext,
+		ThumbnailKey: "products/" + productID + "/" + id + "_thumb.jpg",
+		ContentType:  contentType,
+		Size:         size,
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+func (i *ProductImage) ToProductImageResp(url func(key string) string) *ProductImageResp {
+	return &ProductImageResp{
+		ID:           i.ID,
+		URL:          url(i.Key),
+		ThumbnailURL: url(i.ThumbnailKey),
+	}
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrVariantSKU       = errors.New("variant sku is required")
+	ErrVariantDuplicate = errors.New("variant sku is used twice")
+	ErrVariantPrice     = errors.New("variant price must be greater than zero")
+	ErrVariantQuantity  = errors.New("variant quantity can't be under zero")
+	ErrVariantRequired  = errors.New("product is sold in variants, variant id is required")
+	ErrVariantNotFound  = errors.New("variant not found for this product")
+)
+
+// ProductVariant is one sellable version of a product (a size, a color). It
+// has its own stock row, keyed by the variant ID instead of the product ID.
+type ProductVariant struct {
+	ID         string            `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	ProductID  string            `gorm:"column:product_id;index" bson:"product_id"`
+	SKU        string            `gorm:"column:sku;uniqueIndex" bson:"sku"`
+	Attributes map[string]string `gorm:"column:attributes;serializer:json" bson:"attributes"`
+	Price      Money             `gorm:"embedded;embeddedPrefix:price_" bson:"price,omitempty"` // zero sells at the product price
+	CreatedAt  time.Time         `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt  time.Time         `gorm:"column:updated_at" bson:"updated_at"`
+}
+
+type ProductVariantReq struct {
+	ID         string            `json:"id"` // empty on update adds a new variant
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *Money            `json:"price"`
+	Quantity   int               `json:"quantity"`
+}
+
+type ProductVariantResp struct {
+	ID         string            `json:"id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      Money             `json:"price"`
+	Available  int               `json:"available"`
+	InStock    bool              `json:"in_stock"`
+}
+
+// ------------------------ Public Method ------------------------
+func (vReq *ProductVariantReq) Verify() error {
+	if strings.TrimSpace(vReq.SKU) == "" {
+		return ErrVariantSKU
+	}
+	if vReq.Price != nil {
+		if vReq.Price.Amount <= 0 {
+			return ErrVariantPrice
+		}
+		if err := vReq.Price.Verify(); err != nil {
+			return err
+		}
+	}
+	if vReq.Quantity < 0 {
+		return ErrVariantQuantity
+	}
+	return nil
+}
+
+func (vReq *ProductVariantReq) ToProductVariant(productID string) *ProductVariant {
+	now := time.Now()
+	return &ProductVariant{
+		ID:         primitive.NewObjectID().Hex(),
+		ProductID:  productID,
+		SKU:        strings.TrimSpace(vReq.SKU),
+		Attributes: vReq.Attributes,
+		Price:      vReq.price(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func (v *ProductVariant) Apply(vReq *ProductVariantReq) {
+	v.SKU = strings.TrimSpace(vReq.SKU)
+	if vReq.Attributes != nil {
+		v.Attributes = vReq.Attributes
+	}
+	v.Price = vReq.price()
+	v.UpdatedAt = time.Now()
+}
+
+// PriceOr returns the variant price, or the product price when it has no override
+func (v *ProductVariant) PriceOr(productPrice Money) Money {
+	if !v.Price.IsZero() {
+		return v.Price
+	}
+	return productPrice
+}
+
+func (v *ProductVariant) ToProductVariantResp(productPrice Money, available int) *ProductVariantResp {
+	return &ProductVariantResp{
+		ID:         v.ID,
+		SKU:        v.SKU,
+		Attributes: v.Attributes,
+		Price:      v.PriceOr(productPrice),
+		Available:  available,
+		InStock:    available > 0,
+	}
+}
+
+// VerifyVariants checks every variant of a product request, that no SKU
+// repeats and that variant prices are in the currency of the product
+func (pReq *ProductReq) VerifyVariants() error {
+	seen := make(map[string]bool, len(pReq.Variants))
+	for _, vReq := range pReq.Variants {
+		if err := vReq.Verify(); err != nil {
+			return err
+		}
+		if vReq.Price != nil && !pReq.Price.IsZero() && vReq.Price.Currency != pReq.Price.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		sku := strings.TrimSpace(vReq.SKU)
+		if seen[sku] {
+			return ErrVariantDuplicate
+		}
+		seen[sku] = true
+	}
+	return nil
+}
+
+// PriceOf returns the price an order line pays, a product sold in variants
+// needs one of its variants picked
+func (p *ProductResp) PriceOf(variantID string) (Money, error) {
+	if len(p.Variants) == 0 {
+		if variantID != "" {
+			return Money{}, ErrVariantNotFound
+		}
+		return p.Price, nil
+	}
+
+	if variantID == "" {
+		return Money{}, ErrVariantRequired
+	}
+	for _, variant := range p.Variants {
+		if variant.ID == variantID {
+			return variant.Price, nil
+		}
+	}
+	return Money{}, ErrVariantNotFound
+}
+
+// ------------------------ Private Method ------------------------
+// price is the override of the variant, zero when it sells at the product price
+func (vReq *ProductVariantReq) price() Money {
+	if vReq.Price == nil {
+		return Money{}
+	}
+	return *vReq.Price
+}
@@ -0,0 +1,289 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrReportDate   = errors.New("report dates must be YYYY-MM-DD")
+	ErrReportRange  = errors.New("report range must end after it starts and span at most 366 days")
+	ErrReportPeriod = errors.New("report period must be DAY, WEEK or MONTH")
+)
+
+const (
+	ReportPeriodDay   = "DAY"
+	ReportPeriodWeek  = "WEEK"
+	ReportPeriodMonth = "MONTH"
+
+	ReportDateLayout   = "2006-01-02"
+	ReportDefaultDays  = 30
+	ReportMaxDays      = 366
+	ReportDefaultLimit = 10
+	ReportMaxLimit     = 100
+)
+
+// SalesStatuses are the order statuses counted as sales. A refunded or
+// cancelled order is not revenue, an order partly refunded by a return still is.
+var SalesStatuses = []string{OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered}
+
+// ReportReq is the query string of a report. Dates are whole UTC days and
+// both ends are included, an empty range is the last 30 days.
+type ReportReq struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Period string `form:"period"` // revenue only, defaults to DAY
+	Limit  int    `form:"limit"`  // top products only
+	Format string `form:"format"` // json or csv
+}
+
+// SalesFilter narrows the orders of a report to a seller and a time range,
+// From included and To excluded
+type SalesFilter struct {
+	SellerID string
+	From     time.Time
+	To       time.Time
+	Period   string
+	Limit    int
+}
+
+// SalesRow is one group of a sales aggregation. Only the keys the query
+// grouped by are set, amounts are in minor units of Currency.
+type SalesRow struct {
+	Period    time.Time `gorm:"column:period" bson:"period"`
+	Status    string    `gorm:"column:status" bson:"status"`
+	ProductID string    `gorm:"column:product_id" bson:"product_id"`
+	Title     string    `gorm:"column:title" bson:"title"`
+	Currency  string    `gorm:"column:currency" bson:"currency"`
+	Orders    int64     `gorm:"column:orders" bson:"orders"`
+	Units     int64     `gorm:"column:units" bson:"units"`
+	Revenue   int64     `gorm:"column:revenue" bson:"revenue"`
+}
+
+type SalesSummary struct {
+	From             time.Time        `json:"from"`
+	To               time.Time        `json:"to"`
+	Orders           int64            `json:"orders"`
+	PaidOrders       int64            `json:"paid_orders"`
+	CancelledOrders  int64            `json:"cancelled_orders"`
+	RefundedOrders   int64            `json:"refunded_orders"`
+	CancellationRate float64          `json:"cancellation_rate"` // cancelled over all orders, 0 to 1
+	UnitsSold        int64            `json:"units_sold"`
+	Revenue          []Money          `json:"revenue"` // one amount per currency
+	ByStatus         map[string]int64 `json:"by_status"`
+}
+
+type RevenuePoint struct {
+	Period  time.Time `json:"period"` // start of the day, week (Monday) or month
+	Orders  int64     `json:"orders"`
+	Units   int64     `json:"units"`
+	Revenue Money     `json:"revenue"`
+}
+
+type TopProduct struct {
+	ProductID string `json:"product_id"`
+	Title     string `json:"title"`
+	Orders    int64  `json:"orders"`
+	Units     int64  `json:"units"`
+	Revenue   Money  `json:"revenue"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *ReportReq) Verify() error {
+	req.Period = strings.ToUpper(req.Period)
+	if req.Period == "" {
+		req.Period = ReportPeriodDay
+	}
+	if req.Period != ReportPeriodDay && req.Period != ReportPeriodWeek && req.Period != ReportPeriodMonth {
+		return ErrReportPeriod
+	}
+
+	if req.Limit < 1 {
+		req.Limit = ReportDefaultLimit
+	}
+	req.Limit = min(req.Limit, ReportMaxLimit)
+	return nil
+}
+
+// ToSalesFilter turns the dates into a half open range of whole days
+func (req *ReportReq) ToSalesFilter(sellerID string, now time.Time) (*SalesFilter, error) {
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	to := today
+	if req.To != "" {
+		t, err := time.Parse(ReportDateLayout, req.To)
+		if err != nil {
+			return nil, ErrReportDate
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(ReportDefaultDays - 1))
+	if req.From != "" {
+		f, err := time.Parse(ReportDateLayout, req.From)
+		if err != nil {
+			return nil, ErrReportDate
+		}
+		from = f
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if !to.After(from) || to.Sub(from) > ReportMaxDays*24*time.Hour {
+		return nil, ErrReportRange
+	}
+
+	return &SalesFilter{
+		SellerID: sellerID,
+		From:     from,
+		To:       to,
+		Period:   req.Period,
+		Limit:    req.Limit,
+	}, nil
+}
+
+// TruncUnit is the period as the date unit Postgres and Mongo truncate to
+func (f *SalesFilter) TruncUnit() string {
+	return strings.ToLower(f.Period)
+}
+
+// IsCSV reports whether the report is asked for as a CSV file
+func (req *ReportReq) IsCSV() bool {
+	return strings.EqualFold(req.Format, "csv")
+}
+
+// NewSalesSummary adds up rows grouped by status and currency
+func NewSalesSummary(filter *SalesFilter, rows []SalesRow) *SalesSummary {
+	summary := &SalesSummary{
+		From:     filter.From,
+		To:       filter.To.AddDate(0, 0, -1),
+		Revenue:  make([]Money, 0),
+		ByStatus: make(map[string]int64),
+	}
+
+	revenue := make(map[string]int64)
+	var currencies []string
+	for _, row := range rows {
+		summary.Orders += row.Orders
+		summary.ByStatus[row.Status] += row.Orders
+
+		switch {
+		case row.Status == OrderStatusCancelled:
+			summary.CancelledOrders += row.Orders
+		case row.Status == OrderStatusRefunded:
+			summary.RefundedOrders += row.Orders
+		case IsSalesStatus(row.Status):
+			summary.PaidOrders += row.Orders
+			summary.UnitsSold += row.Units
+			if _, ok := revenue[row.Currency]; !ok {
+				currencies = append(currencies, row.Currency)
+			}
+			revenue[row.Currency] += row.Revenue
+		}
+	}
+
+	for _, currency := range currencies {
+		summary.Revenue = append(summary.Revenue, NewMoney(revenue[currency], currency))
+	}
+	if summary.Orders > 0 {
+		summary.CancellationRate = float64(summary.CancelledOrders) / float64(summary.Orders)
+	}
+	return summary
+}
+
+func NewRevenuePoints(rows []SalesRow) []RevenuePoint {
+	points := make([]RevenuePoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, RevenuePoint{
+			Period:  row.Period.UTC(),
+			Orders:  row.Orders,
+			Units:   row.Units,
+			Revenue: NewMoney(row.Revenue, row.Currency),
+		})
+	}
+	return points
+}
+
+func NewTopProducts(rows []SalesRow) []TopProduct {
+	products := make([]TopProduct, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, TopProduct{
+			ProductID: row.ProductID,
+			Title:     row.Title,
+			Orders:    row.Orders,
+			Units:     row.Units,
+			Revenue:   NewMoney(row.Revenue, row.Currency),
+		})
+	}
+	return products
+}
+
+func IsSalesStatus(status string) bool {
+	for _, s := range SalesStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CSVText makes a cell of text a user wrote safe to open in a spreadsheet. A
+// cell starting with =, +, -, @, a tab or a carriage return would be run as a
+// formula, a leading ' keeps it text. Amounts are written by us and left as is.
+func CSVText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// CSVRows is the summary as a metric,value table
+func (s *SalesSummary) CSVRows() [][]string {
+	rows := [][]string{
+		{"metric", "value"},
+		{"from", s.From.Format(ReportDateLayout)},
+		{"to", s.To.Format(ReportDateLayout)},
+		{"orders", strconv.FormatInt(s.Orders, 10)},
+		{"paid_orders", strconv.FormatInt(s.PaidOrders, 10)},
+		{"cancelled_orders", strconv.FormatInt(s.CancelledOrders, 10)},
+		{"refunded_orders", strconv.FormatInt(s.RefundedOrders, 10)},
+		{"cancellation_rate", strconv.FormatFloat(s.CancellationRate, 'f', 4, 64)},
+		{"units_sold", strconv.FormatInt(s.UnitsSold, 10)},
+	}
+	for _, revenue := range s.Revenue {
+		rows = append(rows, []string{"revenue_" + strings.ToLower(revenue.Currency), revenue.Decimal()})
+	}
+	return rows
+}
+
+func RevenueCSVRows(points []RevenuePoint) [][]string {
+	rows := [][]string{{"period", "orders", "units", "revenue", "currency"}}
+	for _, p := range points {
+		rows = append(rows, []string{
+			p.Period.Format(ReportDateLayout),
+			strconv.FormatInt(p.Orders, 10),
+			strconv.FormatInt(p.Units, 10),
+			p.Revenue.Decimal(),
+			p.Revenue.Currency,
+		})
+	}
+	return rows
+}
+
+func TopProductsCSVRows(products []TopProduct) [][]string {
+	rows := [][]string{{"product_id", "title", "orders", "units", "revenue", "currency"}}
+	for _, p := range products {
+		rows = append(rows, []string{
+			CSVText(p.ProductID),
+			CSVText(p.Title),
+			strconv.FormatInt(p.Orders, 10),
+			strconv.FormatInt(p.Units, 10),
+			p.Revenue.Decimal(),
+			p.Revenue.Currency,
+		})
+	}
+	return rows
+}
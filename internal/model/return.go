@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReturnQuantity = errors.New("return quantity must be above zero and at most what is left of the order")
+	ErrReturnStatus   = errors.New("return status must be APPROVED or REJECTED")
+	ErrReturnDecided  = errors.New("return was already decided")
+)
+
+const (
+	ReturnRequested = "REQUESTED"
+	ReturnApproved  = "APPROVED" // restocked, the refund is still to go through
+	ReturnRejected  = "REJECTED"
+	ReturnRefunded  = "REFUNDED"
+)
+
+// Return is a buyer's request to send back part or all of a paid order.
+// Refund is fixed when it is requested, the share of the order amount for
+// the quantity, so the returns of one order never refund more than was paid.
+// Seq numbers the returns of an order, the unique index keeps two requests
+// checked against the same earlier returns from both being saved.
+type Return struct {
+	ID        string               `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	OrderID   string               `gorm:"column:order_id;index;uniqueIndex:idx_return_order_seq" bson:"order_id" json:"order_id"`
+	Seq       *int                 `gorm:"column:seq;uniqueIndex:idx_return_order_seq" bson:"seq,omitempty" json:"-"`
+	UserID    string               `gorm:"column:user_id;index" bson:"user_id" json:"user_id"`
+	SellerID  string               `gorm:"column:seller_id;index" bson:"seller_id" json:"seller_id"`
+	ProductID string               `gorm:"column:product_id" bson:"product_id" json:"product_id"`
+	VariantID string               `gorm:"column:variant_id" bson:"variant_id,omitempty" json:"variant_id,omitempty"`
+	Quantity  int                  `gorm:"column:quantity" bson:"quantity" json:"quantity"`
+	Reason    string               `gorm:"column:reason" bson:"reason" json:"reason"`
+	Refund    Money                `gorm:"embedded;embeddedPrefix:refund_" bson:"refund" json:"refund"`
+	Status    string               `gorm:"column:status" bson:"status" json:"status"`
+	History   []ReturnStatusChange `gorm:"column:history;serializer:json" bson:"history" json:"history"`
+	CreatedAt time.Time            `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type ReturnStatusChange struct {
+	Status  string    `bson:"status" json:"status"`
+	ActorID string    `bson:"actor_id" json:"actor_id"`
+	Note    string    `bson:"note,omitempty" json:"note,omitempty"`
+	At      time.Time `bson:"at" json:"at"`
+}
+
+type ReturnReq struct {
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+type ReturnDecisionReq struct {
+	Status string `json:"status"` // APPROVED or REJECTED
+	Note   string `json:"note"`
+}
+
+// ------------------------ Public Method ------------------------
+// ToReturn builds the return that follows others, the earlier returns of the order
+func (rReq *ReturnReq) ToReturn(order *Order, sellerID string, refund Money, others []Return) *Return {
+	now := time.Now()
+	seq := len(others) + 1
+	r := &Return{
+		ID:        primitive.NewObjectID().Hex(),
+		OrderID:   order.ID,
+		Seq:       &seq,
+		UserID:    order.UserID,
+		SellerID:  sellerID,
+		ProductID: order.ProductID,
+		VariantID: order.VariantID,
+		Quantity:  rReq.Quantity,
+		Reason:    rReq.Reason,
+		Refund:    refund,
+		CreatedAt: now,
+	}
+	r.SetStatus(ReturnRequested, order.UserID, rReq.Reason)
+	return r
+}
+
+func (dReq *ReturnDecisionReq) Verify() error {
+	if dReq.Status != ReturnApproved && dReq.Status != ReturnRejected {
+		return ErrReturnStatus
+	}
+	return nil
+}
+
+// SetStatus moves the return on and records who did it in the history
+func (r *Return) SetStatus(status string, actorID string, note string) {
+	r.Status = status
+	r.UpdatedAt = time.Now()
+	r.History = append(r.History, ReturnStatusChange{
+		Status:  status,
+		ActorID: actorID,
+		Note:    note,
+		At:      r.UpdatedAt,
+	})
+}
+
+// StockID is the key of the stock the items go back to, the variant when there is one
+func (r *Return) StockID() string {
+	if r.VariantID != "" {
+		return r.VariantID
+	}
+	return r.ProductID
+}
+
+// ReturnRefund is the share of the order amount refunded for quantity items.
+// The return that takes the last items gets what the others left, so the
+// rounding of each share never adds up to more or less than was paid.
+func ReturnRefund(order *Order, quantity int, others []Return) (Money, error) {
+	returned := 0
+	refunded := NewMoney(0, order.Amount.Currency)
+	for _, r := range others {
+		if r.Status == ReturnRejected {
+			continue
+		}
+		returned += r.Quantity
+
+		var err error
+		if refunded, err = refunded.Add(r.Refund); err != nil {
+			return Money{}, err
+		}
+	}
+
+	if quantity <= 0 || returned+quantity > order.Quantity {
+		return Money{}, ErrReturnQuantity
+	}
+	if returned+quantity == order.Quantity {
+		return order.Amount.Sub(refunded)
+	}
+	return order.Amount.MulRat(int64(quantity), int64(order.Quantity), RoundDown), nil
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReviewRating = errors.New("review rating must be between 1 and 5")
+	ErrReviewOrder  = errors.New("review needs the id of an order for this product")
+	ErrReviewText   = errors.New("review text is too long")
+	ErrReviewStatus = errors.New("review status must be PUBLISHED or HIDDEN")
+)
+
+const (
+	ReviewPublished = "PUBLISHED"
+	ReviewHidden    = "HIDDEN"
+
+	ReviewMinRating   = 1
+	ReviewMaxRating   = 5
+	ReviewMaxTextSize = 2000
+)
+
+// Review is a buyer's rating of a product, backed by one of their orders. A
+// buyer reviews a product once.
+type Review struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	ProductID string    `gorm:"column:product_id;uniqueIndex:idx_review_product_user;index" bson:"product_id"`
+	UserID    string    `gorm:"column:user_id;uniqueIndex:idx_review_product_user" bson:"user_id"`
+	OrderID   string    `gorm:"column:order_id" bson:"order_id"`
+	Rating    int       `gorm:"column:rating" bson:"rating"`
+	Text      string    `gorm:"column:text" bson:"text"`
+	Status    string    `gorm:"column:status;index" bson:"status"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" bson:"updated_at"`
+}
+
+type ReviewReq struct {
+	OrderID string `json:"order_id"`
+	Rating  int    `json:"rating"`
+	Text    string `json:"text"`
+}
+
+type ReviewModerateReq struct {
+	Status string `json:"status"`
+}
+
+type ReviewResp struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"product_id"`
+	UserID    string    `json:"user_id"`
+	Rating    int       `json:"rating"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductRating keeps the published reviews of a product summed up, so a
+// product can be shown without reading all of its reviews
+type ProductRating struct {
+	ProductID string    `gorm:"column:product_id;primaryKey" bson:"product_id"`
+	Count     int       `gorm:"column:count" bson:"count"`
+	Sum       int       `gorm:"column:sum" bson:"sum"`
+	UpdatedAt time.Time `gorm:"column:updated_at" bson:"updated_at"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *ReviewReq) Verify() error {
+	if req.OrderID == "" {
+		return ErrReviewOrder
+	}
+	if req.Rating < ReviewMinRating || req.Rating > ReviewMaxRating {
+		return ErrReviewRating
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if len(req.Text) > ReviewMaxTextSize {
+		return ErrReviewText
+	}
+	return nil
+}
+
+func (req *ReviewReq) ToReview(productID string, userID string) *Review {
+	now := time.Now()
+	return &Review{
+		ID:        primitive.NewObjectID().Hex(),
+		ProductID: productID,
+		UserID:    userID,
+		OrderID:   req.OrderID,
+		Rating:    req.Rating,
+		Text:      req.Text,
+		Status:    ReviewPublished,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (r *Review) Moderate(status string) {
+	r.Status = status
+	r.UpdatedAt = time.Now()
+}
+
+func (r *Review) IsPublished() bool {
+	return r.Status == ReviewPublished
+}
+
+func (r *Review) ToReviewResp() *ReviewResp {
+	return &ReviewResp{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		UserID:    r.UserID,
+		Rating:    r.Rating,
+		Text:      r.Text,
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+func (req *ReviewModerateReq) Verify() error {
+	if req.Status != ReviewPublished && req.Status != ReviewHidden {
+		return ErrReviewStatus
+	}
+	return nil
+}
+
+// CanBeReviewed reports whether the order lets its buyer review the product,
+// only once it was delivered to them
+func (o *Order) CanBeReviewed(productID string, userID string) bool {
+	return o.ProductID == productID && o.UserID == userID && o.Status == OrderStatusDelivered
+}
+
+// NewProductRating sums up the published reviews among reviews
+func NewProductRating(productID string, reviews []Review) *ProductRating {
+	rating := &ProductRating{ProductID: productID, UpdatedAt: time.Now()}
+	for _, review := range reviews {
+		if review.IsPublished() {
+			rating.Count++
+			rating.Sum += review.Rating
+		}
+	}
+	return rating
+}
+
+// Average is the mean rating rounded to one decimal, zero without reviews
+func (pr *ProductRating) Average() float64 {
+	if pr.Count == 0 {
+		return 0
+	}
+	return math.Round(float64(pr.Sum)/float64(pr.Count)*10) / 10
+}
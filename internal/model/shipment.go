@@ -0,0 +1,133 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrShipmentRequired = errors.New("shipment needs a carrier and a tracking number")
+	ErrShipmentStatus   = errors.New("shipment status must be IN_TRANSIT, OUT_FOR_DELIVERY or DELIVERED")
+	ErrShipmentDone     = errors.New("shipment was already delivered")
+)
+
+const (
+	ShipmentShipped        = "SHIPPED"
+	ShipmentInTransit      = "IN_TRANSIT"
+	ShipmentOutForDelivery = "OUT_FOR_DELIVERY"
+	ShipmentDelivered      = "DELIVERED"
+)
+
+// Shipment is the parcel a seller sent for an order, Events is its tracking
+// history with the latest last
+type Shipment struct {
+	ID             string          `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	OrderID        string          `gorm:"column:order_id;uniqueIndex" bson:"order_id" json:"order_id"`
+	UserID         string          `gorm:"column:user_id;index" bson:"user_id" json:"user_id"`
+	SellerID       string          `gorm:"column:seller_id;index" bson:"seller_id" json:"seller_id"`
+	Carrier        string          `gorm:"column:carrier" bson:"carrier" json:"carrier"`
+	TrackingNumber string          `gorm:"column:tracking_number" bson:"tracking_number" json:"tracking_number"`
+	Status         string          `gorm:"column:status" bson:"status" json:"status"`
+	Events         []ShipmentEvent `gorm:"column:events;serializer:json" bson:"events" json:"events"`
+	ShippedAt      time.Time       `gorm:"column:shipped_at" bson:"shipped_at" json:"shipped_at"`
+	DeliveredAt    *time.Time      `gorm:"column:delivered_at" bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type ShipmentEvent struct {
+	Status   string    `bson:"status" json:"status"`
+	Location string    `bson:"location,omitempty" json:"location,omitempty"`
+	Note     string    `bson:"note,omitempty" json:"note,omitempty"`
+	At       time.Time `bson:"at" json:"at"`
+}
+
+type ShipmentReq struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+type ShipmentEventReq struct {
+	Status   string `json:"status"`
+	Location string `json:"location"`
+	Note     string `json:"note"`
+}
+
+// ShipmentNotice is the body of the shipment.updated event
+type ShipmentNotice struct {
+	OrderID        string `json:"order_id"`
+	UserID         string `json:"user_id"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	Status         string `json:"status"`
+	Location       string `json:"location,omitempty"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *ShipmentReq) Verify() error {
+	if strings.TrimSpace(req.Carrier) == "" || strings.TrimSpace(req.TrackingNumber) == "" {
+		return ErrShipmentRequired
+	}
+	return nil
+}
+
+func (req *ShipmentReq) ToShipment(order *Order, sellerID string) *Shipment {
+	now := time.Now()
+	s := &Shipment{
+		ID:             primitive.NewObjectID().Hex(),
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		SellerID:       sellerID,
+		Carrier:        strings.TrimSpace(req.Carrier),
+		TrackingNumber: strings.TrimSpace(req.TrackingNumber),
+		ShippedAt:      now,
+		CreatedAt:      now,
+	}
+	s.AddEvent(&ShipmentEventReq{Status: ShipmentShipped})
+	return s
+}
+
+func (req *ShipmentEventReq) Verify() error {
+	switch req.Status {
+	case ShipmentInTransit, ShipmentOutForDelivery, ShipmentDelivered:
+		return nil
+	}
+	return ErrShipmentStatus
+}
+
+// AddEvent records a tracking update and moves the shipment to its status
+func (s *Shipment) AddEvent(req *ShipmentEventReq) {
+	now := time.Now()
+	s.Status = req.Status
+	s.Events = append(s.Events, ShipmentEvent{
+		Status:   req.Status,
+		Location: strings.TrimSpace(req.Location),
+		Note:     strings.TrimSpace(req.Note),
+		At:       now,
+	})
+	if req.Status == ShipmentDelivered {
+		s.DeliveredAt = &now
+	}
+	s.UpdatedAt = now
+}
+
+func (s *Shipment) IsDelivered() bool {
+	return s.Status == ShipmentDelivered
+}
+
+func (s *Shipment) ToShipmentNotice() *ShipmentNotice {
+	notice := &ShipmentNotice{
+		OrderID:        s.OrderID,
+		UserID:         s.UserID,
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		Status:         s.Status,
+	}
+	if len(s.Events) > 0 {
+		notice.Location = s.Events[len(s.Events)-1].Location
+	}
+	return notice
+}
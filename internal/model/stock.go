@@ -9,15 +9,61 @@ import (
 var (
 	ErrDebtStock = errors.New("insufficient stock")
 	ErrQuantity = errors.New("quantity can't be under zero")
+
+	ErrStockNotFound = errors.New("stock not found")
+	ErrStockAccess   = errors.New("no permission to manage this stock")
+	ErrStockRequest  = errors.New("invalid stock request")
+
+	ErrAdjustType       = errors.New("adjust type must be RESTOCK, WRITE_OFF or CORRECTION")
+	ErrAdjustQuantity   = errors.New("adjust quantity must be greater than zero")
+	ErrAdjustReasonCode = errors.New("adjust reason code is invalid")
+	ErrReorderThreshold = errors.New("reorder threshold can't be under zero")
 )
 
+const (
+	StockAdjustRestock    = "RESTOCK"
+	StockAdjustWriteOff   = "WRITE_OFF"
+	StockAdjustCorrection = "CORRECTION"
+)
+
+// reason codes allowed for each adjust type
+var stockAdjustReasonCodes = map[string][]string{
+	StockAdjustRestock:    {"SUPPLIER_DELIVERY", "CUSTOMER_RETURN", "OTHER"},
+	StockAdjustWriteOff:   {"DAMAGED", "EXPIRED", "LOST", "OTHER"},
+	StockAdjustCorrection: {"STOCK_COUNT", "SYSTEM_ERROR", "OTHER"},
+}
 
 type Stock struct {
-	ProductID string     `gorm:"column:product_id;primaryKey"`
-	Quantity  int        `gorm:"column:quantity"`
-	CreatedAt time.Time  `gorm:"column:created_at"`
-	UpdatedAt time.Time  `gorm:"column:updated_at"`
-	DeletedAt *time.Time `gorm:"column:deleted_at"`
+	ProductID        string     `gorm:"column:product_id;primaryKey" bson:"product_id"`
+	Quantity         int        `gorm:"column:quantity" bson:"quantity"` // on hand
+	Reserved         int        `gorm:"column:reserved" bson:"reserved"` // held by unpaid orders
+	ReorderThreshold int        `gorm:"column:reorder_threshold" bson:"reorder_threshold"`
+	CreatedAt        time.Time  `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" bson:"updated_at"`
+	DeletedAt        *time.Time `gorm:"column:deleted_at" bson:"deleted_at,omitempty"`
+}
+
+type StockResp struct {
+	ProductID        string               `json:"product_id"`
+	Quantity         int                  `json:"quantity"`
+	Reserved         int                  `json:"reserved"`
+	Available        int                  `json:"available"`
+	ReorderThreshold int                  `json:"reorder_threshold"`
+	Warehouses       []WarehouseStockResp `json:"warehouses,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+type StockThresholdReq struct {
+	ReorderThreshold int `json:"reorder_threshold"`
+}
+
+type StockAdjustReq struct {
+	Type        string `json:"type"`
+	Quantity    int    `json:"quantity"` // delta for RESTOCK and WRITE_OFF, new on-hand count for CORRECTION
+	ReasonCode  string `json:"reason_code"`
+	Note        string `json:"note"`
+	WarehouseID string `json:"warehouse_id"` // empty adjusts the seller's default warehouse
 }
 
 // ------------------------ Public Method ------------------------
@@ -33,16 +79,119 @@ func (s *Stock) GetQuantity() int {
 	return s.Quantity
 }
 
+// Available is the on-hand quantity not held by a reservation
+func (s *Stock) Available() int {
+	return s.Quantity - s.Reserved
+}
+
+// DecreaseQuantity takes from the available quantity, items held by a
+// reservation can only leave through CommitReserved
 func (s *Stock) DecreaseQuantity(quantity int) error {
-	if s.Quantity - quantity < 0 {
+	if s.Available() - quantity < 0 {
 		return ErrDebtStock
 	}
 	s.Quantity -= quantity
 	return nil
 }
 
+func (s *Stock) Reserve(quantity int) error {
+	if quantity <= 0 {
+		return ErrQuantity
+	}
+	if s.Available() < quantity {
+		return ErrDebtStock
+	}
+	s.Reserved += quantity
+	return nil
+}
+
+func (s *Stock) ReleaseReserved(quantity int) {
+	s.Reserved = max(s.Reserved-quantity, 0)
+}
+
+// CommitReserved turns held items into a sale, they leave the on-hand quantity
+func (s *Stock) CommitReserved(quantity int) {
+	s.ReleaseReserved(quantity)
+	s.Quantity = max(s.Quantity-quantity, 0)
+}
+
 func (s *Stock) IncreaseQuantity(quantity int) {
 	s.Quantity += quantity
 }
 
+// AddDelta moves the total by a change already applied to one of its warehouses
+func (s *Stock) AddDelta(delta int) {
+	s.SetQuantity(s.Quantity + delta)
+}
+
+func (s *Stock) SetReorderThreshold(threshold int) error {
+	if threshold < 0 {
+		return ErrReorderThreshold
+	}
+	s.ReorderThreshold = threshold
+	return nil
+}
+
+// CrossedReorderThreshold reports whether the available quantity fell to the
+// threshold or below it from above it. A zero threshold disables alerts.
+func (s *Stock) CrossedReorderThreshold(beforeAvailable int) bool {
+	return s.ReorderThreshold > 0 && beforeAvailable > s.ReorderThreshold && s.Available() <= s.ReorderThreshold
+}
+
+// CameBackInStock reports whether nothing was available and something is again
+func (s *Stock) CameBackInStock(beforeAvailable int) bool {
+	return beforeAvailable <= 0 && s.Available() > 0
+}
+
+func (s *Stock) ToStockAlert() *StockAlert {
+	return &StockAlert{
+		ProductID:        s.ProductID,
+		Quantity:         s.Available(),
+		ReorderThreshold: s.ReorderThreshold,
+	}
+}
+
+func (s *Stock) ToStockResp() *StockResp {
+	return &StockResp{
+		ProductID:        s.ProductID,
+		Quantity:         s.Quantity,
+		Reserved:         s.Reserved,
+		Available:        s.Available(),
+		ReorderThreshold: s.ReorderThreshold,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+// Verify checks the adjust type, quantity and that the reason code belongs to the type
+func (req *StockAdjustReq) Verify() error {
+	reasonCodes, ok := stockAdjustReasonCodes[req.Type]
+	if !ok {
+		return ErrAdjustType
+	}
+
+	if req.Type == StockAdjustCorrection {
+		if req.Quantity < 0 {
+			return ErrQuantity
+		}
+	} else if req.Quantity <= 0 {
+		return ErrAdjustQuantity
+	}
+
+	for _, code := range reasonCodes {
+		if req.ReasonCode == code {
+			return nil
+		}
+	}
+	return ErrAdjustReasonCode
+}
+
+// AdjustReason maps an adjust type to the ledger reason
+func (req *StockAdjustReq) AdjustReason() string {
+	if req.Type == StockAdjustRestock {
+		return StockReasonRestock
+	}
+	return StockReasonAdjustment
+}
+
 // ------------------------ Private Method ------------------------
\ No newline at end of file
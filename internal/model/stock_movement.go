@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StockReasonInitial        = "INITIAL"
+	StockReasonOrderPlaced    = "ORDER_PLACED"
+	StockReasonOrderCancelled = "ORDER_CANCELLED"
+	StockReasonOrderReturned  = "ORDER_RETURNED"
+	StockReasonRestock        = "RESTOCK"
+	StockReasonAdjustment     = "ADJUSTMENT"
+	StockReasonTransfer       = "TRANSFER"
+)
+
+// StockMovement is an append-only ledger entry, one per change of Stock.Quantity
+type StockMovement struct {
+	ID          string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	ProductID   string    `gorm:"column:product_id;index" bson:"product_id" json:"product_id"`
+	WarehouseID string    `gorm:"column:warehouse_id" bson:"warehouse_id" json:"warehouse_id"`
+	Delta       int       `gorm:"column:delta" bson:"delta" json:"delta"`
+	Reason      string    `gorm:"column:reason" bson:"reason" json:"reason"`
+	ReferenceID string    `gorm:"column:reference_id" bson:"reference_id" json:"reference_id"`
+	ActorID     string    `gorm:"column:actor_id" bson:"actor_id" json:"actor_id"`
+	Note        string    `gorm:"column:note" bson:"note" json:"note"`
+	CreatedAt   time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+}
+
+// StockRef tells the stock service why a quantity changes and who changed it
+type StockRef struct {
+	Reason      string
+	ReferenceID string
+	ActorID     string
+	Note        string
+	WarehouseID string
+}
+
+// StockMessage is the body published on the stock exchange
+type StockMessage struct {
+	ProductID   string
+	Quantity    int
+	ReferenceID string
+	ActorID     string
+	WarehouseID string
+}
+
+// StockDrift is a stock whose quantity disagrees with its ledger, the product
+// total when WarehouseID is empty or the row of that warehouse. Kept is set when
+// the ledger quantity is under what is reserved, so it was not written back.
+type StockDrift struct {
+	ProductID      string `json:"product_id"`
+	WarehouseID    string `json:"warehouse_id,omitempty"`
+	Quantity       int    `json:"quantity"`
+	Reserved       int    `json:"reserved"`
+	LedgerQuantity int    `json:"ledger_quantity"`
+	Drift          int    `json:"drift"`
+	Kept           bool   `json:"kept,omitempty"`
+}
+
+// ------------------------ Public Method ------------------------
+func (ref *StockRef) ToStockMovement(productID string, delta int) *StockMovement {
+	return &StockMovement{
+		ID:          primitive.NewObjectID().Hex(),
+		ProductID:   productID,
+		WarehouseID: ref.WarehouseID,
+		Delta:       delta,
+		Reason:      ref.Reason,
+		ReferenceID: ref.ReferenceID,
+		ActorID:     ref.ActorID,
+		Note:        ref.Note,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// HasOpening reports whether the ledger starts with the INITIAL entry of the
+// stock. Stock created before the ledger existed has none, its sum is only the
+// changes made since.
+func HasOpening(movements []StockMovement) bool {
+	for _, m := range movements {
+		if m.Reason == StockReasonInitial {
+			return true
+		}
+	}
+	return false
+}
+
+// SumDelta replays the ledger and returns the quantity it implies
+func SumDelta(movements []StockMovement) int {
+	total := 0
+	for _, m := range movements {
+		total += m.Delta
+	}
+	return total
+}
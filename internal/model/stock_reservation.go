@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReservationActive    = "ACTIVE"
+	ReservationCommitted = "COMMITTED"
+	ReservationReleased  = "RELEASED"
+	ReservationExpired   = "EXPIRED"
+)
+
+// StockReservation holds stock for an unpaid order until it expires
+type StockReservation struct {
+	ID          string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	OrderID     string    `gorm:"column:order_id;uniqueIndex" bson:"order_id" json:"order_id"`
+	ProductID   string    `gorm:"column:product_id;index" bson:"product_id" json:"product_id"`
+	WarehouseID string    `gorm:"column:warehouse_id" bson:"warehouse_id" json:"warehouse_id"`
+	Quantity    int       `gorm:"column:quantity" bson:"quantity" json:"quantity"`
+	Status      string    `gorm:"column:status;index" bson:"status" json:"status"`
+	ExpiresAt   time.Time `gorm:"column:expires_at" bson:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+// ------------------------ Public Method ------------------------
+func NewStockReservation(orderID string, productID string, warehouseID string, quantity int, ttl time.Duration) *StockReservation {
+	now := time.Now()
+	return &StockReservation{
+		ID:          primitive.NewObjectID().Hex(),
+		OrderID:     orderID,
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+		Status:      ReservationActive,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func (r *StockReservation) IsActive() bool {
+	return r.Status == ReservationActive
+}
+
+func (r *StockReservation) IsExpired(now time.Time) bool {
+	return r.IsActive() && now.After(r.ExpiresAt)
+}
+
+func (r *StockReservation) SetStatus(status string) {
+	r.Status = status
+	r.UpdatedAt = time.Now()
+}
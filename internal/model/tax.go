@@ -0,0 +1,121 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrTaxRuleName = errors.New("tax rule name is required")
+	ErrTaxRate     = errors.New("tax rate must be between 0 and 10000 basis points")
+)
+
+const MaxTaxRate = 10000 // basis points, 100%
+
+// TaxRule is one row of the tax rules table. An empty Region or CategoryID
+// matches any, the most specific matching rule taxes a line.
+type TaxRule struct {
+	ID         string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	Name       string    `gorm:"column:name" bson:"name" json:"name"`
+	Region     string    `gorm:"column:region;index" bson:"region" json:"region"`
+	CategoryID string    `gorm:"column:category_id" bson:"category_id" json:"category_id"`
+	Rate       int64     `gorm:"column:rate" bson:"rate" json:"rate"`                // basis points, 700 is 7%
+	Inclusive  bool      `gorm:"column:inclusive" bson:"inclusive" json:"inclusive"` // the price already holds the tax
+	CreatedAt  time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type TaxRuleReq struct {
+	Name       string `json:"name"`
+	Region     string `json:"region"`
+	CategoryID string `json:"category_id"`
+	Rate       int64  `json:"rate"`
+	Inclusive  bool   `json:"inclusive"`
+}
+
+// TaxLine is the tax of one order line. Taxable is the amount after discount
+// the rate applied to, with the tax still in it when Inclusive.
+type TaxLine struct {
+	ProductID string `json:"product_id" bson:"product_id"`
+	RuleID    string `json:"rule_id,omitempty" bson:"rule_id,omitempty"`
+	Name      string `json:"name" bson:"name"`
+	Rate      int64  `json:"rate" bson:"rate"`
+	Inclusive bool   `json:"inclusive" bson:"inclusive"`
+	Taxable   Money  `json:"taxable" bson:"taxable"`
+	Tax       Money  `json:"tax" bson:"tax"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *TaxRuleReq) Verify() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return ErrTaxRuleName
+	}
+	if req.Rate < 0 || req.Rate > MaxTaxRate {
+		return ErrTaxRate
+	}
+	return nil
+}
+
+func (req *TaxRuleReq) ToTaxRule() *TaxRule {
+	now := time.Now()
+	rule := &TaxRule{
+		ID:        primitive.NewObjectID().Hex(),
+		CreatedAt: now,
+	}
+	rule.Apply(req)
+	return rule
+}
+
+func (r *TaxRule) Apply(req *TaxRuleReq) {
+	r.Name = req.Name
+	r.Region = strings.TrimSpace(req.Region)
+	r.CategoryID = req.CategoryID
+	r.Rate = req.Rate
+	r.Inclusive = req.Inclusive
+	r.UpdatedAt = time.Now()
+}
+
+// Specificity ranks how closely the rule targets a line, a region counts
+// over a category and both over neither
+func (r *TaxRule) Specificity() int {
+	specificity := 0
+	if r.Region != "" {
+		specificity += 2
+	}
+	if r.CategoryID != "" {
+		specificity++
+	}
+	return specificity
+}
+
+// TaxLineOf taxes amount at the rule. Each line rounds half up on its own,
+// see Rounding.
+func (r *TaxRule) TaxLineOf(productID string, amount Money) TaxLine {
+	return NewTaxLine(productID, r.ID, r.Name, r.Rate, r.Inclusive, amount)
+}
+
+func NewTaxLine(productID string, ruleID string, name string, rate int64, inclusive bool, amount Money) TaxLine {
+	tax := amount.TaxAt(rate)
+	if inclusive {
+		tax = amount.TaxIncluded(rate)
+	}
+	return TaxLine{
+		ProductID: productID,
+		RuleID:    ruleID,
+		Name:      name,
+		Rate:      rate,
+		Inclusive: inclusive,
+		Taxable:   amount,
+		Tax:       tax,
+	}
+}
+
+// TaxIncluded is the tax already inside a gross m at a rate in basis points,
+// rounded half up
+func (m Money) TaxIncluded(basisPoints int64) Money {
+	return m.MulRat(basisPoints, 10000+basisPoints, RoundHalfUp)
+}
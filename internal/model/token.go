@@ -0,0 +1,50 @@
+package model
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrRefreshReused = errors.New("refresh token was already used, the session is revoked")
+	ErrLogoutToken   = errors.New("send the access token or the refresh token to log out")
+)
+
+const TokenTypeBearer = "Bearer"
+
+// Session is one sign in. Its refresh token rotates on every refresh and only
+// the hash of the current one is kept, presenting an older one revokes it.
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	CreatedAt time.Time `json:"created_at"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResp is sent on login and refresh, the refresh token is shown only here
+type TokenResp struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // seconds the access token is valid
+}
+
+// ------------------------ Public Method ------------------------
+func (req *RefreshReq) Verify() error {
+	if req.RefreshToken == "" {
+		return ErrRefreshToken
+	}
+	return nil
+}
+
+// Matches reports whether tokenHash is the current refresh token of the session
+func (s *Session) Matches(tokenHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(s.TokenHash), []byte(tokenHash)) == 1
+}
@@ -9,14 +9,16 @@ import (
 )
 
 type User struct {
-	ID        string     `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
-	Role      string     `gorm:"column:role" bson:"role"`
-	Username  string     `gorm:"column:username" bson:"username"`
-	Password  string     `gorm:"column:password" bson:"password"`
-	Email     string     `gorm:"column:email;unique" bson:"email"`
-	CreatedAt time.Time  `gorm:"column:created_at" bson:"created_at"`
-	UpdatedAt time.Time  `gorm:"column:updated_at" bson:"updated_at"`
-	DeletedAt *time.Time `gorm:"column:deleted_at;index" bson:"deleted_at,omitempty"`
+	ID              string     `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	Role            string     `gorm:"column:role" bson:"role"`
+	Username        string     `gorm:"column:username" bson:"username"`
+	Password        string     `gorm:"column:password" bson:"password"`
+	Email           string     `gorm:"column:email;unique" bson:"email"`
+	EmailVerified   bool       `gorm:"column:email_verified" bson:"email_verified"`
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" bson:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at" bson:"updated_at"`
+	DeletedAt       *time.Time `gorm:"column:deleted_at;index" bson:"deleted_at,omitempty"`
 }
 
 // ------------------------ Setter ------------------------
@@ -35,8 +37,11 @@ func (u *User) SetDefaultNotNilField(req *User){
 		u.Username = req.Username
 	}
 
-	if req.Email != "" {
+	if req.Email != "" && req.Email != u.Email {
+		// a new email is verified again through a link sent to it
 		u.Email = req.Email
+		u.EmailVerified = false
+		u.EmailVerifiedAt = nil
 	}
 
 	if req.Password != "" {
@@ -46,6 +51,14 @@ func (u *User) SetDefaultNotNilField(req *User){
 	u.UpdatedAt = time.Now()
 }
 
+// MarkEmailVerified is set once the user opened the link sent to their email
+func (u *User) MarkEmailVerified() {
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+	u.UpdatedAt = now
+}
+
 // ------------------------ Public Method ------------------------
 func (u *User) Verify() error {
 	if u.Username != "" {
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetDefaultNotNilFieldEmailVerification(t *testing.T) {
+	verifiedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		req          User
+		wantEmail    string
+		wantVerified bool
+	}{
+		{name: "new email is unverified", req: User{Email: "new@example.com"}, wantEmail: "new@example.com"},
+		{name: "same email stays verified", req: User{Email: "u1@example.com"}, wantEmail: "u1@example.com", wantVerified: true},
+		{name: "no email stays verified", req: User{Username: "u1"}, wantEmail: "u1@example.com", wantVerified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := User{Email: "u1@example.com", EmailVerified: true, EmailVerifiedAt: &verifiedAt}
+			u.SetDefaultNotNilField(&tt.req)
+
+			if u.Email != tt.wantEmail || u.EmailVerified != tt.wantVerified {
+				t.Errorf("email %s verified %v, want %s verified %v", u.Email, u.EmailVerified, tt.wantEmail, tt.wantVerified)
+			}
+			if (u.EmailVerifiedAt != nil) != tt.wantVerified {
+				t.Errorf("email verified at = %v, want it set only while verified", u.EmailVerifiedAt)
+			}
+		})
+	}
+}
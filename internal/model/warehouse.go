@@ -0,0 +1,201 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrWarehouseName     = errors.New("warehouse name is required")
+	ErrTransferWarehouse = errors.New("transfer needs two different warehouses")
+	ErrTransferQuantity  = errors.New("transfer quantity must be greater than zero")
+)
+
+const (
+	AllocationMostStock = "MOST_STOCK"
+	AllocationNearest   = "NEAREST"
+
+	DefaultWarehouseName = "Default"
+)
+
+// Warehouse is a location a seller ships from, new stock lands in the default one
+type Warehouse struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty" json:"id"`
+	SellerID  string    `gorm:"column:seller_id;index" bson:"seller_id" json:"seller_id"`
+	Name      string    `gorm:"column:name" bson:"name" json:"name"`
+	Region    string    `gorm:"column:region" bson:"region" json:"region"`
+	IsDefault bool      `gorm:"column:is_default" bson:"is_default" json:"is_default"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" bson:"updated_at" json:"updated_at"`
+}
+
+type WarehouseReq struct {
+	Name      string `json:"name"`
+	Region    string `json:"region"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// WarehouseStock is the part of a Stock held in one warehouse. The Stock row
+// keeps the totals across every warehouse of the product.
+type WarehouseStock struct {
+	ID          string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	ProductID   string    `gorm:"column:product_id;uniqueIndex:idx_product_warehouse" bson:"product_id"`
+	WarehouseID string    `gorm:"column:warehouse_id;uniqueIndex:idx_product_warehouse" bson:"warehouse_id"`
+	Quantity    int       `gorm:"column:quantity" bson:"quantity"`
+	Reserved    int       `gorm:"column:reserved" bson:"reserved"`
+	CreatedAt   time.Time `gorm:"column:created_at" bson:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" bson:"updated_at"`
+}
+
+type WarehouseStockResp struct {
+	WarehouseID string `json:"warehouse_id"`
+	Quantity    int    `json:"quantity"`
+	Reserved    int    `json:"reserved"`
+	Available   int    `json:"available"`
+}
+
+type StockTransferReq struct {
+	FromWarehouseID string `json:"from_warehouse_id"`
+	ToWarehouseID   string `json:"to_warehouse_id"`
+	Quantity        int    `json:"quantity"`
+	Note            string `json:"note"`
+}
+
+// ------------------------ Public Method ------------------------
+func NewDefaultWarehouse(sellerID string, region string) *Warehouse {
+	now := time.Now()
+	return &Warehouse{
+		ID:        primitive.NewObjectID().Hex(),
+		SellerID:  sellerID,
+		Name:      DefaultWarehouseName,
+		Region:    region,
+		IsDefault: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (req *WarehouseReq) Verify() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrWarehouseName
+	}
+	return nil
+}
+
+func (req *WarehouseReq) ToWarehouse(sellerID string) *Warehouse {
+	now := time.Now()
+	return &Warehouse{
+		ID:        primitive.NewObjectID().Hex(),
+		SellerID:  sellerID,
+		Name:      strings.TrimSpace(req.Name),
+		Region:    strings.TrimSpace(req.Region),
+		IsDefault: req.IsDefault,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (w *Warehouse) Apply(req *WarehouseReq) {
+	w.Name = strings.TrimSpace(req.Name)
+	w.Region = strings.TrimSpace(req.Region)
+	w.IsDefault = w.IsDefault || req.IsDefault
+	w.UpdatedAt = time.Now()
+}
+
+func NewWarehouseStock(productID string, warehouseID string) *WarehouseStock {
+	now := time.Now()
+	return &WarehouseStock{
+		ID:          primitive.NewObjectID().Hex(),
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func (ws *WarehouseStock) Available() int {
+	return ws.Quantity - ws.Reserved
+}
+
+func (ws *WarehouseStock) IncreaseQuantity(quantity int) {
+	ws.Quantity += quantity
+	ws.UpdatedAt = time.Now()
+}
+
+func (ws *WarehouseStock) DecreaseQuantity(quantity int) error {
+	if ws.Available() < quantity {
+		return ErrDebtStock
+	}
+	ws.Quantity -= quantity
+	ws.UpdatedAt = time.Now()
+	return nil
+}
+
+func (ws *WarehouseStock) Reserve(quantity int) error {
+	if ws.Available() < quantity {
+		return ErrDebtStock
+	}
+	ws.Reserved += quantity
+	ws.UpdatedAt = time.Now()
+	return nil
+}
+
+func (ws *WarehouseStock) ReleaseReserved(quantity int) {
+	ws.Reserved = max(ws.Reserved-quantity, 0)
+	ws.UpdatedAt = time.Now()
+}
+
+func (ws *WarehouseStock) CommitReserved(quantity int) {
+	ws.ReleaseReserved(quantity)
+	ws.Quantity = max(ws.Quantity-quantity, 0)
+}
+
+// ShiftQuantity moves the quantity by delta without going under what is
+// reserved and returns the change actually made
+func (ws *WarehouseStock) ShiftQuantity(delta int) int {
+	before := ws.Quantity
+	ws.Quantity = max(ws.Quantity+delta, ws.Reserved, 0)
+	ws.UpdatedAt = time.Now()
+	return ws.Quantity - before
+}
+
+// Apply runs a seller adjust against this warehouse and returns the change in quantity
+func (ws *WarehouseStock) Apply(req *StockAdjustReq) (int, error) {
+	before := ws.Quantity
+	switch req.Type {
+	case StockAdjustRestock:
+		ws.IncreaseQuantity(req.Quantity)
+	case StockAdjustWriteOff:
+		if err := ws.DecreaseQuantity(req.Quantity); err != nil {
+			return 0, err
+		}
+	case StockAdjustCorrection:
+		// held items can't be corrected away under an open reservation
+		ws.ShiftQuantity(req.Quantity - ws.Quantity)
+	default:
+		return 0, ErrAdjustType
+	}
+	return ws.Quantity - before, nil
+}
+
+func (ws *WarehouseStock) ToWarehouseStockResp() *WarehouseStockResp {
+	return &WarehouseStockResp{
+		WarehouseID: ws.WarehouseID,
+		Quantity:    ws.Quantity,
+		Reserved:    ws.Reserved,
+		Available:   ws.Available(),
+	}
+}
+
+func (req *StockTransferReq) Verify() error {
+	if req.FromWarehouseID == "" || req.ToWarehouseID == "" || req.FromWarehouseID == req.ToWarehouseID {
+		return ErrTransferWarehouse
+	}
+	if req.Quantity <= 0 {
+		return ErrTransferQuantity
+	}
+	return nil
+}
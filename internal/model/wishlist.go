@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrWishlistProduct = errors.New("product id is required")
+	ErrWishlistFull    = errors.New("wishlist holds at most 200 products")
+)
+
+const (
+	WishlistMaxItems = 200
+
+	NotificationPriceDrop = "PRICE_DROP"
+)
+
+// Wishlist is one product a user saved for later, a user's wishlist is all of
+// them. The price is what the product cost when it was saved.
+type Wishlist struct {
+	ID        string    `gorm:"column:id;primaryKey" bson:"_id,omitempty"`
+	UserID    string    `gorm:"column:user_id;uniqueIndex:idx_wishlist_user_product" bson:"user_id"`
+	ProductID string    `gorm:"column:product_id;uniqueIndex:idx_wishlist_user_product;index" bson:"product_id"`
+	Price     Money     `gorm:"embedded;embeddedPrefix:price_" bson:"price"`
+	CreatedAt time.Time `gorm:"column:created_at" bson:"created_at"`
+}
+
+type WishlistReq struct {
+	ProductID string `json:"product_id"`
+}
+
+type WishlistResp struct {
+	ProductID  string    `json:"product_id"`
+	Title      string    `json:"title"`
+	Price      Money     `json:"price"`       // the price now
+	SavedPrice Money     `json:"saved_price"` // the price when it was saved
+	PriceDrop  bool      `json:"price_drop"`  // cheaper now than when saved
+	Available  bool      `json:"available"`   // false once the product is gone
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PriceChangedEvent is the body of the product.price_changed event, it is
+// only published when the price went down
+type PriceChangedEvent struct {
+	ProductID string `json:"product_id"`
+	Title     string `json:"title"`
+	OldPrice  Money  `json:"old_price"`
+	NewPrice  Money  `json:"new_price"`
+}
+
+// ------------------------ Public Method ------------------------
+func (req *WishlistReq) Verify() error {
+	if req.ProductID == "" {
+		return ErrWishlistProduct
+	}
+	return nil
+}
+
+func (req *WishlistReq) ToWishlist(userID string, price Money) *Wishlist {
+	return &Wishlist{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		ProductID: req.ProductID,
+		Price:     price,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ToWishlistResp shows the item against the product as it is now, a nil
+// product is one that no longer exists
+func (w *Wishlist) ToWishlistResp(product *ProductResp) *WishlistResp {
+	resp := &WishlistResp{
+		ProductID:  w.ProductID,
+		Price:      w.Price,
+		SavedPrice: w.Price,
+		CreatedAt:  w.CreatedAt,
+	}
+	if product != nil {
+		resp.Title = product.Title
+		resp.Price = product.Price
+		resp.PriceDrop = IsPriceDrop(w.Price, product.Price)
+		resp.Available = true
+	}
+	return resp
+}
+
+// IsPriceDrop is true when to is cheaper than from, prices in different
+// currencies can't be compared and never count as a drop
+func IsPriceDrop(from Money, to Money) bool {
+	return from.Currency == to.Currency && to.Amount < from.Amount
+}
@@ -0,0 +1,326 @@
+package cart
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrGetCart    = errors.New("fail to get cart")
+	ErrSaveCart   = errors.New("fail to save cart")
+	ErrCheckout   = errors.New("fail to check out cart")
+	ErrNoProduct  = errors.New("product not found")
+	ErrNoStock    = errors.New("not enough stock for this quantity")
+	ErrGuestMerge = errors.New("only a guest cart can be merged")
+)
+
+type cartService struct {
+	cartRepo   repository.CartRepository
+	productSvc module.ProductService
+	stockSvc   module.StockService
+	orderSvc   module.OrderService
+}
+
+// ------------------------ Constructor ------------------------
+func NewCartService(cartRepo repository.CartRepository, productSvc module.ProductService, stockSvc module.StockService, orderSvc module.OrderService) module.CartService {
+	return &cartService{
+		cartRepo:   cartRepo,
+		productSvc: productSvc,
+		stockSvc:   stockSvc,
+		orderSvc:   orderSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// AddLine puts an item in the cart at its current price. A guest without a
+// cart yet is given a new guest id, returned with the cart.
+func (s *cartService) AddLine(ctx context.Context, owner model.CartOwner, req *model.CartLineReq) (*model.CartResp, error) {
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+	if owner.IsGuest() && owner.GuestID == "" {
+		guestID, err := newGuestID()
+		if err != nil {
+			return nil, ErrSaveCart
+		}
+		owner.GuestID = guestID
+	}
+
+	cart, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productSvc.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, ErrNoProduct
+	}
+	price, err := product.PriceOf(req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := cart.Add(req, price); err != nil {
+		return nil, err
+	}
+
+	return s.saveAndShow(ctx, owner, cart)
+}
+
+// UpdateLine sets the quantity of a line, 0 removes it
+func (s *cartService) UpdateLine(ctx context.Context, owner model.CartOwner, id string, req *model.CartQuantityReq) (*model.CartResp, error) {
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := cart.SetQuantity(id, req.Quantity); err != nil {
+		return nil, err
+	}
+	return s.saveAndShow(ctx, owner, cart)
+}
+
+func (s *cartService) RemoveLine(ctx context.Context, owner model.CartOwner, id string) (*model.CartResp, error) {
+	cart, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := cart.Remove(id); err != nil {
+		return nil, err
+	}
+	return s.saveAndShow(ctx, owner, cart)
+}
+
+func (s *cartService) Clear(ctx context.Context, owner model.CartOwner) error {
+	if err := owner.Verify(); err != nil {
+		return err
+	}
+	if err := s.cartRepo.DeleteCart(ctx, owner); err != nil {
+		log.WithError(err).WithField("cart", owner.Key()).Error("[Service]: delete cart")
+		return ErrSaveCart
+	}
+	return nil
+}
+
+// Merge moves the guest cart into the cart of the user who just signed in
+func (s *cartService) Merge(ctx context.Context, guestID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"user_id": userID,
+		"layer":   "cart_service",
+		"method":  "cart_merge",
+	}
+
+	if userID == "" {
+		return ErrGuestMerge
+	}
+	guest := model.CartOwner{GuestID: guestID}
+	user := model.CartOwner{UserID: userID}
+
+	guestCart, err := s.load(ctx, guest)
+	if err != nil {
+		return err
+	}
+	if guestCart.IsEmpty() {
+		return nil
+	}
+
+	cart, err := s.load(ctx, user)
+	if err != nil {
+		return err
+	}
+	cart.Merge(guestCart)
+	if err := s.cartRepo.SaveCart(ctx, user, cart); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("save cart")
+		return ErrSaveCart
+	}
+
+	if err := s.cartRepo.DeleteCart(ctx, guest); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("delete guest cart")
+	}
+	log.Info("[Service]: guest cart merged success:", userID)
+	return nil
+}
+
+// Checkout places one order per line of the user's cart, an order holds a
+// single product. It stops when the cart changed since it was last shown, and
+// cancels the orders already placed when a later one fails.
+func (s *cartService) Checkout(ctx context.Context, userID string, req *model.CheckoutReq) (*model.CheckoutResp, error) {
+	var baseLogFields = log.Fields{
+		"user_id": userID,
+		"layer":   "cart_service",
+		"method":  "cart_checkout",
+	}
+
+	owner := model.CartOwner{UserID: userID}
+	cart, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart.IsEmpty() {
+		return nil, model.ErrCartEmpty
+	}
+
+	view := s.show(ctx, cart)
+	for _, line := range view.Lines {
+		if line.PreviousPrice != nil || line.Problem != "" {
+			return nil, model.ErrCartChanged
+		}
+	}
+
+	orders := make([]model.OrderResp, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		order, err := s.orderSvc.Save(ctx, line.ToOrderReq(req), userID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("save order")
+			for _, placed := range orders {
+				if err := s.orderSvc.Delete(ctx, placed.ID, userID); err != nil {
+					log.WithError(err).WithFields(baseLogFields).Error("cancel order " + placed.ID)
+				}
+			}
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	if err := s.cartRepo.DeleteCart(ctx, owner); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("delete cart")
+	}
+	log.Info("[Service]: cart checked out success:", userID)
+	return &model.CheckoutResp{Orders: orders}, nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+// Get shows the cart at current prices and stock. A price change is shown
+// once, the cart then keeps the new price.
+func (s *cartService) Get(ctx context.Context, owner model.CartOwner) (*model.CartResp, error) {
+	if owner.IsGuest() && owner.GuestID == "" {
+		return s.show(ctx, &model.Cart{}), nil
+	}
+
+	cart, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart.IsEmpty() {
+		return s.show(ctx, cart), nil
+	}
+	return s.saveAndShow(ctx, owner, cart)
+}
+
+// ------------------------ Private Method ------------------------
+func (s *cartService) load(ctx context.Context, owner model.CartOwner) (*model.Cart, error) {
+	if err := owner.Verify(); err != nil {
+		return nil, err
+	}
+
+	var cart model.Cart
+	if err := s.cartRepo.GetCart(ctx, owner, &cart); err != nil {
+		log.WithError(err).WithField("cart", owner.Key()).Error("[Service]: get cart")
+		return nil, ErrGetCart
+	}
+	return &cart, nil
+}
+
+// saveAndShow revalidates the cart, then saves it with the prices shown
+func (s *cartService) saveAndShow(ctx context.Context, owner model.CartOwner, cart *model.Cart) (*model.CartResp, error) {
+	view := s.show(ctx, cart)
+	if err := s.cartRepo.SaveCart(ctx, owner, cart); err != nil {
+		log.WithError(err).WithField("cart", owner.Key()).Error("[Service]: save cart")
+		return nil, ErrSaveCart
+	}
+	if owner.IsGuest() {
+		view.GuestID = owner.GuestID
+	}
+	return view, nil
+}
+
+// show prices every line at the current product price and checks its stock.
+// Lines of the cart take the current price, the view keeps the one before.
+func (s *cartService) show(ctx context.Context, cart *model.Cart) *model.CartResp {
+	view := &model.CartResp{
+		Lines:     make([]model.CartLineResp, 0, len(cart.Lines)),
+		Totals:    make([]model.Money, 0),
+		Valid:     true,
+		UpdatedAt: cart.UpdatedAt,
+	}
+
+	totals := make(map[string]int64)
+	var currencies []string
+	for i := range cart.Lines {
+		line := &cart.Lines[i]
+		lineView := model.CartLineResp{
+			ID:        line.ID(),
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			Price:     line.Price,
+		}
+
+		product, err := s.productSvc.GetByID(ctx, line.ProductID)
+		if err == nil {
+			lineView.Title = product.Title
+			for _, variant := range product.Variants {
+				if variant.ID == line.VariantID {
+					lineView.Title += " (" + variant.SKU + ")"
+				}
+			}
+		}
+
+		var price model.Money
+		if err == nil {
+			price, err = product.PriceOf(line.VariantID)
+		}
+		switch {
+		case err != nil:
+			lineView.Problem = model.CartLineUnavailable
+		case price != line.Price:
+			previous := line.Price
+			lineView.PreviousPrice = &previous
+			lineView.Price = price
+			line.Price = price
+		}
+
+		if lineView.Problem == "" {
+			if stock, err := s.stockSvc.GetByProductID(ctx, line.ID()); err == nil {
+				lineView.Available = stock.Available
+			}
+			if lineView.Available < line.Quantity {
+				lineView.Problem = model.CartLineOutOfStock
+			}
+		}
+
+		lineView.Subtotal = lineView.Price.Mul(int64(line.Quantity))
+		if lineView.Problem != "" {
+			view.Valid = false
+		} else {
+			if _, ok := totals[lineView.Subtotal.Currency]; !ok {
+				currencies = append(currencies, lineView.Subtotal.Currency)
+			}
+			totals[lineView.Subtotal.Currency] += lineView.Subtotal.Amount
+		}
+		view.Lines = append(view.Lines, lineView)
+	}
+
+	for _, currency := range currencies {
+		view.Totals = append(view.Totals, model.NewMoney(totals[currency], currency))
+	}
+	return view
+}
+
+func newGuestID() (string, error) {
+	b := make([]byte, model.CartGuestIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
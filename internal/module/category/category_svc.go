@@ -0,0 +1,288 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateCategory      = errors.New("fail to create category")
+	ErrUpdateCategory      = errors.New("fail to update category")
+	ErrDeleteCategory      = errors.New("fail to delete category")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrSlugTaken           = errors.New("category slug is already used")
+	ErrCategoryHasChildren = errors.New("category still has children, move or delete them first")
+	ErrAssignCategory      = errors.New("fail to assign product categories")
+	ErrPermission          = errors.New("no permission to categorize this product")
+)
+
+type categoryService struct {
+	categoryRepo        repository.CategoryRepository
+	productCategoryRepo repository.ProductCategoryRepository
+	productSvc          module.ProductService
+}
+
+// ------------------------ Constructor ------------------------
+func NewCategoryService(categoryRepo repository.CategoryRepository, productCategoryRepo repository.ProductCategoryRepository, productSvc module.ProductService) module.CategoryService {
+	return &categoryService{
+		categoryRepo:        categoryRepo,
+		productCategoryRepo: productCategoryRepo,
+		productSvc:          productSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (s *categoryService) Save(ctx context.Context, req *model.CategoryReq) (*model.Category, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "category_service",
+		"method": "category_save",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all category")
+		return nil, ErrCreateCategory
+	}
+
+	if err := checkCategory(categories, req, ""); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check category")
+		return nil, err
+	}
+
+	category := req.ToCategory()
+	if err := s.categoryRepo.AddCategory(ctx, category); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add category")
+		return nil, ErrCreateCategory
+	}
+	return category, nil
+}
+
+func (s *categoryService) Update(ctx context.Context, req *model.CategoryReq, id string) (*model.Category, error) {
+	var baseLogFields = log.Fields{
+		"category_id": id,
+		"layer":       "category_service",
+		"method":      "category_update",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var category model.Category
+	if err := s.categoryRepo.GetCategoryByID(ctx, id, &category); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get category by id")
+		return nil, ErrCategoryNotFound
+	}
+
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all category")
+		return nil, ErrUpdateCategory
+	}
+
+	if err := checkCategory(categories, req, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check category")
+		return nil, err
+	}
+
+	category.Apply(req)
+	if err := s.categoryRepo.UpdateCategory(ctx, &category, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update category")
+		return nil, ErrUpdateCategory
+	}
+	return &category, nil
+}
+
+// Delete removes a leaf category together with its product assignments
+func (s *categoryService) Delete(ctx context.Context, id string) error {
+	var baseLogFields = log.Fields{
+		"category_id": id,
+		"layer":       "category_service",
+		"method":      "category_delete",
+	}
+
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all category")
+		return ErrDeleteCategory
+	}
+
+	if !slices.ContainsFunc(categories, func(c model.Category) bool { return c.ID == id }) {
+		return ErrCategoryNotFound
+	}
+	if slices.ContainsFunc(categories, func(c model.Category) bool { return c.ParentID == id }) {
+		return ErrCategoryHasChildren
+	}
+
+	assignments, err := s.productCategoryRepo.GetByCategoryID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get product categories")
+		return ErrDeleteCategory
+	}
+	for _, assignment := range assignments {
+		if err := s.productCategoryRepo.DeleteProductCategory(ctx, assignment.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("delete product category")
+			return ErrDeleteCategory
+		}
+	}
+
+	if err := s.categoryRepo.DeleteCategory(ctx, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete category")
+		return ErrDeleteCategory
+	}
+	return nil
+}
+
+// SetProductCategories replaces the categories of a product. Sellers can only
+// categorize their own products, admins any.
+func (s *categoryService) SetProductCategories(ctx context.Context, productID string, categoryIDs []string, userID string, role string) error {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "category_service",
+		"method":     "category_setProductCategories",
+	}
+
+	product, err := s.productSvc.GetByID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get product by id")
+		return err
+	}
+
+	if role != "ADMIN" && product.CreatedBy != userID {
+		return ErrPermission
+	}
+
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all category")
+		return ErrAssignCategory
+	}
+	for _, categoryID := range categoryIDs {
+		if !slices.ContainsFunc(categories, func(c model.Category) bool { return c.ID == categoryID }) {
+			return ErrCategoryNotFound
+		}
+	}
+
+	current, err := s.productCategoryRepo.GetByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get product categories")
+		return ErrAssignCategory
+	}
+
+	for _, assignment := range current {
+		if slices.Contains(categoryIDs, assignment.CategoryID) {
+			continue
+		}
+		if err := s.productCategoryRepo.DeleteProductCategory(ctx, assignment.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("delete product category")
+			return ErrAssignCategory
+		}
+	}
+
+	for _, categoryID := range categoryIDs {
+		if slices.ContainsFunc(current, func(pc model.ProductCategory) bool { return pc.CategoryID == categoryID }) {
+			continue
+		}
+		if err := s.productCategoryRepo.AddProductCategory(ctx, model.NewProductCategory(productID, categoryID)); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("add product category")
+			return ErrAssignCategory
+		}
+	}
+
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *categoryService) GetTree(ctx context.Context) ([]model.CategoryNode, error) {
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"layer":  "category_service",
+			"method": "category_getTree",
+		}).Error("get all category")
+		return nil, ErrCategoryNotFound
+	}
+	return model.BuildCategoryTree(categories), nil
+}
+
+// GetProductsBySlug lists the products of a category and of every category below it
+func (s *categoryService) GetProductsBySlug(ctx context.Context, slug string) ([]model.ProductResp, error) {
+	var baseLogFields = log.Fields{
+		"slug":   slug,
+		"layer":  "category_service",
+		"method": "category_getProductsBySlug",
+	}
+
+	categories, err := s.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all category")
+		return nil, ErrCategoryNotFound
+	}
+
+	i := slices.IndexFunc(categories, func(c model.Category) bool { return c.Slug == slug })
+	if i < 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	seen := make(map[string]bool)
+	products := make([]model.ProductResp, 0)
+	for _, categoryID := range model.DescendantIDs(categories, categories[i].ID) {
+		assignments, err := s.productCategoryRepo.GetByCategoryID(ctx, categoryID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("get product categories")
+			return nil, ErrCategoryNotFound
+		}
+
+		for _, assignment := range assignments {
+			if seen[assignment.ProductID] {
+				continue
+			}
+			seen[assignment.ProductID] = true
+
+			product, err := s.productSvc.GetByID(ctx, assignment.ProductID)
+			if err != nil {
+				log.WithError(err).WithFields(baseLogFields).Warnf("product {%s} not found", assignment.ProductID)
+				continue
+			}
+			products = append(products, *product)
+		}
+	}
+
+	return products, nil
+}
+
+// ------------------------ Private Method ------------------------
+// checkCategory validates the parent and slug of a request against the current
+// tree, id is the category being updated or empty for a new one
+func checkCategory(categories []model.Category, req *model.CategoryReq, id string) error {
+	if req.ParentID != "" {
+		if !slices.ContainsFunc(categories, func(c model.Category) bool { return c.ID == req.ParentID }) {
+			return ErrParentNotFound
+		}
+		if id != "" && slices.Contains(model.DescendantIDs(categories, id), req.ParentID) {
+			return model.ErrCategoryParent
+		}
+	}
+
+	for _, c := range categories {
+		if c.Slug == req.Slug && c.ID != id {
+			return ErrSlugTaken
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package category
+
+import (
+	"errors"
+	"go-rebuild/internal/model"
+	"testing"
+)
+
+func TestCheckCategory(t *testing.T) {
+	categories := []model.Category{
+		{ID: "electronics", Slug: "electronics"},
+		{ID: "phones", ParentID: "electronics", Slug: "phones"},
+		{ID: "cases", ParentID: "phones", Slug: "cases"},
+		{ID: "books", Slug: "books"},
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		req     model.CategoryReq
+		wantErr error
+	}{
+		{name: "new root", req: model.CategoryReq{Slug: "toys"}},
+		{name: "new child", req: model.CategoryReq{Slug: "tablets", ParentID: "electronics"}},
+		{name: "missing parent", req: model.CategoryReq{Slug: "tablets", ParentID: "deleted"}, wantErr: ErrParentNotFound},
+		{name: "slug taken", req: model.CategoryReq{Slug: "books"}, wantErr: ErrSlugTaken},
+		{name: "update keeps its own slug", id: "books", req: model.CategoryReq{Slug: "books"}},
+		{name: "move under another branch", id: "phones", req: model.CategoryReq{Slug: "phones", ParentID: "books"}},
+		{name: "under itself", id: "phones", req: model.CategoryReq{Slug: "phones", ParentID: "phones"}, wantErr: model.ErrCategoryParent},
+		{name: "under its child", id: "phones", req: model.CategoryReq{Slug: "phones", ParentID: "cases"}, wantErr: model.ErrCategoryParent},
+		{name: "under its grandchild", id: "electronics", req: model.CategoryReq{Slug: "electronics", ParentID: "cases"}, wantErr: model.ErrCategoryParent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCategory(categories, &tt.req, tt.id); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package coupon
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateCoupon   = errors.New("fail to create coupon")
+	ErrUpdateCoupon   = errors.New("fail to update coupon")
+	ErrDeleteCoupon   = errors.New("fail to delete coupon")
+	ErrGetCoupon      = errors.New("fail to get coupons")
+	ErrCouponNotFound = errors.New("coupon not found")
+	ErrCodeTaken      = errors.New("coupon code is already used")
+	ErrRedeemCoupon   = model.ErrCouponRedeem
+)
+
+type couponService struct {
+	tx             repository.Transactor
+	couponRepo     repository.CouponRepository
+	redemptionRepo repository.CouponRedemptionRepository
+}
+
+// ------------------------ Constructor ------------------------
+func NewCouponService(tx repository.Transactor, couponRepo repository.CouponRepository, redemptionRepo repository.CouponRedemptionRepository) module.CouponService {
+	return &couponService{
+		tx:             tx,
+		couponRepo:     couponRepo,
+		redemptionRepo: redemptionRepo,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (s *couponService) Save(ctx context.Context, req *model.CouponReq) (*model.Coupon, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "coupon_service",
+		"method": "coupon_save",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var existing model.Coupon
+	if err := s.couponRepo.GetCouponByCode(ctx, req.Code, &existing); err == nil {
+		return nil, ErrCodeTaken
+	}
+
+	coupon := req.ToCoupon()
+	if err := s.couponRepo.AddCoupon(ctx, coupon); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add coupon")
+		return nil, ErrCreateCoupon
+	}
+
+	log.WithFields(baseLogFields).Infof("[Service]: coupon {%s} created", coupon.Code)
+	return coupon, nil
+}
+
+func (s *couponService) Update(ctx context.Context, req *model.CouponReq, id string) (*model.Coupon, error) {
+	var baseLogFields = log.Fields{
+		"coupon_id": id,
+		"layer":     "coupon_service",
+		"method":    "coupon_update",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var coupon model.Coupon
+	if err := s.couponRepo.GetCouponByID(ctx, id, &coupon); err != nil {
+		return nil, ErrCouponNotFound
+	}
+
+	var existing model.Coupon
+	if err := s.couponRepo.GetCouponByCode(ctx, req.Code, &existing); err == nil && existing.ID != id {
+		return nil, ErrCodeTaken
+	}
+
+	coupon.Apply(req)
+	if err := s.couponRepo.UpdateCoupon(ctx, &coupon); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update coupon")
+		return nil, ErrUpdateCoupon
+	}
+	return &coupon, nil
+}
+
+// Delete removes the coupon, orders placed with it keep their discount
+func (s *couponService) Delete(ctx context.Context, id string) error {
+	var baseLogFields = log.Fields{
+		"coupon_id": id,
+		"layer":     "coupon_service",
+		"method":    "coupon_delete",
+	}
+
+	var coupon model.Coupon
+	if err := s.couponRepo.GetCouponByID(ctx, id, &coupon); err != nil {
+		return ErrCouponNotFound
+	}
+
+	if err := s.couponRepo.DeleteCoupon(ctx, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete coupon")
+		return ErrDeleteCoupon
+	}
+	return nil
+}
+
+// ------------------------ Method Redeem ------------------------
+// Redeem checks the coupon behind code against the order, takes its discount
+// off the order and records the use. The checks only fail early, the limits
+// hold through the guarded use count and the unique redemption slot, written
+// in one transaction. The order is not saved here.
+func (s *couponService) Redeem(ctx context.Context, code string, order *model.Order, sellerID string) error {
+	var baseLogFields = log.Fields{
+		"order_id": order.ID,
+		"user_id":  order.UserID,
+		"layer":    "coupon_service",
+		"method":   "coupon_redeem",
+	}
+
+	var coupon model.Coupon
+	if err := s.couponRepo.GetCouponByCode(ctx, model.NormalizeCouponCode(code), &coupon); err != nil {
+		return ErrCouponNotFound
+	}
+
+	if err := coupon.Check(time.Now(), order.ProductID, sellerID, order.Subtotal); err != nil {
+		return err
+	}
+
+	discount := coupon.Discount(order.Subtotal)
+	redemption := model.NewCouponRedemption(coupon.ID, order.UserID, order.ID, discount)
+	if coupon.MaxUsesPerUser > 0 {
+		slot, err := s.redemptionSlot(ctx, &coupon, order.UserID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("get redemption slot")
+			return err
+		}
+		redemption.Slot = &slot
+	}
+
+	if err := order.ApplyDiscount(coupon.Code, discount); err != nil {
+		return err
+	}
+
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		used, err := s.couponRepo.UseCoupon(ctx, coupon.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return model.ErrCouponUsedUp
+		}
+		return s.redemptionRepo.AddRedemption(ctx, redemption)
+	})
+	if errors.Is(err, model.ErrCouponUsedUp) {
+		return err
+	}
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("redeem")
+		// the slot was taken by another order of the same user in the meantime
+		if redemption.Slot != nil {
+			if _, err := s.redemptionSlot(ctx, &coupon, order.UserID); errors.Is(err, model.ErrCouponUserUsed) {
+				return err
+			}
+		}
+		return ErrRedeemCoupon
+	}
+
+	log.WithFields(baseLogFields).Infof("[Service]: coupon {%s} redeemed for %s", coupon.Code, discount)
+	return nil
+}
+
+// Release gives back the coupon use of an order that did not go through, an
+// order without a coupon has nothing to release
+func (s *couponService) Release(ctx context.Context, orderID string) error {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "coupon_service",
+		"method":   "coupon_release",
+	}
+
+	var redemption model.CouponRedemption
+	if err := s.redemptionRepo.GetRedemptionByOrderID(ctx, orderID, &redemption); err != nil {
+		return nil
+	}
+
+	// a deleted coupon has no count to give back, the guarded decrement matches nothing then
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.redemptionRepo.DeleteRedemption(ctx, redemption.ID); err != nil {
+			return err
+		}
+		return s.couponRepo.UnuseCoupon(ctx, redemption.CouponID)
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("release redemption")
+		return err
+	}
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *couponService) GetAll(ctx context.Context) ([]model.Coupon, error) {
+	coupons, err := s.couponRepo.GetAllCoupon(ctx)
+	if err != nil {
+		log.WithError(err).Error("[Service]: get all coupon")
+		return nil, ErrGetCoupon
+	}
+	if coupons == nil {
+		coupons = make([]model.Coupon, 0)
+	}
+	return coupons, nil
+}
+
+func (s *couponService) GetByID(ctx context.Context, id string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	if err := s.couponRepo.GetCouponByID(ctx, id, &coupon); err != nil {
+		return nil, ErrCouponNotFound
+	}
+	return &coupon, nil
+}
+
+// ------------------------ Private Method ------------------------
+// redemptionSlot returns the slot the next use of userID takes, or
+// ErrCouponUserUsed when they have none left
+func (s *couponService) redemptionSlot(ctx context.Context, coupon *model.Coupon, userID string) (int, error) {
+	redemptions, err := s.redemptionRepo.GetRedemptionsByCouponID(ctx, coupon.ID)
+	if err != nil {
+		return 0, ErrRedeemCoupon
+	}
+
+	slot, ok := coupon.RedemptionSlot(redemptions, userID)
+	if !ok {
+		return 0, model.ErrCouponUserUsed
+	}
+	return slot, nil
+}
@@ -0,0 +1,216 @@
+package coupon
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"strconv"
+	"testing"
+)
+
+func TestRedeemLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxUses  int
+		perUser  int
+		orders   []string // user of each order, redeemed in turn
+		wantErrs []error
+		wantUsed int
+	}{
+		{name: "no limits", orders: []string{"u1", "u1", "u2"}, wantErrs: []error{nil, nil, nil}, wantUsed: 3},
+		{name: "max uses", maxUses: 2, orders: []string{"u1", "u2", "u3"}, wantErrs: []error{nil, nil, model.ErrCouponUsedUp}, wantUsed: 2},
+		{name: "max uses per user", perUser: 1, orders: []string{"u1", "u1", "u2"}, wantErrs: []error{nil, model.ErrCouponUserUsed, nil}, wantUsed: 2},
+		{name: "both limits", maxUses: 3, perUser: 2, orders: []string{"u1", "u1", "u1", "u2", "u3"}, wantErrs: []error{nil, nil, model.ErrCouponUserUsed, nil, model.ErrCouponUsedUp}, wantUsed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newCouponEnv(model.Coupon{ID: "c1", Code: "SAVE10", Type: model.CouponPercent, Value: 10, Currency: "THB", MaxUses: tt.maxUses, MaxUsesPerUser: tt.perUser, Active: true})
+
+			for i, userID := range tt.orders {
+				order := newOrder(i, userID)
+				err := env.svc.Redeem(ctx, "save10", order, "seller_1")
+				if !errors.Is(err, tt.wantErrs[i]) {
+					t.Fatalf("order %d: err = %v, want %v", i, err, tt.wantErrs[i])
+				}
+				if err == nil && (order.CouponCode != "SAVE10" || order.Discount.Amount != 100) {
+					t.Errorf("order %d: coupon %s took %d off, want SAVE10 taking 100", i, order.CouponCode, order.Discount.Amount)
+				}
+			}
+			if env.coupons.coupon.Used != tt.wantUsed {
+				t.Errorf("used = %d, want %d", env.coupons.coupon.Used, tt.wantUsed)
+			}
+			if len(env.redemptions.items) != tt.wantUsed {
+				t.Errorf("redemptions = %d, want %d", len(env.redemptions.items), tt.wantUsed)
+			}
+		})
+	}
+}
+
+// Two orders of a user that read the same free slot: the second write hits
+// the unique slot and is refused as over the limit, its use given back.
+func TestRedeemSlotTaken(t *testing.T) {
+	ctx := context.Background()
+	env := newCouponEnv(model.Coupon{ID: "c1", Code: "SAVE10", Type: model.CouponPercent, Value: 10, Currency: "THB", MaxUsesPerUser: 1, Active: true})
+	env.redemptions.onRead = func() {
+		// the other order lands between the slot read and the write
+		env.redemptions.onRead = nil
+		if err := env.svc.Redeem(ctx, "SAVE10", newOrder(1, "u1"), "seller_1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := env.svc.Redeem(ctx, "SAVE10", newOrder(0, "u1"), "seller_1"); !errors.Is(err, model.ErrCouponUserUsed) {
+		t.Fatalf("err = %v, want %v", err, model.ErrCouponUserUsed)
+	}
+	if env.coupons.coupon.Used != 1 || len(env.redemptions.items) != 1 {
+		t.Errorf("used = %d with %d redemptions, want 1 and 1", env.coupons.coupon.Used, len(env.redemptions.items))
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	env := newCouponEnv(model.Coupon{ID: "c1", Code: "SAVE10", Type: model.CouponPercent, Value: 10, Currency: "THB", MaxUses: 1, MaxUsesPerUser: 1, Active: true})
+
+	if err := env.svc.Redeem(ctx, "SAVE10", newOrder(0, "u1"), "seller_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.Release(ctx, "order_0"); err != nil {
+		t.Fatal(err)
+	}
+	if env.coupons.coupon.Used != 0 || len(env.redemptions.items) != 0 {
+		t.Fatalf("used = %d with %d redemptions after release, want 0 and 0", env.coupons.coupon.Used, len(env.redemptions.items))
+	}
+
+	// the released use is free for the same user again
+	if err := env.svc.Redeem(ctx, "SAVE10", newOrder(1, "u1"), "seller_1"); err != nil {
+		t.Fatalf("redeem after release: %v", err)
+	}
+	// an order without a coupon has nothing to release
+	if err := env.svc.Release(ctx, "order_none"); err != nil {
+		t.Fatal(err)
+	}
+	if env.coupons.coupon.Used != 1 {
+		t.Errorf("used = %d, want 1", env.coupons.coupon.Used)
+	}
+}
+
+func newOrder(i int, userID string) *model.Order {
+	return &model.Order{
+		ID:        "order_" + strconv.Itoa(i),
+		UserID:    userID,
+		ProductID: "product_1",
+		Subtotal:  model.NewMoney(1000, "THB"),
+		Amount:    model.NewMoney(1000, "THB"),
+	}
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes keep one coupon in memory and hold the guards the database holds:
+// the use count never passes max_uses and a redemption slot is unique. The
+// transaction puts both back when fn fails. A method the tests don't expect
+// the service to call panics through the embedded interface.
+
+type couponEnv struct {
+	svc         *couponService
+	coupons     *fakeCouponRepo
+	redemptions *fakeRedemptionRepo
+}
+
+func newCouponEnv(coupon model.Coupon) *couponEnv {
+	env := &couponEnv{
+		coupons:     &fakeCouponRepo{coupon: coupon},
+		redemptions: &fakeRedemptionRepo{},
+	}
+	env.svc = NewCouponService(&fakeTx{env: env}, env.coupons, env.redemptions).(*couponService)
+	return env
+}
+
+type fakeTx struct {
+	env *couponEnv
+}
+
+func (tx *fakeTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	used := tx.env.coupons.coupon.Used
+	redemptions := append([]model.CouponRedemption(nil), tx.env.redemptions.items...)
+	if err := fn(ctx); err != nil {
+		tx.env.coupons.coupon.Used = used
+		tx.env.redemptions.items = redemptions
+		return err
+	}
+	return nil
+}
+
+type fakeCouponRepo struct {
+	repository.CouponRepository
+	coupon model.Coupon
+}
+
+func (r *fakeCouponRepo) UseCoupon(ctx context.Context, id string) (bool, error) {
+	if r.coupon.MaxUses > 0 && r.coupon.Used >= r.coupon.MaxUses {
+		return false, nil
+	}
+	r.coupon.Used++
+	return true, nil
+}
+
+func (r *fakeCouponRepo) UnuseCoupon(ctx context.Context, id string) error {
+	if r.coupon.Used > 0 {
+		r.coupon.Used--
+	}
+	return nil
+}
+
+func (r *fakeCouponRepo) GetCouponByCode(ctx context.Context, code string, c *model.Coupon) error {
+	if code != r.coupon.Code {
+		return errors.New("not found")
+	}
+	*c = r.coupon
+	return nil
+}
+
+type fakeRedemptionRepo struct {
+	repository.CouponRedemptionRepository
+	items  []model.CouponRedemption
+	onRead func()
+}
+
+func (r *fakeRedemptionRepo) AddRedemption(ctx context.Context, cr *model.CouponRedemption) error {
+	for _, existing := range r.items {
+		if cr.Slot != nil && existing.Slot != nil && existing.UserID == cr.UserID && *existing.Slot == *cr.Slot {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	r.items = append(r.items, *cr)
+	return nil
+}
+
+func (r *fakeRedemptionRepo) DeleteRedemption(ctx context.Context, id string) error {
+	for i, existing := range r.items {
+		if existing.ID == id {
+			r.items = append(r.items[:i], r.items[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *fakeRedemptionRepo) GetRedemptionByOrderID(ctx context.Context, orderID string, cr *model.CouponRedemption) error {
+	for _, existing := range r.items {
+		if existing.OrderID == orderID {
+			*cr = existing
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (r *fakeRedemptionRepo) GetRedemptionsByCouponID(ctx context.Context, couponID string) ([]model.CouponRedemption, error) {
+	redemptions := append([]model.CouponRedemption(nil), r.items...)
+	if r.onRead != nil {
+		r.onRead()
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,136 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/invoice"
+	"go-rebuild/internal/mail"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrRenderInvoice = errors.New("fail to render invoice")
+	ErrSendInvoice   = errors.New("fail to send invoice")
+
+	ErrOrderNotFound = errors.New("order not found")
+	ErrPermission    = errors.New("no permission to see this invoice")
+)
+
+type invoiceService struct {
+	orderRepo  repository.OrderRepository
+	productSvc module.ProductService
+	userSvc    module.UserService
+	mailSvc    mail.Mail
+}
+
+// ------------------------ Constructor ------------------------
+func NewInvoiceService(orderRepo repository.OrderRepository, productSvc module.ProductService, userSvc module.UserService, mailSvc mail.Mail) module.InvoiceService {
+	return &invoiceService{
+		orderRepo:  orderRepo,
+		productSvc: productSvc,
+		userSvc:    userSvc,
+		mailSvc:    mailSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// SendPaid emails the buyer of a paid order the invoice, as the body and as a PDF
+func (s *invoiceService) SendPaid(ctx context.Context, orderID string) error {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "invoice_service",
+		"method":   "invoice_sendPaid",
+	}
+
+	inv, _, _, err := s.build(ctx, orderID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("build invoice")
+		return err
+	}
+
+	html, err := invoice.Render(inv, invoice.FormatHTML)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("render html")
+		return ErrRenderInvoice
+	}
+	pdf, err := invoice.Render(inv, invoice.FormatPDF)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("render pdf")
+		return ErrRenderInvoice
+	}
+
+	subject := "Your invoice " + inv.Number
+	attachment := mail.Attachment{Filename: pdf.Filename, ContentType: pdf.ContentType, Data: pdf.Data}
+	if err := s.mailSvc.SendEmailWithAttachments(string(html.Data), subject, []string{inv.Buyer.Email}, []mail.Attachment{attachment}); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("send email")
+		return ErrSendInvoice
+	}
+
+	log.Info("[Service]: invoice sent success:", inv.Number)
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetByOrderID renders the invoice of an order for its buyer, its seller or an ADMIN
+func (s *invoiceService) GetByOrderID(ctx context.Context, orderID string, format string, userID string, role string) (*invoice.Document, error) {
+	inv, order, sellerID, err := s.build(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != "ADMIN" && userID != sellerID && userID != order.UserID {
+		return nil, ErrPermission
+	}
+
+	doc, err := invoice.Render(inv, format)
+	if errors.Is(err, invoice.ErrFormat) {
+		return nil, err
+	}
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"order_id": orderID,
+			"layer":    "invoice_service",
+			"method":   "invoice_getByOrderID",
+		}).Error("render invoice")
+		return nil, ErrRenderInvoice
+	}
+	return doc, nil
+}
+
+// ------------------------ Private Method ------------------------
+// build loads the order with its product, seller and buyer into an invoice,
+// it returns the order and the seller id for the permission check too
+func (s *invoiceService) build(ctx context.Context, orderID string) (*invoice.Invoice, *model.Order, string, error) {
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		return nil, nil, "", ErrOrderNotFound
+	}
+
+	product, err := s.productSvc.GetByID(ctx, order.ProductID)
+	if err != nil {
+		return nil, nil, "", ErrOrderNotFound
+	}
+
+	seller, err := s.userSvc.GetByID(ctx, product.CreatedBy)
+	if err != nil {
+		return nil, nil, "", ErrRenderInvoice
+	}
+	buyer, err := s.userSvc.GetByID(ctx, order.UserID)
+	if err != nil {
+		return nil, nil, "", ErrRenderInvoice
+	}
+
+	title := product.Title
+	for _, variant := range product.Variants {
+		if variant.ID == order.VariantID {
+			title += " (" + variant.SKU + ")"
+		}
+	}
+
+	return invoice.New(&order, title, seller, buyer), &order, seller.ID, nil
+}
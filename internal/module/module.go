@@ -2,6 +2,7 @@ package module
 
 import (
 	"context"
+	"go-rebuild/internal/invoice"
 	"go-rebuild/internal/model"
 )
 
@@ -15,23 +16,81 @@ type MessageService interface {
 }
 
 type StockService interface {
-	Save(ctx context.Context, productID string, quantity int) error
-	Update(ctx context.Context, productID string, quantity int) error
-	IncreaseQuantity(ctx context.Context, q int, productID string) error
-	DecreaseQuantity(ctx context.Context, q int, productID string) error
+	Save(ctx context.Context, productID string, quantity int, actorID string) error
+	Update(ctx context.Context, productID string, quantity int, actorID string) error
+	IncreaseQuantity(ctx context.Context, q int, productID string, ref model.StockRef) error
+	DecreaseQuantity(ctx context.Context, q int, productID string, ref model.StockRef) error
+	Adjust(ctx context.Context, productID string, req *model.StockAdjustReq, userID string) (*model.Stock, error)
+	Transfer(ctx context.Context, productID string, req *model.StockTransferReq, userID string) (*model.StockResp, error)
+	SetReorderThreshold(ctx context.Context, productID string, threshold int, userID string) (*model.Stock, error)
+	Subscribe(ctx context.Context, productID string, userID string) error
+	Unsubscribe(ctx context.Context, productID string, userID string) error
+	MarkNotified(ctx context.Context, subscriptionID string) error
 	Delete(ctx context.Context, id string) error
 
-	GetAll(ctx context.Context) ([]model.Stock, error)
-	GetByProductID(ctx context.Context, productID string) (*model.Stock, error)
+	Reserve(ctx context.Context, orderID string, productID string, quantity int, region string) (*model.StockReservation, error)
+	ReleaseReservation(ctx context.Context, orderID string, status string) error
+	CommitReservation(ctx context.Context, orderID string, actorID string) error
+	GetReservationByOrderID(ctx context.Context, orderID string) (*model.StockReservation, error)
+	GetExpiredReservations(ctx context.Context) ([]model.StockReservation, error)
+
+	GetAll(ctx context.Context) ([]model.StockResp, error)
+	GetByProductID(ctx context.Context, productID string) (*model.StockResp, error)
+	GetBySeller(ctx context.Context, sellerID string) ([]model.StockResp, error)
+	GetMovements(ctx context.Context, productID string, userID string, role string) ([]model.StockMovement, error)
+	GetSubscriptions(ctx context.Context, productID string) ([]model.StockSubscription, error)
+
+	Reconcile(ctx context.Context, apply bool) ([]model.StockDrift, error)
+}
+
+type WarehouseService interface {
+	Save(ctx context.Context, req *model.WarehouseReq, sellerID string) (*model.Warehouse, error)
+	Update(ctx context.Context, req *model.WarehouseReq, id string, sellerID string) (*model.Warehouse, error)
+	Delete(ctx context.Context, id string, sellerID string) error
+
+	GetBySeller(ctx context.Context, sellerID string) ([]model.Warehouse, error)
 }
 
 type OrderService interface {
-	Save(ctx context.Context, oReq *model.OrderReq, userID string) error
+	Save(ctx context.Context, oReq *model.OrderReq, userID string) (*model.OrderResp, error)
 	Update(ctx context.Context, o *model.Order, id string) error
 	Delete(ctx context.Context, id string, userID string) error
 
+	CancelExpired(ctx context.Context) (int, error)
+
 	GetAll(ctx context.Context) ([]model.OrderResp, error)
-	GetByID(ctx context.Context, id string) (*model.OrderResp, error)
+	GetByID(ctx context.Context, id string, userID string, role string) (*model.OrderResp, error)
+	GetByUser(ctx context.Context, userID string, status string, page model.Page) (*model.PageResp[model.OrderResp], error)
+}
+
+type PaymentService interface {
+	Pay(ctx context.Context, orderID string, userID string) (*model.PaymentResp, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+	Refund(ctx context.Context, id string, amount *model.Money) (*model.PaymentResp, error)
+	RefundOrder(ctx context.Context, orderID string, amount model.Money) (*model.PaymentResp, error)
+
+	GetByOrderID(ctx context.Context, orderID string, userID string, role string) ([]model.PaymentResp, error)
+}
+
+type ReturnService interface {
+	Request(ctx context.Context, orderID string, rReq *model.ReturnReq, userID string) (*model.Return, error)
+	Decide(ctx context.Context, id string, dReq *model.ReturnDecisionReq, userID string, role string) (*model.Return, error)
+
+	GetByOrderID(ctx context.Context, orderID string, userID string, role string) ([]model.Return, error)
+	GetBySeller(ctx context.Context, sellerID string) ([]model.Return, error)
+}
+
+type InvoiceService interface {
+	SendPaid(ctx context.Context, orderID string) error
+
+	GetByOrderID(ctx context.Context, orderID string, format string, userID string, role string) (*invoice.Document, error)
+}
+
+type ShipmentService interface {
+	Ship(ctx context.Context, orderID string, req *model.ShipmentReq, userID string, role string) (*model.Shipment, error)
+	AddEvent(ctx context.Context, orderID string, req *model.ShipmentEventReq, userID string, role string) (*model.Shipment, error)
+
+	GetByOrderID(ctx context.Context, orderID string, userID string, role string) (*model.Shipment, error)
 }
 
 type ProductService interface {
@@ -41,6 +100,88 @@ type ProductService interface {
 
 	GetAll(ctx context.Context) ([]model.ProductResp, error)
 	GetByID(ctx context.Context, id string) (*model.ProductResp, error)
+	GetBySeller(ctx context.Context, sellerID string) ([]model.ProductResp, error)
+
+	UploadImage(ctx context.Context, productID string, data []byte, userID string) (*model.ProductImageResp, error)
+	DeleteImage(ctx context.Context, productID string, imageID string, userID string) error
+
+	SchedulePrice(ctx context.Context, productID string, req *model.PriceScheduleReq, userID string) (*model.PriceChange, error)
+	CancelScheduledPrice(ctx context.Context, productID string, changeID string, userID string) error
+	ApplyScheduledPrices(ctx context.Context) (int, error)
+	GetPriceHistory(ctx context.Context, productID string) ([]model.PriceChange, error)
+}
+
+type CategoryService interface {
+	Save(ctx context.Context, req *model.CategoryReq) (*model.Category, error)
+	Update(ctx context.Context, req *model.CategoryReq, id string) (*model.Category, error)
+	Delete(ctx context.Context, id string) error
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string, userID string, role string) error
+
+	GetTree(ctx context.Context) ([]model.CategoryNode, error)
+	GetProductsBySlug(ctx context.Context, slug string) ([]model.ProductResp, error)
+}
+
+type ReviewService interface {
+	Save(ctx context.Context, productID string, req *model.ReviewReq, userID string) (*model.ReviewResp, error)
+	Moderate(ctx context.Context, id string, req *model.ReviewModerateReq) (*model.ReviewResp, error)
+	Delete(ctx context.Context, id string) error
+
+	GetByProductID(ctx context.Context, productID string, page model.Page, withHidden bool) (*model.PageResp[model.ReviewResp], error)
+}
+
+type CouponService interface {
+	Save(ctx context.Context, req *model.CouponReq) (*model.Coupon, error)
+	Update(ctx context.Context, req *model.CouponReq, id string) (*model.Coupon, error)
+	Delete(ctx context.Context, id string) error
+
+	Redeem(ctx context.Context, code string, order *model.Order, sellerID string) error
+	Release(ctx context.Context, orderID string) error
+
+	GetAll(ctx context.Context) ([]model.Coupon, error)
+	GetByID(ctx context.Context, id string) (*model.Coupon, error)
+}
+
+type TaxRuleService interface {
+	Save(ctx context.Context, req *model.TaxRuleReq) (*model.TaxRule, error)
+	Update(ctx context.Context, req *model.TaxRuleReq, id string) (*model.TaxRule, error)
+	Delete(ctx context.Context, id string) error
+
+	GetAll(ctx context.Context) ([]model.TaxRule, error)
+}
+
+type AddressService interface {
+	Save(ctx context.Context, req *model.AddressReq, userID string) (*model.Address, error)
+	Update(ctx context.Context, req *model.AddressReq, id string, userID string) (*model.Address, error)
+	Delete(ctx context.Context, id string, userID string) error
+
+	GetByUserID(ctx context.Context, userID string) ([]model.Address, error)
+	GetForOrder(ctx context.Context, userID string, id string) (*model.Address, error)
+}
+
+type CartService interface {
+	AddLine(ctx context.Context, owner model.CartOwner, req *model.CartLineReq) (*model.CartResp, error)
+	UpdateLine(ctx context.Context, owner model.CartOwner, id string, req *model.CartQuantityReq) (*model.CartResp, error)
+	RemoveLine(ctx context.Context, owner model.CartOwner, id string) (*model.CartResp, error)
+	Clear(ctx context.Context, owner model.CartOwner) error
+	Merge(ctx context.Context, guestID string, userID string) error
+	Checkout(ctx context.Context, userID string, req *model.CheckoutReq) (*model.CheckoutResp, error)
+
+	Get(ctx context.Context, owner model.CartOwner) (*model.CartResp, error)
+}
+
+type WishlistService interface {
+	Add(ctx context.Context, req *model.WishlistReq, userID string) (*model.WishlistResp, error)
+	Remove(ctx context.Context, productID string, userID string) error
+
+	GetByUser(ctx context.Context, userID string) ([]model.WishlistResp, error)
+	GetByProductID(ctx context.Context, productID string) ([]model.Wishlist, error)
+}
+
+type ReportService interface {
+	GetSellerOrders(ctx context.Context, sellerID string, status string, page model.Page) (*model.PageResp[model.SellerOrderResp], error)
+	GetSummary(ctx context.Context, sellerID string, req *model.ReportReq) (*model.SalesSummary, error)
+	GetRevenue(ctx context.Context, sellerID string, req *model.ReportReq) ([]model.RevenuePoint, error)
+	GetTopProducts(ctx context.Context, sellerID string, req *model.ReportReq) ([]model.TopProduct, error)
 }
 
 type UserService interface {
@@ -51,4 +192,8 @@ type UserService interface {
 	GetAll(ctx context.Context) ([]model.User, error)
 	GetByID(ctx context.Context, id string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+
+	NewVerificationToken(user *model.User) (string, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string)
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"go-rebuild/internal/repository"
+	"go-rebuild/internal/tax"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -18,35 +20,68 @@ var (
 	ErrCreateOrder = errors.New("fail to create order")
 	ErrUpdateOrder = errors.New("fail to update order")
 	ErrDeleteOrder = errors.New("fail to delete order")
+	ErrGetOrders   = errors.New("fail to get orders")
 
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound = model.ErrOrderNotFound
 	ErrChangeProduct = errors.New("can not change product")
-	ErrPermission    = errors.New("no permission can't delete another order")
+	ErrPermission    = fmt.Errorf("%w, can't cancel another's order", model.ErrOrderAccess)
+	ErrNoAccess      = fmt.Errorf("%w, can't see another's order", model.ErrOrderAccess)
+	ErrNotCancelable = invalid(errors.New("only an unpaid order can be cancelled, return a paid one instead"))
+	ErrOutOfStock    = invalid(errors.New("not enough stock for this order"))
+	ErrCalculateTax  = errors.New("fail to calculate order tax")
 )
 
 type orderService struct {
 	orderRepo   repository.OrderRepository
 	productSvc  module.ProductService
+	stockSvc    module.StockService
+	couponSvc   module.CouponService
+	addressSvc  module.AddressService
+	taxCalc     tax.Calculator
 	producerSvc messagebroker.ProducerService
 }
 
 // ------------------------ Constructor ------------------------
-func NewOrderService(ordeRepo repository.OrderRepository, productSvc module.ProductService, producerSvc messagebroker.ProducerService) module.OrderService {
+func NewOrderService(ordeRepo repository.OrderRepository, productSvc module.ProductService, stockSvc module.StockService, couponSvc module.CouponService, addressSvc module.AddressService, taxCalc tax.Calculator, producerSvc messagebroker.ProducerService) module.OrderService {
 	return &orderService{
 		orderRepo:   ordeRepo,
 		productSvc:  productSvc,
+		stockSvc:    stockSvc,
+		couponSvc:   couponSvc,
+		addressSvc:  addressSvc,
+		taxCalc:     taxCalc,
 		producerSvc: producerSvc,
 	}
 }
 
 // ------------------------ Method Basic CUD ------------------------
-func (s *orderService) Save(ctx context.Context, oReq *model.OrderReq, userID string) error {
+func (s *orderService) Save(ctx context.Context, oReq *model.OrderReq, userID string) (*model.OrderResp, error) {
 	productResp, err := s.productSvc.GetByID(ctx, oReq.ProductID)
 	if err != nil {
-		return ErrCreateOrder
+		return nil, ErrCreateOrder
 	}
 
-	order := oReq.ToOrder(userID, productResp)
+	price, err := productResp.PriceOf(oReq.VariantID)
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	address, err := s.addressSvc.GetForOrder(ctx, userID, oReq.AddressID)
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	order := oReq.ToOrder(userID, price)
+	if err := order.VerifyCurrency(); err != nil {
+		return nil, invalid(err)
+	}
+	order.ShippingAddress = address.ToShippingAddress()
+
+	// tax and allocation go by where the order ships unless a region is given
+	region := oReq.Region
+	if region == "" {
+		region = address.Region
+	}
 
 	var baseLogFields = log.Fields{
 		"order_id": order.ID,
@@ -54,30 +89,50 @@ func (s *orderService) Save(ctx context.Context, oReq *model.OrderReq, userID st
 		"method":   "order_save",
 	}
 
-	if err := s.orderRepo.AddOrder(ctx, order); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("save order")
-		return ErrCreateOrder
+	if oReq.CouponCode != "" {
+		if err := s.couponSvc.Redeem(ctx, oReq.CouponCode, order, productResp.CreatedBy); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("redeem coupon")
+			if errors.Is(err, model.ErrCouponRedeem) {
+				return nil, err
+			}
+			return nil, invalid(err)
+		}
 	}
-	log.Info("[Service]: Order created success:", order)
 
-	bodyByte, err := json.Marshal(model.Stock{ProductID: order.ProductID, Quantity: order.Quantity})
+	// tax goes on what is left after the discount
+	taxLines, err := s.taxCalc.Calculate(ctx, region, []tax.Line{{ProductID: order.ProductID, Amount: order.Taxable()}})
 	if err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
-		return ErrCreateOrder
+		log.WithError(err).WithFields(baseLogFields).Error("calculate tax")
+		s.releaseCoupon(ctx, order.ID)
+		return nil, ErrCalculateTax
+	}
+	if err := order.ApplyTax(taxLines); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("apply tax")
+		s.releaseCoupon(ctx, order.ID)
+		return nil, err
 	}
 
-	mqConf := &model.MQConfig{
-		ExchangeName: messagebroker.StockExchangeName,
-		ExchangeType: messagebroker.StockExchangeType,
-		QueueName:    messagebroker.StockQueueName,
-		RoutingKey:   "stock.decrease",
+	// hold the stock first so an order is never saved for items we don't have
+	if _, err := s.stockSvc.Reserve(ctx, order.ID, order.StockID(), order.Quantity, region); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("reserve stock")
+		s.releaseCoupon(ctx, order.ID)
+		if errors.Is(err, model.ErrDebtStock) {
+			return nil, ErrOutOfStock
+		}
+		return nil, ErrCreateOrder
 	}
-	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("publishing")
-		return ErrCreateOrder
+
+	if err := s.orderRepo.AddOrder(ctx, order); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("save order")
+		if err := s.stockSvc.ReleaseReservation(ctx, order.ID, model.ReservationReleased); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("release reservation")
+		}
+		s.releaseCoupon(ctx, order.ID)
+		return nil, ErrCreateOrder
 	}
+	log.Info("[Service]: Order created success:", order)
 
-	return nil
+	return order.ToOrderResp(), nil
 }
 
 func (s *orderService) Update(ctx context.Context, orderReq *model.Order, id string) error {
@@ -113,6 +168,8 @@ func (s *orderService) Update(ctx context.Context, orderReq *model.Order, id str
 	return nil
 }
 
+// Delete cancels an unpaid order of the user. The order is kept as CANCELLED,
+// a paid order goes back through a return.
 func (s *orderService) Delete(ctx context.Context, id string, userID string) error {
 	var baseLogFields = log.Fields{
 		"order_id": id,
@@ -127,17 +184,48 @@ func (s *orderService) Delete(ctx context.Context, id string, userID string) err
 	}
 
 	if order.UserID != userID {
-		log.WithError(ErrPermission).WithFields(baseLogFields)
-		return ErrDeleteOrder
+		log.WithError(ErrPermission).WithFields(baseLogFields).Warn("cancel another's order")
+		return ErrPermission
+	}
+
+	if order.Status != model.OrderStatusPending {
+		return ErrNotCancelable
 	}
 
-	if err := s.orderRepo.DeleteOrder(ctx, id); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("delete order")
+	// the read may be cached, only a cancel that finds the order still pending
+	// goes through, a payment that got there first wins
+	cancelled, err := s.orderRepo.SetOrderStatus(ctx, id, model.OrderStatusPending, model.OrderStatusCancelled)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("cancel order")
 		return ErrDeleteOrder
 	}
-	log.Info("[Service]: order deleted success:", order)
+	if !cancelled {
+		return ErrNotCancelable
+	}
+	log.Info("[Service]: order cancelled success:", order.ID)
+
+	// an unpaid order only holds a reservation, one placed before reservations
+	// existed took the items off hand and has to restock them
+	reservation, err := s.stockSvc.GetReservationByOrderID(ctx, id)
+	if err == nil && reservation.IsActive() {
+		if err := s.stockSvc.ReleaseReservation(ctx, id, model.ReservationReleased); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("release reservation")
+			return err
+		}
+		s.releaseCoupon(ctx, id)
+		return nil
+	}
+	if err == nil && reservation.Status != model.ReservationCommitted {
+		return nil
+	}
 
-	bodyByte, err := json.Marshal(model.Stock{ProductID: order.ProductID, Quantity: order.Quantity})
+	// the items go back to the warehouse they were shipped from
+	stockMessage := model.StockMessage{ProductID: order.StockID(), Quantity: order.Quantity, ReferenceID: order.ID, ActorID: userID}
+	if reservation != nil {
+		stockMessage.WarehouseID = reservation.WarehouseID
+	}
+
+	bodyByte, err := json.Marshal(stockMessage)
 	if err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
 		return err
@@ -158,6 +246,66 @@ func (s *orderService) Delete(ctx context.Context, id string, userID string) err
 	return nil
 }
 
+// CancelExpired releases every reservation past its expiry and cancels the
+// unpaid order that held it. It returns how many orders were cancelled.
+func (s *orderService) CancelExpired(ctx context.Context) (int, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "order_service",
+		"method": "order_cancelExpired",
+	}
+
+	expired, err := s.stockSvc.GetExpiredReservations(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get expired reservations")
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, reservation := range expired {
+		var order model.Order
+		if err := s.orderRepo.GetOrderByID(ctx, reservation.OrderID, &order); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Warnf("order {%s} of expired reservation not found", reservation.OrderID)
+			continue
+		}
+
+		// an order paid without its reservation being committed keeps the items
+		if order.IsPaid() {
+			if err := s.stockSvc.CommitReservation(ctx, order.ID, order.UserID); err != nil {
+				log.WithError(err).WithFields(baseLogFields).Errorf("commit reservation of order {%s}", order.ID)
+			}
+			continue
+		}
+
+		// cancel before releasing, a payment that settles in between finds the
+		// order cancelled and refunds instead of committing released items
+		expiredOrder := false
+		if order.Status == model.OrderStatusPending {
+			changed, err := s.orderRepo.SetOrderStatus(ctx, order.ID, model.OrderStatusPending, model.OrderStatusCancelled)
+			if err != nil {
+				log.WithError(err).WithFields(baseLogFields).Errorf("cancel order {%s}", order.ID)
+				continue
+			}
+			if !changed {
+				// paid since the read, the next sweep commits the reservation
+				continue
+			}
+			expiredOrder = true
+		}
+
+		if err := s.stockSvc.ReleaseReservation(ctx, reservation.OrderID, model.ReservationExpired); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Errorf("release reservation of order {%s}", reservation.OrderID)
+			continue
+		}
+
+		if expiredOrder {
+			s.releaseCoupon(ctx, order.ID)
+			cancelled++
+		}
+	}
+
+	return cancelled, nil
+}
+
 // ------------------------ Method Basic Query ------------------------
 func (s *orderService) GetAll(ctx context.Context) ([]model.OrderResp, error) {
 	var baseLogFields = log.Fields{
@@ -181,7 +329,8 @@ func (s *orderService) GetAll(ctx context.Context) ([]model.OrderResp, error) {
 	return ordersResp, nil
 }
 
-func (s *orderService) GetByID(ctx context.Context, id string) (*model.OrderResp, error) {
+// GetByID returns the order to its buyer, the seller of its product or an ADMIN
+func (s *orderService) GetByID(ctx context.Context, id string, userID string, role string) (*model.OrderResp, error) {
 	var order model.Order
 	var baseLogFields = log.Fields{
 		"order_id": id,
@@ -195,8 +344,55 @@ func (s *orderService) GetByID(ctx context.Context, id string) (*model.OrderResp
 		return nil, ErrOrderNotFound
 	}
 
+	if role != "ADMIN" && order.UserID != userID {
+		product, err := s.productSvc.GetByID(ctx, order.ProductID)
+		if err != nil || product.CreatedBy != userID {
+			return nil, ErrNoAccess
+		}
+	}
+
 	orderResp := order.ToOrderResp()
 
 	log.Info("[Service]: get order by id success:", order)
 	return orderResp, nil
 }
+
+// GetByUser pages through the orders the user placed, newest first, an empty
+// status lists every status
+func (s *orderService) GetByUser(ctx context.Context, userID string, status string, page model.Page) (*model.PageResp[model.OrderResp], error) {
+	var baseLogFields = log.Fields{
+		"user_id": userID,
+		"layer":   "order_service",
+		"method":  "order_getByUser",
+	}
+
+	if status != "" && !model.IsOrderStatus(status) {
+		return nil, invalid(model.ErrOrderStatus)
+	}
+
+	page.Normalize()
+	orders, total, err := s.orderRepo.GetOrderPageByUser(ctx, userID, status, page)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get order page")
+		return nil, ErrGetOrders
+	}
+
+	ordersResp := make([]model.OrderResp, 0, len(orders))
+	for _, order := range orders {
+		ordersResp = append(ordersResp, *order.ToOrderResp())
+	}
+	return model.NewPageResp(ordersResp, page, total), nil
+}
+
+// ------------------------ Private Method ------------------------
+// releaseCoupon gives back the coupon use of an order that was never paid
+func (s *orderService) releaseCoupon(ctx context.Context, orderID string) {
+	if err := s.couponSvc.Release(ctx, orderID); err != nil {
+		log.WithError(err).WithField("order_id", orderID).Warn("[Service]: release coupon")
+	}
+}
+
+// invalid marks err as something the order request got wrong
+func invalid(err error) error {
+	return fmt.Errorf("%w: %w", model.ErrOrderRequest, err)
+}
@@ -0,0 +1,189 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"slices"
+	"testing"
+)
+
+func TestGetByIDAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		userID  string
+		role    string
+		wantErr error
+	}{
+		{name: "buyer", id: "o1", userID: "u1", role: "USER"},
+		{name: "seller of the product", id: "o1", userID: "s1", role: "USER"},
+		{name: "admin", id: "o1", userID: "a1", role: "ADMIN"},
+		{name: "someone else", id: "o1", userID: "u2", role: "USER", wantErr: model.ErrOrderAccess},
+		{name: "seller of another product", id: "o2", userID: "s1", role: "USER", wantErr: model.ErrOrderAccess},
+		{name: "missing order", id: "o3", userID: "a1", role: "ADMIN", wantErr: model.ErrOrderNotFound},
+	}
+
+	orders := &fakeOrderRepo{orders: map[string]model.Order{
+		"o1": {ID: "o1", UserID: "u1", ProductID: "p1", Status: model.OrderStatusPaid},
+		"o2": {ID: "o2", UserID: "u1", ProductID: "p2", Status: model.OrderStatusPaid},
+	}}
+	s := &orderService{orderRepo: orders, productSvc: &fakeProductService{}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := s.GetByID(context.Background(), tt.id, tt.userID, tt.role)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.ID != tt.id {
+				t.Errorf("order = %s, want %s", order.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestCancelExpired(t *testing.T) {
+	tests := []struct {
+		name          string
+		order         *model.Order
+		paidMeanwhile bool
+		wantStatus    string
+		wantCancelled int
+		wantReleased  bool
+		wantCommitted bool
+	}{
+		{name: "pending is cancelled", order: &model.Order{ID: "o1", UserID: "u1", Status: model.OrderStatusPending}, wantStatus: model.OrderStatusCancelled, wantCancelled: 1, wantReleased: true},
+		{name: "paid keeps the items", order: &model.Order{ID: "o1", UserID: "u1", Status: model.OrderStatusPaid}, wantStatus: model.OrderStatusPaid, wantCommitted: true},
+		{name: "paid while sweeping", order: &model.Order{ID: "o1", UserID: "u1", Status: model.OrderStatusPending}, paidMeanwhile: true, wantStatus: model.OrderStatusPaid},
+		{name: "already cancelled", order: &model.Order{ID: "o1", UserID: "u1", Status: model.OrderStatusCancelled}, wantStatus: model.OrderStatusCancelled, wantReleased: true},
+		{name: "order gone", order: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderRepo{orders: make(map[string]model.Order)}
+			if tt.order != nil {
+				orders.orders["o1"] = *tt.order
+			}
+			if tt.paidMeanwhile {
+				orders.beforeSet = func() {
+					orders.beforeSet = nil
+					paid := orders.orders["o1"]
+					paid.Status = model.OrderStatusPaid
+					orders.orders["o1"] = paid
+				}
+			}
+			stock := &fakeStockService{expired: []model.StockReservation{{ID: "r1", OrderID: "o1", Status: model.ReservationActive}}}
+			coupons := &fakeCouponService{}
+			s := &orderService{orderRepo: orders, stockSvc: stock, couponSvc: coupons}
+
+			cancelled, err := s.CancelExpired(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %d, want %d", cancelled, tt.wantCancelled)
+			}
+			if got := orders.orders["o1"].Status; got != tt.wantStatus {
+				t.Errorf("order status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := slices.Contains(stock.released, "o1"); got != tt.wantReleased {
+				t.Errorf("released = %v, want %v", got, tt.wantReleased)
+			}
+			if got := slices.Contains(stock.committed, "o1"); got != tt.wantCommitted {
+				t.Errorf("committed = %v, want %v", got, tt.wantCommitted)
+			}
+			if got := slices.Contains(coupons.released, "o1"); got != (tt.wantCancelled > 0) {
+				t.Errorf("coupon released = %v, want it only for a cancelled order", got)
+			}
+		})
+	}
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes embed the interface they stand in for, a method the tests don't
+// expect the service to call panics.
+
+// fakeOrderRepo keeps orders in memory, SetOrderStatus compares and sets like
+// the guarded update. beforeSet runs once the service read the order.
+type fakeOrderRepo struct {
+	repository.OrderRepository
+	orders    map[string]model.Order
+	beforeSet func()
+}
+
+func (r *fakeOrderRepo) GetOrderByID(ctx context.Context, id string, order *model.Order) error {
+	found, ok := r.orders[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	*order = found
+	return nil
+}
+
+func (r *fakeOrderRepo) SetOrderStatus(ctx context.Context, id string, from string, to string) (bool, error) {
+	if r.beforeSet != nil {
+		r.beforeSet()
+	}
+	order, ok := r.orders[id]
+	if !ok || order.Status != from {
+		return false, nil
+	}
+	order.Status = to
+	r.orders[id] = order
+	return true, nil
+}
+
+// fakeProductService knows product p1 sold by s1 and p2 sold by s2
+type fakeProductService struct {
+	module.ProductService
+}
+
+func (s *fakeProductService) GetByID(ctx context.Context, id string) (*model.ProductResp, error) {
+	sellers := map[string]string{"p1": "s1", "p2": "s2"}
+	seller, ok := sellers[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &model.ProductResp{ID: id, CreatedBy: seller}, nil
+}
+
+type fakeStockService struct {
+	module.StockService
+	expired   []model.StockReservation
+	released  []string
+	committed []string
+}
+
+func (s *fakeStockService) GetExpiredReservations(ctx context.Context) ([]model.StockReservation, error) {
+	return s.expired, nil
+}
+
+func (s *fakeStockService) ReleaseReservation(ctx context.Context, orderID string, status string) error {
+	s.released = append(s.released, orderID)
+	return nil
+}
+
+func (s *fakeStockService) CommitReservation(ctx context.Context, orderID string, actorID string) error {
+	s.committed = append(s.committed, orderID)
+	return nil
+}
+
+type fakeCouponService struct {
+	module.CouponService
+	released []string
+}
+
+func (s *fakeCouponService) Release(ctx context.Context, orderID string) error {
+	s.released = append(s.released, orderID)
+	return nil
+}
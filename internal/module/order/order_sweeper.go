@@ -0,0 +1,39 @@
+package order
+
+import (
+	"context"
+	"go-rebuild/internal/module"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StartReservationSweeper cancels unpaid orders whose stock reservation expired,
+// once per interval until ctx is done
+func StartReservationSweeper(ctx context.Context, orderSvc module.OrderService, interval time.Duration) {
+	if interval <= 0 {
+		log.Warn("[Sweeper]: reservation sweeper disabled, interval is not positive")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Infof("[Sweeper]: reservation sweeper start every %s", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("[Sweeper]: reservation sweeper stopped")
+			return
+		case <-ticker.C:
+			cancelled, err := orderSvc.CancelExpired(ctx)
+			if err != nil {
+				log.WithError(err).Error("[Sweeper]: cancel expired orders failed")
+				continue
+			}
+			if cancelled > 0 {
+				log.Infof("[Sweeper]: cancelled %d expired order(s)", cancelled)
+			}
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	messagebroker "go-rebuild/internal/message_broker"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/payment"
+	"go-rebuild/internal/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreatePayment = errors.New("fail to create payment")
+	ErrUpdatePayment = errors.New("fail to update payment")
+	ErrRefundPayment = errors.New("fail to refund payment")
+
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrPermission      = errors.New("no permission to pay for another order")
+	ErrOrderNotPayable = errors.New("order is not waiting for payment")
+)
+
+type paymentService struct {
+	paymentRepo repository.PaymentRepository
+	eventRepo   repository.PaymentEventRepository
+	orderRepo   repository.OrderRepository
+	stockSvc    module.StockService
+	provider    payment.Provider
+	producerSvc messagebroker.ProducerService
+}
+
+// ------------------------ Constructor ------------------------
+func NewPaymentService(paymentRepo repository.PaymentRepository, eventRepo repository.PaymentEventRepository, orderRepo repository.OrderRepository, stockSvc module.StockService, provider payment.Provider, producerSvc messagebroker.ProducerService) module.PaymentService {
+	return &paymentService{
+		paymentRepo: paymentRepo,
+		eventRepo:   eventRepo,
+		orderRepo:   orderRepo,
+		stockSvc:    stockSvc,
+		provider:    provider,
+		producerSvc: producerSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Pay starts a new payment attempt for an unpaid order of the user. A
+// provider that authorizes straight away is captured here, otherwise the
+// buyer confirms with the client secret and the webhook settles it.
+func (s *paymentService) Pay(ctx context.Context, orderID string, userID string) (*model.PaymentResp, error) {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "payment_service",
+		"method":   "payment_pay",
+	}
+
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get order by id")
+		return nil, ErrOrderNotFound
+	}
+
+	if order.UserID != userID {
+		return nil, ErrPermission
+	}
+	if order.Status != model.OrderStatusPending {
+		return nil, ErrOrderNotPayable
+	}
+	if reservation, err := s.stockSvc.GetReservationByOrderID(ctx, orderID); err == nil && !reservation.IsActive() {
+		return nil, ErrOrderNotPayable
+	}
+
+	attempts, err := s.paymentRepo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get payments by order id")
+		return nil, ErrCreatePayment
+	}
+	for _, attempt := range attempts {
+		if attempt.Status == model.PaymentStatusSucceeded || attempt.Status == model.PaymentStatusRefunded {
+			return nil, ErrOrderNotPayable
+		}
+	}
+
+	p := model.NewPayment(&order, s.provider.Name(), len(attempts)+1)
+	if err := s.paymentRepo.AddPayment(ctx, p); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add payment")
+		return nil, ErrCreatePayment
+	}
+	baseLogFields["payment_id"] = p.ID
+
+	intent, err := s.provider.CreateIntent(ctx, p.ID, p.Amount)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("create intent")
+		p.MarkFailed(err.Error())
+		if err := s.paymentRepo.UpdatePayment(ctx, p); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("update payment")
+		}
+		return nil, ErrCreatePayment
+	}
+
+	p.ProviderRef = intent.ID
+	p.UpdatedAt = time.Now()
+	if err := s.paymentRepo.UpdatePayment(ctx, p); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update payment")
+		return nil, ErrCreatePayment
+	}
+
+	if intent.Status == payment.IntentRequiresCapture {
+		if intent, err = s.provider.Capture(ctx, intent.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("capture intent")
+			if err := s.fail(ctx, p, err.Error()); err != nil {
+				return nil, err
+			}
+			return p.ToPaymentResp(), nil
+		}
+	}
+
+	switch intent.Status {
+	case payment.IntentSucceeded:
+		err = s.settle(ctx, p)
+	case payment.IntentFailed:
+		err = s.fail(ctx, p, "declined by provider")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("[Service]: payment created success:", p.ID)
+	resp := p.ToPaymentResp()
+	if p.IsPending() {
+		resp.ClientSecret = intent.ClientSecret
+	}
+	return resp, nil
+}
+
+// HandleWebhook applies a signed provider event. An event already handled is
+// ignored, and every change checks the current status first, so a redelivery
+// or a webhook for what Pay already settled is a no-op.
+func (s *paymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	var baseLogFields = log.Fields{
+		"layer":  "payment_service",
+		"method": "payment_handleWebhook",
+	}
+
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("parse webhook")
+		return err
+	}
+	baseLogFields["event_id"] = event.ID
+
+	var handled model.PaymentEvent
+	if err := s.eventRepo.GetEventByEventID(ctx, event.ID, &handled); err == nil {
+		return nil
+	}
+
+	var p model.Payment
+	if err := s.paymentRepo.GetPaymentByProviderRef(ctx, event.IntentID, &p); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get payment by provider ref")
+		return ErrPaymentNotFound
+	}
+
+	switch event.Type {
+	case payment.EventSucceeded:
+		err = s.settle(ctx, &p)
+	case payment.EventFailed:
+		err = s.fail(ctx, &p, event.Reason)
+	case payment.EventRefunded:
+		// refunded at the provider, what we refunded ourselves is already recorded
+		if left := p.Refundable(); !left.IsZero() {
+			err = s.recordRefund(ctx, &p, left)
+		}
+	default:
+		log.WithFields(baseLogFields).Warnf("unhandled event type %s", event.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.eventRepo.AddEvent(ctx, model.NewPaymentEvent(p.Provider, event.ID, event.Type, p.ID)); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("add payment event")
+	}
+	return nil
+}
+
+// Refund gives back amount of a succeeded payment, all that is left when amount is nil
+func (s *paymentService) Refund(ctx context.Context, id string, amount *model.Money) (*model.PaymentResp, error) {
+	var p model.Payment
+	if err := s.paymentRepo.GetPaymentByID(ctx, id, &p); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	refund := p.Refundable()
+	if amount != nil {
+		refund = *amount
+	}
+	if err := s.refund(ctx, &p, refund); err != nil {
+		return nil, err
+	}
+
+	log.Info("[Service]: payment refunded success:", p.ID)
+	return p.ToPaymentResp(), nil
+}
+
+// RefundOrder gives back amount of the payment that paid the order
+func (s *paymentService) RefundOrder(ctx context.Context, orderID string, amount model.Money) (*model.PaymentResp, error) {
+	attempts, err := s.paymentRepo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"order_id": orderID,
+			"layer":    "payment_service",
+			"method":   "payment_refundOrder",
+		}).Error("get payments by order id")
+		return nil, ErrPaymentNotFound
+	}
+
+	for _, p := range attempts {
+		if p.Status != model.PaymentStatusSucceeded {
+			continue
+		}
+		if err := s.refund(ctx, &p, amount); err != nil {
+			return nil, err
+		}
+		log.Info("[Service]: order refunded success:", orderID)
+		return p.ToPaymentResp(), nil
+	}
+	return nil, ErrPaymentNotFound
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *paymentService) GetByOrderID(ctx context.Context, orderID string, userID string, role string) ([]model.PaymentResp, error) {
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if role != "ADMIN" && order.UserID != userID {
+		return nil, ErrPermission
+	}
+
+	payments, err := s.paymentRepo.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"order_id": orderID,
+			"layer":    "payment_service",
+			"method":   "payment_getByOrderID",
+		}).Error("get payments by order id")
+		return nil, ErrPaymentNotFound
+	}
+
+	paymentsResp := make([]model.PaymentResp, 0, len(payments))
+	for _, p := range payments {
+		paymentsResp = append(paymentsResp, *p.ToPaymentResp())
+	}
+	return paymentsResp, nil
+}
+
+// ------------------------ Private Method ------------------------
+// settle turns a pending payment into a paid order, committing its stock
+// reservation. Money that arrives after the order was cancelled, or after its
+// reservation lapsed, is refunded since the items may be gone.
+func (s *paymentService) settle(ctx context.Context, p *model.Payment) error {
+	if !p.IsPending() {
+		return nil
+	}
+
+	var baseLogFields = log.Fields{
+		"payment_id": p.ID,
+		"order_id":   p.OrderID,
+		"layer":      "payment_service",
+		"method":     "payment_settle",
+	}
+
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, p.OrderID, &order); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get order by id")
+		return ErrOrderNotFound
+	}
+
+	late := order.Status != model.OrderStatusPending
+	if order.IsPaid() {
+		// by this payment on an earlier delivery, or by another attempt
+		late = s.paidByAnother(ctx, p)
+	}
+	commit := false
+	if !late {
+		// an order placed before reservations has no reservation, its stock is already taken
+		reservation, err := s.stockSvc.GetReservationByOrderID(ctx, order.ID)
+		switch {
+		case err != nil, reservation.Status == model.ReservationCommitted:
+		case reservation.IsActive():
+			commit = true
+		default:
+			late = true
+		}
+	}
+
+	// claim the order before committing its stock, a cancel that got there
+	// first makes this payment late, and one after finds the order paid
+	if !late && order.Status == model.OrderStatusPending {
+		paid, err := s.orderRepo.SetOrderStatus(ctx, order.ID, model.OrderStatusPending, model.OrderStatusPaid)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("set order paid")
+			return ErrUpdatePayment
+		}
+		late = !paid
+	}
+
+	p.MarkSucceeded()
+	if late {
+		log.WithFields(baseLogFields).Warn("payment arrived for an order that is no longer payable, refunding")
+		return s.refund(ctx, p, p.Refundable())
+	}
+
+	if commit {
+		if err := s.stockSvc.CommitReservation(ctx, order.ID, order.UserID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("commit reservation")
+			return ErrUpdatePayment
+		}
+	}
+
+	if err := s.paymentRepo.UpdatePayment(ctx, p); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update payment")
+		return ErrUpdatePayment
+	}
+
+	// the order is paid by this payment, now or on a delivery that failed
+	// before the payment was stored
+	s.publishPaid(ctx, &order)
+	return nil
+}
+
+// publishPaid announces a paid order, the invoice is mailed from it. A
+// failure is only logged, the payment is already recorded.
+func (s *paymentService) publishPaid(ctx context.Context, order *model.Order) {
+	var baseLogFields = log.Fields{
+		"order_id": order.ID,
+		"layer":    "payment_service",
+		"method":   "payment_publishPaid",
+	}
+
+	bodyByte, err := json.Marshal(model.OrderEvent{OrderID: order.ID, UserID: order.UserID})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
+		return
+	}
+
+	mqConf := &model.MQConfig{
+		ExchangeName: messagebroker.NotificationExchangeName,
+		ExchangeType: messagebroker.NotificationExchangeType,
+		QueueName:    messagebroker.NotificationQueueName,
+		RoutingKey:   "order.paid",
+	}
+	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+	}
+}
+
+func (s *paymentService) paidByAnother(ctx context.Context, p *model.Payment) bool {
+	attempts, err := s.paymentRepo.GetPaymentsByOrderID(ctx, p.OrderID)
+	if err != nil {
+		return false
+	}
+	for _, attempt := range attempts {
+		if attempt.ID != p.ID && (attempt.Status == model.PaymentStatusSucceeded || attempt.Status == model.PaymentStatusRefunded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *paymentService) fail(ctx context.Context, p *model.Payment, reason string) error {
+	if !p.IsPending() {
+		return nil
+	}
+
+	p.MarkFailed(reason)
+	if err := s.paymentRepo.UpdatePayment(ctx, p); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"payment_id": p.ID,
+			"layer":      "payment_service",
+			"method":     "payment_fail",
+		}).Error("update payment")
+		return ErrUpdatePayment
+	}
+	return nil
+}
+
+// refund sends the refund to the provider and records it
+func (s *paymentService) refund(ctx context.Context, p *model.Payment, amount model.Money) error {
+	if err := p.Refund(amount); err != nil {
+		return err
+	}
+
+	if _, err := s.provider.Refund(ctx, p.ProviderRef, amount); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"payment_id": p.ID,
+			"layer":      "payment_service",
+			"method":     "payment_refund",
+		}).Error("provider refund")
+		return ErrRefundPayment
+	}
+	return s.saveRefund(ctx, p)
+}
+
+// recordRefund records a refund the provider already made
+func (s *paymentService) recordRefund(ctx context.Context, p *model.Payment, amount model.Money) error {
+	if err := p.Refund(amount); err != nil {
+		return err
+	}
+	return s.saveRefund(ctx, p)
+}
+
+// saveRefund stores the payment, and marks the order REFUNDED once all of it is back
+func (s *paymentService) saveRefund(ctx context.Context, p *model.Payment) error {
+	var baseLogFields = log.Fields{
+		"payment_id": p.ID,
+		"order_id":   p.OrderID,
+		"layer":      "payment_service",
+		"method":     "payment_saveRefund",
+	}
+
+	if err := s.paymentRepo.UpdatePayment(ctx, p); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update payment")
+		return ErrUpdatePayment
+	}
+
+	if p.Status != model.PaymentStatusRefunded {
+		return nil
+	}
+
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, p.OrderID, &order); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("get order by id")
+		return nil
+	}
+	if !order.IsPaid() {
+		return nil
+	}
+
+	refunded, err := s.orderRepo.SetOrderStatus(ctx, order.ID, order.Status, model.OrderStatusRefunded)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("set order refunded")
+		return ErrUpdatePayment
+	}
+	if !refunded {
+		log.WithFields(baseLogFields).Warnf("order left %s before it was marked refunded", order.Status)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/payment"
+	"go-rebuild/internal/repository"
+	"testing"
+)
+
+const webhookSecret = "whsec_test"
+
+func TestHandleWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderStatus string
+		reservation string
+		eventType   string
+		forged      bool
+		wantErr     error
+		wantPayment string
+		wantOrder   string
+		wantCommits int
+	}{
+		{name: "succeeded settles the order", orderStatus: model.OrderStatusPending, reservation: model.ReservationActive, eventType: payment.EventSucceeded, wantPayment: model.PaymentStatusSucceeded, wantOrder: model.OrderStatusPaid, wantCommits: 1},
+		{name: "failed leaves the order pending", orderStatus: model.OrderStatusPending, reservation: model.ReservationActive, eventType: payment.EventFailed, wantPayment: model.PaymentStatusFailed, wantOrder: model.OrderStatusPending},
+		{name: "money for a cancelled order is refunded", orderStatus: model.OrderStatusCancelled, reservation: model.ReservationReleased, eventType: payment.EventSucceeded, wantPayment: model.PaymentStatusRefunded, wantOrder: model.OrderStatusCancelled},
+		{name: "money after the reservation lapsed is refunded", orderStatus: model.OrderStatusPending, reservation: model.ReservationExpired, eventType: payment.EventSucceeded, wantPayment: model.PaymentStatusRefunded, wantOrder: model.OrderStatusPending},
+		{name: "forged signature", orderStatus: model.OrderStatusPending, reservation: model.ReservationActive, eventType: payment.EventSucceeded, forged: true, wantErr: payment.ErrInvalidSignature, wantPayment: model.PaymentStatusPending, wantOrder: model.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentEnv(t, tt.orderStatus, tt.reservation)
+
+			payload, signature, err := env.provider.Webhook(tt.eventType, env.payment.ProviderRef, "declined")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.forged {
+				signature = payment.Sign([]byte("guess"), payload)
+			}
+
+			if err := env.svc.HandleWebhook(ctx, payload, signature); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := env.payments.items[env.payment.ID].Status; got != tt.wantPayment {
+				t.Errorf("payment status = %s, want %s", got, tt.wantPayment)
+			}
+			if got := env.orders.items[env.order.ID].Status; got != tt.wantOrder {
+				t.Errorf("order status = %s, want %s", got, tt.wantOrder)
+			}
+			if env.stock.commits != tt.wantCommits {
+				t.Errorf("reservation commits = %d, want %d", env.stock.commits, tt.wantCommits)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookRedelivered(t *testing.T) {
+	ctx := context.Background()
+	env := newPaymentEnv(t, model.OrderStatusPending, model.ReservationActive)
+
+	payload, signature, err := env.provider.Webhook(payment.EventSucceeded, env.payment.ProviderRef, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := env.svc.HandleWebhook(ctx, payload, signature); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a second event for a payment already settled changes nothing either
+	payload, signature, err = env.provider.Webhook(payment.EventSucceeded, env.payment.ProviderRef, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.HandleWebhook(ctx, payload, signature); err != nil {
+		t.Fatal(err)
+	}
+
+	if env.stock.commits != 1 {
+		t.Errorf("reservation commits = %d, want 1", env.stock.commits)
+	}
+	if env.producer.published != 1 {
+		t.Errorf("order.paid published %d times, want 1", env.producer.published)
+	}
+	if len(env.events.items) != 2 {
+		t.Errorf("events recorded = %d, want 2", len(env.events.items))
+	}
+}
+
+// The sweeper cancels the order after the webhook read it as pending: the
+// payment is refunded and the released stock is not committed.
+func TestHandleWebhookCancelRace(t *testing.T) {
+	env := newPaymentEnv(t, model.OrderStatusPending, model.ReservationActive)
+	env.orders.beforeSet = func() {
+		env.orders.beforeSet = nil
+		order := env.orders.items[env.order.ID]
+		order.Status = model.OrderStatusCancelled
+		env.orders.items[env.order.ID] = order
+		env.stock.status = model.ReservationExpired
+	}
+
+	payload, signature, err := env.provider.Webhook(payment.EventSucceeded, env.payment.ProviderRef, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.HandleWebhook(context.Background(), payload, signature); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := env.payments.items[env.payment.ID].Status; got != model.PaymentStatusRefunded {
+		t.Errorf("payment status = %s, want %s", got, model.PaymentStatusRefunded)
+	}
+	if got := env.orders.items[env.order.ID].Status; got != model.OrderStatusCancelled {
+		t.Errorf("order status = %s, want %s", got, model.OrderStatusCancelled)
+	}
+	if env.stock.commits != 0 || env.producer.published != 0 {
+		t.Errorf("commits %d, published %d, want neither", env.stock.commits, env.producer.published)
+	}
+}
+
+func TestHandleWebhookUnknownIntent(t *testing.T) {
+	env := newPaymentEnv(t, model.OrderStatusPending, model.ReservationActive)
+
+	payload, signature, err := env.provider.Webhook(payment.EventSucceeded, "fake_pi_unknown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.HandleWebhook(context.Background(), payload, signature); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrPaymentNotFound)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	tests := []struct {
+		name         string
+		amounts      []*model.Money
+		wantErr      error
+		wantRefunded int64
+		wantPayment  string
+		wantOrder    string
+	}{
+		{name: "part of it", amounts: []*model.Money{money(400)}, wantRefunded: 400, wantPayment: model.PaymentStatusSucceeded, wantOrder: model.OrderStatusPaid},
+		{name: "what is left", amounts: []*model.Money{money(400), nil}, wantRefunded: 1000, wantPayment: model.PaymentStatusRefunded, wantOrder: model.OrderStatusRefunded},
+		{name: "more than was paid", amounts: []*model.Money{money(1001)}, wantErr: model.ErrRefundAmount, wantPayment: model.PaymentStatusSucceeded, wantOrder: model.OrderStatusPaid},
+		{name: "another currency", amounts: []*model.Money{{Amount: 100, Currency: "EUR"}}, wantErr: model.ErrRefundAmount, wantPayment: model.PaymentStatusSucceeded, wantOrder: model.OrderStatusPaid},
+		{name: "nothing left", amounts: []*model.Money{nil, money(1)}, wantErr: model.ErrRefundAmount, wantRefunded: 1000, wantPayment: model.PaymentStatusRefunded, wantOrder: model.OrderStatusRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newPaymentEnv(t, model.OrderStatusPending, model.ReservationActive)
+
+			payload, signature, err := env.provider.Webhook(payment.EventSucceeded, env.payment.ProviderRef, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := env.svc.HandleWebhook(ctx, payload, signature); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, amount := range tt.amounts {
+				_, err = env.svc.Refund(ctx, env.payment.ID, amount)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			p := env.payments.items[env.payment.ID]
+			if p.Refunded.Amount != tt.wantRefunded || p.Status != tt.wantPayment {
+				t.Errorf("payment = %d refunded %s, want %d refunded %s", p.Refunded.Amount, p.Status, tt.wantRefunded, tt.wantPayment)
+			}
+			if got := env.orders.items[env.order.ID].Status; got != tt.wantOrder {
+				t.Errorf("order status = %s, want %s", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func money(amount int64) *model.Money {
+	m := model.NewMoney(amount, "USD")
+	return &m
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes embed the interface they stand in for, a method the tests don't
+// expect the service to call panics.
+
+type paymentEnv struct {
+	svc      module.PaymentService
+	provider *payment.Fake
+	payments *fakePaymentRepo
+	events   *fakeEventRepo
+	orders   *fakeOrderRepo
+	stock    *fakeStockService
+	producer *fakeProducer
+	order    model.Order
+	payment  model.Payment
+}
+
+// newPaymentEnv is a pending payment of a 10.00 USD order, captured at the
+// provider with its webhook not yet delivered
+func newPaymentEnv(t *testing.T, orderStatus string, reservation string) *paymentEnv {
+	t.Helper()
+	env := &paymentEnv{
+		provider: payment.NewFake(webhookSecret),
+		payments: &fakePaymentRepo{items: make(map[string]model.Payment)},
+		events:   &fakeEventRepo{items: make(map[string]model.PaymentEvent)},
+		orders:   &fakeOrderRepo{items: make(map[string]model.Order)},
+		stock:    &fakeStockService{status: reservation},
+		producer: &fakeProducer{},
+	}
+	env.svc = NewPaymentService(env.payments, env.events, env.orders, env.stock, env.provider, env.producer)
+
+	env.order = model.Order{ID: "order_1", UserID: "user_1", Status: orderStatus, Amount: model.NewMoney(1000, "USD")}
+	env.orders.items[env.order.ID] = env.order
+
+	ctx := context.Background()
+	p := model.NewPayment(&env.order, env.provider.Name(), 1)
+	intent, err := env.provider.CreateIntent(ctx, p.ID, p.Amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.provider.Capture(ctx, intent.ID); err != nil {
+		t.Fatal(err)
+	}
+	p.ProviderRef = intent.ID
+	env.payment = *p
+	env.payments.items[p.ID] = *p
+	return env
+}
+
+type fakePaymentRepo struct {
+	repository.PaymentRepository
+	items map[string]model.Payment
+}
+
+func (r *fakePaymentRepo) UpdatePayment(ctx context.Context, p *model.Payment) error {
+	r.items[p.ID] = *p
+	return nil
+}
+
+func (r *fakePaymentRepo) GetPaymentByID(ctx context.Context, id string, p *model.Payment) error {
+	found, ok := r.items[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	*p = found
+	return nil
+}
+
+func (r *fakePaymentRepo) GetPaymentByProviderRef(ctx context.Context, providerRef string, p *model.Payment) error {
+	for _, found := range r.items {
+		if found.ProviderRef == providerRef {
+			*p = found
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (r *fakePaymentRepo) GetPaymentsByOrderID(ctx context.Context, orderID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	for _, p := range r.items {
+		if p.OrderID == orderID {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+type fakeEventRepo struct {
+	repository.PaymentEventRepository
+	items map[string]model.PaymentEvent
+}
+
+func (r *fakeEventRepo) AddEvent(ctx context.Context, e *model.PaymentEvent) error {
+	r.items[e.EventID] = *e
+	return nil
+}
+
+func (r *fakeEventRepo) GetEventByEventID(ctx context.Context, eventID string, e *model.PaymentEvent) error {
+	found, ok := r.items[eventID]
+	if !ok {
+		return errors.New("not found")
+	}
+	*e = found
+	return nil
+}
+
+// fakeOrderRepo compares and sets the status like UpdateWhere, beforeSet runs
+// once the service read the order
+type fakeOrderRepo struct {
+	repository.OrderRepository
+	items     map[string]model.Order
+	beforeSet func()
+}
+
+func (r *fakeOrderRepo) SetOrderStatus(ctx context.Context, id string, from string, to string) (bool, error) {
+	if r.beforeSet != nil {
+		r.beforeSet()
+	}
+	order, ok := r.items[id]
+	if !ok || order.Status != from {
+		return false, nil
+	}
+	order.Status = to
+	r.items[id] = order
+	return true, nil
+}
+
+func (r *fakeOrderRepo) GetOrderByID(ctx context.Context, id string, order *model.Order) error {
+	found, ok := r.items[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	*order = found
+	return nil
+}
+
+type fakeStockService struct {
+	module.StockService
+	status  string
+	commits int
+}
+
+func (s *fakeStockService) GetReservationByOrderID(ctx context.Context, orderID string) (*model.StockReservation, error) {
+	return &model.StockReservation{OrderID: orderID, Status: s.status}, nil
+}
+
+func (s *fakeStockService) CommitReservation(ctx context.Context, orderID string, actorID string) error {
+	s.commits++
+	s.status = model.ReservationCommitted
+	return nil
+}
+
+type fakeProducer struct {
+	published int
+}
+
+func (p *fakeProducer) Publishing(ctx context.Context, mqConf *model.MQConfig, body []byte) error {
+	p.published++
+	return nil
+}
@@ -0,0 +1,39 @@
+package product
+
+import (
+	"context"
+	"go-rebuild/internal/module"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StartPriceScheduler applies scheduled prices that came due, once per
+// interval until ctx is done
+func StartPriceScheduler(ctx context.Context, productSvc module.ProductService, interval time.Duration) {
+	if interval <= 0 {
+		log.Warn("[Scheduler]: price scheduler disabled, interval is not positive")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Infof("[Scheduler]: price scheduler start every %s", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("[Scheduler]: price scheduler stopped")
+			return
+		case <-ticker.C:
+			applied, err := productSvc.ApplyScheduledPrices(ctx)
+			if err != nil {
+				log.WithError(err).Error("[Scheduler]: apply scheduled prices failed")
+				continue
+			}
+			if applied > 0 {
+				log.Infof("[Scheduler]: applied %d scheduled price(s)", applied)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	messagebroker "go-rebuild/internal/message_broker"
+	"go-rebuild/internal/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrSchedulePrice       = errors.New("fail to schedule product price")
+	ErrCancelPrice         = errors.New("fail to cancel scheduled price")
+	ErrGetPriceHistory     = errors.New("fail to get price history")
+	ErrPriceChangeNotFound = errors.New("scheduled price not found")
+	ErrPriceChangeApplied  = errors.New("only a scheduled price can be cancelled")
+)
+
+// ------------------------ Method Price ------------------------
+// SchedulePrice sets the price a product of userID sells at from req.EffectiveAt on
+func (s *productService) SchedulePrice(ctx context.Context, productID string, req *model.PriceScheduleReq, userID string) (*model.PriceChange, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "product_service",
+		"method":     "product_schedulePrice",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return nil, err
+	}
+
+	change := req.ToPriceChange(productID, userID)
+	if err := s.priceRepo.AddPriceChange(ctx, change); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add price change")
+		return nil, ErrSchedulePrice
+	}
+
+	log.WithFields(baseLogFields).Infof("[Service]: price %s scheduled at %s", change.NewPrice, change.EffectiveAt)
+	return change, nil
+}
+
+func (s *productService) CancelScheduledPrice(ctx context.Context, productID string, changeID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"change_id":  changeID,
+		"user_id":    userID,
+		"layer":      "product_service",
+		"method":     "product_cancelScheduledPrice",
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return err
+	}
+
+	var change model.PriceChange
+	if err := s.priceRepo.GetPriceChangeByID(ctx, changeID, &change); err != nil || change.ProductID != productID {
+		return ErrPriceChangeNotFound
+	}
+	if change.Status != model.PriceChangeScheduled {
+		return ErrPriceChangeApplied
+	}
+
+	// the scheduler may claim it at the same time, only one of them wins
+	cancelled, err := s.priceRepo.SetPriceChangeStatus(ctx, change.ID, model.PriceChangeScheduled, model.PriceChangeCancelled)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("cancel price change")
+		return ErrCancelPrice
+	}
+	if !cancelled {
+		return ErrPriceChangeApplied
+	}
+	return nil
+}
+
+// ApplyScheduledPrices moves every product with a due scheduled price to it
+// and returns how many changes were applied. A product with several due
+// changes ends on the latest one. Each change is claimed first, so schedulers
+// running on several instances never apply the same change twice.
+func (s *productService) ApplyScheduledPrices(ctx context.Context) (int, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "product_service",
+		"method": "product_applyScheduledPrices",
+	}
+
+	changes, err := s.priceRepo.GetPriceChangesByStatus(ctx, model.PriceChangeScheduled)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get scheduled price changes")
+		return 0, err
+	}
+
+	now := time.Now()
+	applied := 0
+	for _, change := range changes {
+		if !change.IsDue(now) {
+			continue
+		}
+
+		claimed, err := s.priceRepo.SetPriceChangeStatus(ctx, change.ID, model.PriceChangeScheduled, model.PriceChangeApplying)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Errorf("claim price change {%s}", change.ID)
+			continue
+		}
+		if !claimed {
+			// cancelled or taken by another instance since it was listed
+			continue
+		}
+
+		if err := s.applyPriceChange(ctx, &change); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Errorf("apply price change {%s}", change.ID)
+
+			// hand it back for the next run
+			if _, err := s.priceRepo.SetPriceChangeStatus(ctx, change.ID, model.PriceChangeApplying, model.PriceChangeScheduled); err != nil {
+				log.WithError(err).WithFields(baseLogFields).Errorf("release price change {%s}", change.ID)
+			}
+			continue
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// ------------------------ Method Price Query ------------------------
+func (s *productService) GetPriceHistory(ctx context.Context, productID string) ([]model.PriceChange, error) {
+	var product model.Product
+	if err := s.productRepo.GetProductByID(ctx, productID, &product); err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	changes, err := s.priceRepo.GetPriceChangesByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithField("product_id", productID).Error("[Service]: get price changes by product id")
+		return nil, ErrGetPriceHistory
+	}
+	if changes == nil {
+		changes = make([]model.PriceChange, 0)
+	}
+	return changes, nil
+}
+
+// ------------------------ Private Method ------------------------
+// applyPriceChange writes the new price through the product repo, which
+// refreshes the cached product and drops the cached list
+func (s *productService) applyPriceChange(ctx context.Context, change *model.PriceChange) error {
+	var product model.Product
+	if err := s.productRepo.GetProductByID(ctx, change.ProductID, &product); err != nil {
+		// the product is gone, the change has nothing left to apply to
+		change.MarkCancelled()
+		return s.priceRepo.UpdatePriceChange(ctx, change)
+	}
+
+	oldPrice := product.Price
+	product.Price = change.NewPrice
+	product.UpdatedAt = time.Now()
+	if err := s.productRepo.UpdateProduct(ctx, &product, product.ID); err != nil {
+		return err
+	}
+
+	change.MarkApplied(oldPrice)
+	if err := s.priceRepo.UpdatePriceChange(ctx, change); err != nil {
+		return err
+	}
+
+	s.publishPriceDrop(ctx, &product, oldPrice)
+	return nil
+}
+
+// recordPriceChange keeps the history, the price itself is already saved so a
+// failure here is only logged
+func (s *productService) recordPriceChange(ctx context.Context, change *model.PriceChange) {
+	if err := s.priceRepo.AddPriceChange(ctx, change); err != nil {
+		log.WithError(err).WithField("product_id", change.ProductID).Error("[Service]: add price change")
+	}
+}
+
+// publishPriceDrop announces a product got cheaper, wishlists are notified
+// from it. A failure is only logged, the price is already saved.
+func (s *productService) publishPriceDrop(ctx context.Context, product *model.Product, oldPrice model.Money) {
+	if !model.IsPriceDrop(oldPrice, product.Price) {
+		return
+	}
+
+	var baseLogFields = log.Fields{
+		"product_id": product.ID,
+		"layer":      "product_service",
+		"method":     "product_publishPriceDrop",
+	}
+
+	bodyByte, err := json.Marshal(&model.PriceChangedEvent{
+		ProductID: product.ID,
+		Title:     product.Title,
+		OldPrice:  oldPrice,
+		NewPrice:  product.Price,
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
+		return
+	}
+
+	mqConf := &model.MQConfig{
+		ExchangeName: messagebroker.NotificationExchangeName,
+		ExchangeType: messagebroker.NotificationExchangeType,
+		QueueName:    messagebroker.NotificationQueueName,
+		RoutingKey:   "product.price_changed",
+	}
+	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+	}
+}
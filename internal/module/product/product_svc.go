@@ -1,13 +1,18 @@
 package product
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"go-rebuild/internal/repository"
+	"go-rebuild/internal/storage"
+	"net/http"
+	"slices"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -22,18 +27,54 @@ var (
 	ErrProductNotFound = errors.New("product not found")
 	ErrPermission      = errors.New("no permission")
 	ErrMarShal         = errors.New("failed to marshal object")
+	ErrUploadImage     = errors.New("fail to upload product image")
+	ErrDeleteImage     = errors.New("fail to delete product image")
+	ErrImageNotFound   = errors.New("product image not found")
 )
 
 type productService struct {
-	productRepo repository.ProductRepository
-	producerSvc messagebroker.ProducerService
+	tx             repository.Transactor
+	productRepo    repository.ProductRepository
+	variantRepo    repository.ProductVariantRepository
+	imageRepo      repository.ProductImageRepository
+	ratingRepo     repository.ProductRatingRepository
+	priceRepo      repository.PriceChangeRepository
+	stockRepo      repository.StockRepository
+	stockSvc       module.StockService
+	producerSvc    messagebroker.ProducerService
+	storage        storage.Storage
+	maxImageSize   int64
+	maxImagePixels int
+	thumbnailSize  int
 }
 
 // ------------------------ Constructor ------------------------
-func NewProductService(productRepo repository.ProductRepository, producerSvc messagebroker.ProducerService) module.ProductService {
+func NewProductService(
+	tx repository.Transactor,
+	productRepo repository.ProductRepository,
+	variantRepo repository.ProductVariantRepository,
+	imageRepo repository.ProductImageRepository,
+	ratingRepo repository.ProductRatingRepository,
+	priceRepo repository.PriceChangeRepository,
+	stockRepo repository.StockRepository,
+	stockSvc module.StockService,
+	producerSvc messagebroker.ProducerService,
+	storage storage.Storage,
+) module.ProductService {
 	return &productService{
-		productRepo: productRepo,
-		producerSvc: producerSvc,
+		tx:             tx,
+		productRepo:    productRepo,
+		variantRepo:    variantRepo,
+		imageRepo:      imageRepo,
+		ratingRepo:     ratingRepo,
+		priceRepo:      priceRepo,
+		stockRepo:      stockRepo,
+		stockSvc:       stockSvc,
+		producerSvc:    producerSvc,
+		storage:        storage,
+		maxImageSize:   appcore_config.Config.ProductImageMaxSize,
+		maxImagePixels: appcore_config.Config.ProductImageMaxPixels,
+		thumbnailSize:  appcore_config.Config.ProductThumbnailSize,
 	}
 }
 
@@ -51,28 +92,37 @@ func (s *productService) Save(ctx context.Context, pReq *model.ProductReq, userI
 		"method":     "product_save",
 	}
 
+	if err := pReq.VerifyVariants(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify variants")
+		return err
+	}
+
 	if err := s.productRepo.AddProduct(ctx, product); err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("add product")
 		return ErrCreateProduct
 	}
 	log.Printf("[Service]: product {%s} created success", product.ID)
 
-	bodyByte, err := json.Marshal(&model.Stock{ProductID: product.ID, Quantity: pReq.Quantity})
-	if err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
-		return ErrMarShal
-	}
-
-	mqConf := &model.MQConfig{
-		ExchangeName: messagebroker.StockExchangeName,
-		ExchangeType: messagebroker.StockExchangeType,
-		QueueName:    messagebroker.StockQueueName,
-		RoutingKey:   "stock.create",
+	if len(pReq.Variants) == 0 {
+		if err := s.publishStock(ctx, "stock.create", product.ID, pReq.Quantity, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("publishing")
+			return err
+		}
+		return nil
 	}
 
-	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("publishing")
-		return err
+	// a product sold in variants has no stock of its own, each variant has one
+	for _, vReq := range pReq.Variants {
+		variant := vReq.ToProductVariant(product.ID)
+		if err := s.variantRepo.AddVariant(ctx, variant); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("add variant")
+			return ErrCreateProduct
+		}
+
+		if err := s.publishStock(ctx, "stock.create", variant.ID, vReq.Quantity, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("publishing")
+			return err
+		}
 	}
 
 	return nil
@@ -95,6 +145,11 @@ func (s *productService) Update(ctx context.Context, pReq *model.ProductReq, id
 		return err
 	}
 
+	if err := pReq.VerifyVariants(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify variants")
+		return err
+	}
+
 	var currentProduct model.Product
 	if err := s.productRepo.GetProductByID(ctx, id, &currentProduct); err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("get product by id")
@@ -105,24 +160,45 @@ func (s *productService) Update(ctx context.Context, pReq *model.ProductReq, id
 		return ErrPermission
 	}
 
+	oldPrice := currentProduct.Price
 	currentProduct.UpdateNotNilField(pReq)
 	if err := s.productRepo.UpdateProduct(ctx, &currentProduct, id); err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("update product")
 		return ErrUpdateProduct
 	}
 
-	bodyByte, err := json.Marshal(&model.Stock{ProductID: currentProduct.ID, Quantity: pReq.Quantity})
-	if err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
-		return ErrMarShal
+	if currentProduct.Price != oldPrice {
+		s.recordPriceChange(ctx, model.NewAppliedPriceChange(id, oldPrice, currentProduct.Price, userID))
+		s.publishPriceDrop(ctx, &currentProduct, oldPrice)
 	}
 
-	mqConf := &model.MQConfig{ExchangeName: messagebroker.StockExchangeName, ExchangeType: messagebroker.StockExchangeType, QueueName: messagebroker.StockQueueName, RoutingKey: "stock.update"}
-	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+	variants, err := s.variantRepo.GetVariantsByProductID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get variants by product id")
 		return ErrUpdateProduct
 	}
 
+	if len(variants) == 0 && len(pReq.Variants) == 0 {
+		if err := s.publishStock(ctx, "stock.update", currentProduct.ID, pReq.Quantity, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("publishing")
+			return ErrUpdateProduct
+		}
+	}
+
+	// the first variants take over from the product's own stock, which goes with them
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if len(variants) == 0 && len(pReq.Variants) > 0 {
+			if err := s.stockSvc.Delete(ctx, id); err != nil {
+				return err
+			}
+		}
+		return s.saveVariants(ctx, id, variants, pReq.Variants, userID)
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("save variants")
+		return err
+	}
+
 	log.Printf("[Service]: product {%s} updated success\n", currentProduct.ID)
 	return nil
 }
@@ -140,15 +216,133 @@ func (s *productService) Delete(ctx context.Context, id string) error {
 		return ErrProductNotFound
 	}
 
-	if err := s.productRepo.DeleteProduct(ctx, id); err != nil {
+	variants, err := s.variantRepo.GetVariantsByProductID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get variants by product id")
+		return ErrDeleteProduct
+	}
+
+	// the product, its variants and every stock row they own go together
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.DeleteProduct(ctx, id); err != nil {
+			return err
+		}
+		if err := s.stockSvc.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		for _, variant := range variants {
+			if err := s.variantRepo.DeleteVariant(ctx, variant.ID); err != nil {
+				return err
+			}
+			if err := s.stockSvc.Delete(ctx, variant.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("delete product")
 		return ErrDeleteProduct
 	}
 
+	images, err := s.imageRepo.GetImagesByProductID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("get images by product id")
+	}
+	for _, image := range images {
+		s.removeImage(ctx, &image)
+	}
+
 	log.Printf("[Service]: product {%s} deleted success\n", product.ID)
 	return nil
 }
 
+// ------------------------ Method Image ------------------------
+// UploadImage stores an image of a product owned by userID next to a thumbnail.
+// The type is sniffed from the bytes, the client's content type is not trusted.
+func (s *productService) UploadImage(ctx context.Context, productID string, data []byte, userID string) (*model.ProductImageResp, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "product_service",
+		"method":     "product_uploadImage",
+	}
+
+	if len(data) == 0 {
+		return nil, model.ErrImageEmpty
+	}
+	if s.maxImageSize > 0 && int64(len(data)) > s.maxImageSize {
+		return nil, model.ErrImageSize
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return nil, err
+	}
+
+	image, err := model.NewProductImage(productID, http.DetectContentType(data), int64(len(data)))
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("new product image")
+		return nil, err
+	}
+
+	thumbnail, err := storage.Thumbnail(data, s.thumbnailSize, s.maxImagePixels)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("make thumbnail")
+		if errors.Is(err, storage.ErrImagePixels) {
+			return nil, model.ErrImagePixels
+		}
+		return nil, model.ErrImageType
+	}
+
+	if err := s.storage.Put(ctx, image.Key, bytes.NewReader(data), image.ContentType); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("put image")
+		return nil, ErrUploadImage
+	}
+	if err := s.storage.Put(ctx, image.ThumbnailKey, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("put thumbnail")
+		s.removeObjects(ctx, image.Key)
+		return nil, ErrUploadImage
+	}
+
+	if err := s.imageRepo.AddImage(ctx, image); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add image")
+		s.removeObjects(ctx, image.Key, image.ThumbnailKey)
+		return nil, ErrUploadImage
+	}
+
+	log.WithFields(baseLogFields).Infof("[Service]: image {%s} uploaded", image.ID)
+	return image.ToProductImageResp(s.storage.URL), nil
+}
+
+func (s *productService) DeleteImage(ctx context.Context, productID string, imageID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"image_id":   imageID,
+		"user_id":    userID,
+		"layer":      "product_service",
+		"method":     "product_deleteImage",
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return err
+	}
+
+	var image model.ProductImage
+	if err := s.imageRepo.GetImageByID(ctx, imageID, &image); err != nil || image.ProductID != productID {
+		return ErrImageNotFound
+	}
+
+	if err := s.imageRepo.DeleteImage(ctx, imageID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete image")
+		return ErrDeleteImage
+	}
+	s.removeObjects(ctx, image.Key, image.ThumbnailKey)
+	return nil
+}
+
 // ------------------------ Method Basic Query ------------------------
 func (s *productService) GetAll(ctx context.Context) ([]model.ProductResp, error) {
 	var baseLogFields = log.Fields{
@@ -164,7 +358,7 @@ func (s *productService) GetAll(ctx context.Context) ([]model.ProductResp, error
 
 	var productsRes []model.ProductResp
 	for _, product := range products {
-		productRes := product.ToProductRes()
+		productRes := s.toProductResp(ctx, &product)
 		productsRes = append(productsRes, *productRes)
 	}
 
@@ -185,7 +379,147 @@ func (s *productService) GetByID(ctx context.Context, id string) (*model.Product
 		return nil, ErrProductNotFound
 	}
 
-	productRes := product.ToProductRes()
+	productRes := s.toProductResp(ctx, &product)
 	log.Printf("[Service]: get product {%s} success\n", product.ID)
 	return productRes, nil
 }
+
+func (s *productService) GetBySeller(ctx context.Context, sellerID string) ([]model.ProductResp, error) {
+	var baseLogFields = log.Fields{
+		"seller_id": sellerID,
+		"layer":     "product_service",
+		"method":    "product_getBySeller",
+	}
+
+	products, err := s.productRepo.GetProductsBySeller(ctx, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get products by seller")
+		return nil, ErrProductNotFound
+	}
+
+	productsRes := make([]model.ProductResp, 0, len(products))
+	for _, product := range products {
+		productsRes = append(productsRes, *s.toProductResp(ctx, &product))
+	}
+
+	log.Printf("[Service]: get products of seller {%s} success\n", sellerID)
+	return productsRes, nil
+}
+
+// ------------------------ Private Method ------------------------
+func (s *productService) checkOwner(ctx context.Context, productID string, userID string) error {
+	var product model.Product
+	if err := s.productRepo.GetProductByID(ctx, productID, &product); err != nil {
+		return ErrProductNotFound
+	}
+
+	if product.CreatedBy != userID {
+		return ErrPermission
+	}
+	return nil
+}
+
+// removeImage drops the record of an image and then its objects
+func (s *productService) removeImage(ctx context.Context, image *model.ProductImage) {
+	if err := s.imageRepo.DeleteImage(ctx, image.ID); err != nil {
+		log.WithError(err).WithField("image_id", image.ID).Warn("[Service]: delete image")
+		return
+	}
+	s.removeObjects(ctx, image.Key, image.ThumbnailKey)
+}
+
+// removeObjects is best effort, an object left behind is only wasted space
+func (s *productService) removeObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.WithError(err).WithField("key", key).Warn("[Service]: delete object")
+		}
+	}
+}
+
+// saveVariants updates the variants a request names by id and adds the ones
+// without an id, each with its own stock message
+func (s *productService) saveVariants(ctx context.Context, productID string, current []model.ProductVariant, vReqs []model.ProductVariantReq, userID string) error {
+	for _, vReq := range vReqs {
+		if vReq.ID == "" {
+			variant := vReq.ToProductVariant(productID)
+			if err := s.variantRepo.AddVariant(ctx, variant); err != nil {
+				return ErrUpdateProduct
+			}
+			if err := s.publishStock(ctx, "stock.create", variant.ID, vReq.Quantity, userID); err != nil {
+				return ErrUpdateProduct
+			}
+			continue
+		}
+
+		i := slices.IndexFunc(current, func(v model.ProductVariant) bool { return v.ID == vReq.ID })
+		if i < 0 {
+			return model.ErrVariantNotFound
+		}
+
+		variant := current[i]
+		variant.Apply(&vReq)
+		if err := s.variantRepo.UpdateVariant(ctx, &variant, variant.ID); err != nil {
+			return ErrUpdateProduct
+		}
+		if err := s.publishStock(ctx, "stock.update", variant.ID, vReq.Quantity, userID); err != nil {
+			return ErrUpdateProduct
+		}
+	}
+	return nil
+}
+
+// publishStock asks the stock consumer to create or set the stock of a product
+// or a variant
+func (s *productService) publishStock(ctx context.Context, routingKey string, stockID string, quantity int, userID string) error {
+	bodyByte, err := json.Marshal(&model.StockMessage{ProductID: stockID, Quantity: quantity, ActorID: userID})
+	if err != nil {
+		return ErrMarShal
+	}
+
+	mqConf := &model.MQConfig{
+		ExchangeName: messagebroker.StockExchangeName,
+		ExchangeType: messagebroker.StockExchangeType,
+		QueueName:    messagebroker.StockQueueName,
+		RoutingKey:   routingKey,
+	}
+	return s.producerSvc.Publishing(ctx, mqConf, bodyByte)
+}
+
+// toProductResp adds the rating, the images and the variants with what is
+// available of each, a variant without a stock row shows as out of stock
+func (s *productService) toProductResp(ctx context.Context, product *model.Product) *model.ProductResp {
+	productRes := product.ToProductRes()
+
+	// a product nobody reviewed yet has no rating row
+	var rating model.ProductRating
+	if err := s.ratingRepo.GetRatingByProductID(ctx, product.ID, &rating); err == nil {
+		productRes.Rating = rating.Average()
+		productRes.Reviews = rating.Count
+	}
+
+	productRes.Images = make([]model.ProductImageResp, 0)
+	images, err := s.imageRepo.GetImagesByProductID(ctx, product.ID)
+	if err != nil {
+		log.WithError(err).WithField("product_id", product.ID).Warn("[Service]: get images by product id")
+	}
+	for _, image := range images {
+		productRes.Images = append(productRes.Images, *image.ToProductImageResp(s.storage.URL))
+	}
+
+	variants, err := s.variantRepo.GetVariantsByProductID(ctx, product.ID)
+	if err != nil {
+		log.WithError(err).WithField("product_id", product.ID).Warn("[Service]: get variants by product id")
+		return productRes
+	}
+
+	for _, variant := range variants {
+		var stock model.Stock
+		available := 0
+		if err := s.stockRepo.GetStockByProductID(ctx, variant.ID, &stock); err == nil {
+			available = stock.Available()
+		}
+		productRes.Variants = append(productRes.Variants, *variant.ToProductVariantResp(product.Price, available))
+	}
+	return productRes
+}
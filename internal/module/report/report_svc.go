@@ -0,0 +1,123 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrGetOrders = errors.New("fail to get seller orders")
+	ErrGetReport = errors.New("fail to get sales report")
+)
+
+type reportService struct {
+	orderRepo  repository.OrderRepository
+	reportRepo repository.SalesReportRepository
+	userSvc    module.UserService
+}
+
+// ------------------------ Constructor ------------------------
+func NewReportService(orderRepo repository.OrderRepository, reportRepo repository.SalesReportRepository, userSvc module.UserService) module.ReportService {
+	return &reportService{
+		orderRepo:  orderRepo,
+		reportRepo: reportRepo,
+		userSvc:    userSvc,
+	}
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetSellerOrders pages through the orders of the seller's products with the
+// buyer of each, an empty status lists every status
+func (s *reportService) GetSellerOrders(ctx context.Context, sellerID string, status string, page model.Page) (*model.PageResp[model.SellerOrderResp], error) {
+	var baseLogFields = log.Fields{
+		"seller_id": sellerID,
+		"layer":     "report_service",
+		"method":    "report_getSellerOrders",
+	}
+
+	page.Normalize()
+	orders, total, err := s.orderRepo.GetOrderPageBySeller(ctx, sellerID, status, page)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get order page")
+		return nil, ErrGetOrders
+	}
+
+	buyers := make(map[string]*model.User)
+	ordersRes := make([]model.SellerOrderResp, 0, len(orders))
+	for _, order := range orders {
+		buyer, ok := buyers[order.UserID]
+		if !ok {
+			// a buyer who deleted the account leaves the order without one
+			if buyer, err = s.userSvc.GetByID(ctx, order.UserID); err != nil {
+				log.WithError(err).WithFields(baseLogFields).Warn("get buyer")
+				buyer = nil
+			}
+			buyers[order.UserID] = buyer
+		}
+		ordersRes = append(ordersRes, *order.ToSellerOrderResp(buyer))
+	}
+	return model.NewPageResp(ordersRes, page, total), nil
+}
+
+// GetSummary counts the seller's orders, units sold, revenue and the
+// cancellation rate over the range
+func (s *reportService) GetSummary(ctx context.Context, sellerID string, req *model.ReportReq) (*model.SalesSummary, error) {
+	filter, err := req.ToSalesFilter(sellerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reportRepo.GetSalesByStatus(ctx, filter)
+	if err != nil {
+		s.logError(err, sellerID, "report_getSummary")
+		return nil, ErrGetReport
+	}
+	return model.NewSalesSummary(filter, rows), nil
+}
+
+// GetRevenue is the seller's revenue per day, week or month, periods without
+// a sale are left out
+func (s *reportService) GetRevenue(ctx context.Context, sellerID string, req *model.ReportReq) ([]model.RevenuePoint, error) {
+	filter, err := req.ToSalesFilter(sellerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reportRepo.GetSalesByPeriod(ctx, filter)
+	if err != nil {
+		s.logError(err, sellerID, "report_getRevenue")
+		return nil, ErrGetReport
+	}
+	return model.NewRevenuePoints(rows), nil
+}
+
+// GetTopProducts ranks the seller's products by units sold
+func (s *reportService) GetTopProducts(ctx context.Context, sellerID string, req *model.ReportReq) ([]model.TopProduct, error) {
+	filter, err := req.ToSalesFilter(sellerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reportRepo.GetSalesByProduct(ctx, filter)
+	if err != nil {
+		s.logError(err, sellerID, "report_getTopProducts")
+		return nil, ErrGetReport
+	}
+	return model.NewTopProducts(rows), nil
+}
+
+// ------------------------ Private Method ------------------------
+func (s *reportService) logError(err error, sellerID string, method string) {
+	log.WithError(err).WithFields(log.Fields{
+		"seller_id": sellerID,
+		"layer":     "report_service",
+		"method":    method,
+	}).Error("aggregate sales")
+}
@@ -0,0 +1,270 @@
+package returns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rebuild/internal/mail"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateReturn = errors.New("fail to create return")
+	ErrUpdateReturn = errors.New("fail to update return")
+	ErrRestock      = errors.New("fail to restock returned items")
+	ErrRefund       = errors.New("return approved but the refund failed, approve again to retry it")
+
+	ErrReturnNotFound = errors.New("return not found")
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrNotReturnable  = errors.New("only a paid order can be returned")
+	ErrPermission     = errors.New("no permission for this return")
+)
+
+// maxRequestAttempts bounds how often Request saves again after losing a Seq
+const maxRequestAttempts = 3
+
+type returnService struct {
+	returnRepo repository.ReturnRepository
+	orderRepo  repository.OrderRepository
+	productSvc module.ProductService
+	stockSvc   module.StockService
+	paymentSvc module.PaymentService
+	userSvc    module.UserService
+	mailSvc    mail.Mail
+}
+
+// ------------------------ Constructor ------------------------
+func NewReturnService(returnRepo repository.ReturnRepository, orderRepo repository.OrderRepository, productSvc module.ProductService, stockSvc module.StockService, paymentSvc module.PaymentService, userSvc module.UserService, mailSvc mail.Mail) module.ReturnService {
+	return &returnService{
+		returnRepo: returnRepo,
+		orderRepo:  orderRepo,
+		productSvc: productSvc,
+		stockSvc:   stockSvc,
+		paymentSvc: paymentSvc,
+		userSvc:    userSvc,
+		mailSvc:    mailSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Request opens a return for quantity items of a paid order of the user, at
+// most what earlier returns that were not rejected left of the order. A
+// request saved concurrently takes the same Seq and fails to save, it is
+// checked again against the returns it missed.
+func (s *returnService) Request(ctx context.Context, orderID string, rReq *model.ReturnReq, userID string) (*model.Return, error) {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "return_service",
+		"method":   "return_request",
+	}
+
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get order by id")
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrPermission
+	}
+	if !order.CanBeReturned() {
+		return nil, ErrNotReturnable
+	}
+
+	product, err := s.productSvc.GetByID(ctx, order.ProductID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get product by id")
+		return nil, ErrCreateReturn
+	}
+
+	var r *model.Return
+	for attempt := 1; r == nil; attempt++ {
+		others, err := s.returnRepo.GetReturnsByOrderID(ctx, orderID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("get returns by order id")
+			return nil, ErrCreateReturn
+		}
+
+		refund, err := model.ReturnRefund(&order, rReq.Quantity, others)
+		if err != nil {
+			return nil, err
+		}
+
+		next := rReq.ToReturn(&order, product.CreatedBy, refund, others)
+		if err := s.returnRepo.AddReturn(ctx, next); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("add return")
+			if attempt == maxRequestAttempts {
+				return nil, ErrCreateReturn
+			}
+			continue
+		}
+		r = next
+	}
+	log.Info("[Service]: return requested success:", r.ID)
+
+	s.notify(ctx, r.SellerID, "Return requested: "+product.Title,
+		fmt.Sprintf("A buyer asked to return %d x %s from order %s. Reason: %s", r.Quantity, product.Title, r.OrderID, r.Reason))
+	return r, nil
+}
+
+// Decide approves or rejects a requested return. The decision is claimed with
+// a conditional status change first, so a return is decided and restocked
+// once. Approval then puts the items back in stock and refunds the buyer. A
+// refund that fails leaves the return APPROVED, deciding APPROVED again
+// retries only the refund.
+func (s *returnService) Decide(ctx context.Context, id string, dReq *model.ReturnDecisionReq, userID string, role string) (*model.Return, error) {
+	var baseLogFields = log.Fields{
+		"return_id": id,
+		"layer":     "return_service",
+		"method":    "return_decide",
+	}
+
+	if err := dReq.Verify(); err != nil {
+		return nil, err
+	}
+
+	var r model.Return
+	if err := s.returnRepo.GetReturnByID(ctx, id, &r); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get return by id")
+		return nil, ErrReturnNotFound
+	}
+	if role != "ADMIN" && r.SellerID != userID {
+		return nil, ErrPermission
+	}
+
+	retry := r.Status == model.ReturnApproved && dReq.Status == model.ReturnApproved
+	if r.Status != model.ReturnRequested && !retry {
+		return nil, model.ErrReturnDecided
+	}
+
+	if !retry {
+		claimed, err := s.returnRepo.SetReturnStatus(ctx, r.ID, model.ReturnRequested, dReq.Status)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("claim return")
+			return nil, ErrUpdateReturn
+		}
+		if !claimed {
+			return nil, model.ErrReturnDecided
+		}
+	}
+
+	if dReq.Status == model.ReturnRejected {
+		r.SetStatus(model.ReturnRejected, userID, dReq.Note)
+		if err := s.returnRepo.UpdateReturn(ctx, &r); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("update return")
+			return nil, ErrUpdateReturn
+		}
+		s.notify(ctx, r.UserID, "Return rejected",
+			fmt.Sprintf("Your return of %d item(s) from order %s was rejected. %s", r.Quantity, r.OrderID, dReq.Note))
+		return &r, nil
+	}
+
+	if !retry {
+		if err := s.restock(ctx, &r, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("restock")
+			if _, err := s.returnRepo.SetReturnStatus(ctx, r.ID, model.ReturnApproved, model.ReturnRequested); err != nil {
+				log.WithError(err).WithFields(baseLogFields).Error("release return")
+			}
+			return nil, ErrRestock
+		}
+		r.SetStatus(model.ReturnApproved, userID, dReq.Note)
+		if err := s.returnRepo.UpdateReturn(ctx, &r); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("update return")
+			return nil, ErrUpdateReturn
+		}
+		s.notify(ctx, r.UserID, "Return approved",
+			fmt.Sprintf("Your return of %d item(s) from order %s was approved, a refund of %s is on its way.", r.Quantity, r.OrderID, r.Refund))
+	}
+
+	if _, err := s.paymentSvc.RefundOrder(ctx, r.OrderID, r.Refund); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("refund order")
+		return nil, ErrRefund
+	}
+
+	r.SetStatus(model.ReturnRefunded, userID, "")
+	if err := s.returnRepo.UpdateReturn(ctx, &r); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update return")
+		return nil, ErrUpdateReturn
+	}
+	log.Info("[Service]: return refunded success:", r.ID)
+
+	s.notify(ctx, r.UserID, "Refund issued",
+		fmt.Sprintf("%s was refunded for your return from order %s.", r.Refund, r.OrderID))
+	return &r, nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *returnService) GetByOrderID(ctx context.Context, orderID string, userID string, role string) ([]model.Return, error) {
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		return nil, ErrOrderNotFound
+	}
+
+	returns, err := s.returnRepo.GetReturnsByOrderID(ctx, orderID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"order_id": orderID,
+			"layer":    "return_service",
+			"method":   "return_getByOrderID",
+		}).Error("get returns by order id")
+		return nil, ErrReturnNotFound
+	}
+
+	if role == "ADMIN" || order.UserID == userID {
+		return returns, nil
+	}
+
+	// the seller sees the returns of their own product
+	for _, r := range returns {
+		if r.SellerID != userID {
+			return nil, ErrPermission
+		}
+	}
+	if len(returns) == 0 {
+		return nil, ErrPermission
+	}
+	return returns, nil
+}
+
+func (s *returnService) GetBySeller(ctx context.Context, sellerID string) ([]model.Return, error) {
+	returns, err := s.returnRepo.GetReturnsBySellerID(ctx, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"seller_id": sellerID,
+			"layer":     "return_service",
+			"method":    "return_getBySeller",
+		}).Error("get returns by seller id")
+		return nil, ErrReturnNotFound
+	}
+	return returns, nil
+}
+
+// ------------------------ Private Method ------------------------
+// restock puts the returned items back in the warehouse the order shipped from
+func (s *returnService) restock(ctx context.Context, r *model.Return, actorID string) error {
+	ref := model.StockRef{
+		Reason:      model.StockReasonOrderReturned,
+		ReferenceID: r.ID,
+		ActorID:     actorID,
+	}
+	if reservation, err := s.stockSvc.GetReservationByOrderID(ctx, r.OrderID); err == nil {
+		ref.WarehouseID = reservation.WarehouseID
+	}
+	return s.stockSvc.IncreaseQuantity(ctx, r.Quantity, r.StockID(), ref)
+}
+
+// notify emails a user about their return, a mail that fails is only logged
+func (s *returnService) notify(ctx context.Context, userID string, subject string, message string) {
+	user, err := s.userSvc.GetByID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithField("user_id", userID).Warn("[Service]: return notification user not found")
+		return
+	}
+	if err := s.mailSvc.SendEmail(message, subject, []string{user.Email}); err != nil {
+		log.WithError(err).WithField("user_id", userID).Warn("[Service]: send return notification")
+	}
+}
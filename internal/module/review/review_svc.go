@@ -0,0 +1,187 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateReview    = errors.New("fail to create review")
+	ErrModerateReview  = errors.New("fail to moderate review")
+	ErrDeleteReview    = errors.New("fail to delete review")
+	ErrGetReviews      = errors.New("fail to get reviews")
+	ErrReviewNotFound  = errors.New("review not found")
+	ErrProductNotFound = errors.New("product not found")
+	ErrReviewExists    = errors.New("product is already reviewed by this user")
+	ErrNotPurchased    = errors.New("only a buyer with a delivered order for this product can review it")
+)
+
+type reviewService struct {
+	reviewRepo  repository.ReviewRepository
+	ratingRepo  repository.ProductRatingRepository
+	productRepo repository.ProductRepository
+	orderRepo   repository.OrderRepository
+}
+
+// ------------------------ Constructor ------------------------
+func NewReviewService(reviewRepo repository.ReviewRepository, ratingRepo repository.ProductRatingRepository, productRepo repository.ProductRepository, orderRepo repository.OrderRepository) module.ReviewService {
+	return &reviewService{
+		reviewRepo:  reviewRepo,
+		ratingRepo:  ratingRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Save reviews a product for a buyer, the order in the request has to be theirs and for this product
+func (s *reviewService) Save(ctx context.Context, productID string, req *model.ReviewReq, userID string) (*model.ReviewResp, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "review_service",
+		"method":     "review_save",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var product model.Product
+	if err := s.productRepo.GetProductByID(ctx, productID, &product); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get product by id")
+		return nil, ErrProductNotFound
+	}
+
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, req.OrderID, &order); err != nil || !order.CanBeReviewed(productID, userID) {
+		return nil, ErrNotPurchased
+	}
+
+	reviews, err := s.reviewRepo.GetReviewsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get reviews by product id")
+		return nil, ErrCreateReview
+	}
+	for _, review := range reviews {
+		if review.UserID == userID {
+			return nil, ErrReviewExists
+		}
+	}
+
+	review := req.ToReview(productID, userID)
+	if err := s.reviewRepo.AddReview(ctx, review); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add review")
+		return nil, ErrCreateReview
+	}
+
+	s.refreshRating(ctx, productID, append(reviews, *review))
+
+	log.WithFields(baseLogFields).Infof("[Service]: review {%s} created", review.ID)
+	return review.ToReviewResp(), nil
+}
+
+// Moderate publishes or hides a review, only published reviews count toward the rating
+func (s *reviewService) Moderate(ctx context.Context, id string, req *model.ReviewModerateReq) (*model.ReviewResp, error) {
+	var baseLogFields = log.Fields{
+		"review_id": id,
+		"layer":     "review_service",
+		"method":    "review_moderate",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var review model.Review
+	if err := s.reviewRepo.GetReviewByID(ctx, id, &review); err != nil {
+		return nil, ErrReviewNotFound
+	}
+
+	review.Moderate(req.Status)
+	if err := s.reviewRepo.UpdateReview(ctx, &review, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update review")
+		return nil, ErrModerateReview
+	}
+
+	s.recountRating(ctx, review.ProductID)
+
+	log.WithFields(baseLogFields).Infof("[Service]: review moderated to {%s}", review.Status)
+	return review.ToReviewResp(), nil
+}
+
+func (s *reviewService) Delete(ctx context.Context, id string) error {
+	var baseLogFields = log.Fields{
+		"review_id": id,
+		"layer":     "review_service",
+		"method":    "review_delete",
+	}
+
+	var review model.Review
+	if err := s.reviewRepo.GetReviewByID(ctx, id, &review); err != nil {
+		return ErrReviewNotFound
+	}
+
+	if err := s.reviewRepo.DeleteReview(ctx, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete review")
+		return ErrDeleteReview
+	}
+
+	s.recountRating(ctx, review.ProductID)
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetByProductID pages through the reviews of a product, hidden ones are only
+// listed for moderators
+func (s *reviewService) GetByProductID(ctx context.Context, productID string, page model.Page, withHidden bool) (*model.PageResp[model.ReviewResp], error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"layer":      "review_service",
+		"method":     "review_getByProductID",
+	}
+
+	status := model.ReviewPublished
+	if withHidden {
+		status = ""
+	}
+
+	page.Normalize()
+	reviews, total, err := s.reviewRepo.GetReviewPage(ctx, productID, status, page)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get review page")
+		return nil, ErrGetReviews
+	}
+
+	reviewsRes := make([]model.ReviewResp, 0, len(reviews))
+	for _, review := range reviews {
+		reviewsRes = append(reviewsRes, *review.ToReviewResp())
+	}
+	return model.NewPageResp(reviewsRes, page, total), nil
+}
+
+// ------------------------ Private Method ------------------------
+func (s *reviewService) recountRating(ctx context.Context, productID string) {
+	reviews, err := s.reviewRepo.GetReviewsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithField("product_id", productID).Warn("[Service]: get reviews by product id")
+		return
+	}
+	s.refreshRating(ctx, productID, reviews)
+}
+
+// refreshRating sums the reviews up again rather than shifting the old sum, a
+// failed write is fixed by the next review of the product
+func (s *reviewService) refreshRating(ctx context.Context, productID string, reviews []model.Review) {
+	if err := s.ratingRepo.SaveRating(ctx, model.NewProductRating(productID, reviews)); err != nil {
+		log.WithError(err).WithField("product_id", productID).Warn("[Service]: save product rating")
+	}
+}
@@ -0,0 +1,186 @@
+package shipment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	messagebroker "go-rebuild/internal/message_broker"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateShipment = errors.New("fail to create shipment")
+	ErrUpdateShipment = errors.New("fail to update shipment")
+
+	ErrShipmentNotFound = errors.New("shipment not found")
+	ErrOrderNotFound    = errors.New("order not found")
+	ErrNotShippable     = errors.New("only a paid order that is not shipped yet can be shipped")
+	ErrPermission       = errors.New("no permission for this shipment")
+)
+
+type shipmentService struct {
+	shipmentRepo repository.ShipmentRepository
+	orderRepo    repository.OrderRepository
+	productSvc   module.ProductService
+	producerSvc  messagebroker.ProducerService
+}
+
+// ------------------------ Constructor ------------------------
+func NewShipmentService(shipmentRepo repository.ShipmentRepository, orderRepo repository.OrderRepository, productSvc module.ProductService, producerSvc messagebroker.ProducerService) module.ShipmentService {
+	return &shipmentService{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		productSvc:   productSvc,
+		producerSvc:  producerSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Ship records the parcel the seller sent for a paid order and moves the
+// order to SHIPPED
+func (s *shipmentService) Ship(ctx context.Context, orderID string, req *model.ShipmentReq, userID string, role string) (*model.Shipment, error) {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "shipment_service",
+		"method":   "shipment_ship",
+	}
+
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	order, sellerID, err := s.sellerOrder(ctx, orderID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.OrderStatusPaid {
+		return nil, ErrNotShippable
+	}
+
+	shipment := req.ToShipment(order, sellerID)
+	if err := s.shipmentRepo.AddShipment(ctx, shipment); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add shipment")
+		return nil, ErrCreateShipment
+	}
+
+	if err := s.setOrderStatus(ctx, order, model.OrderStatusShipped); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update order")
+		return nil, ErrCreateShipment
+	}
+	log.Info("[Service]: order shipped success:", orderID)
+
+	s.publish(ctx, shipment)
+	return shipment, nil
+}
+
+// AddEvent records a tracking update, DELIVERED moves the order to DELIVERED
+func (s *shipmentService) AddEvent(ctx context.Context, orderID string, req *model.ShipmentEventReq, userID string, role string) (*model.Shipment, error) {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "shipment_service",
+		"method":   "shipment_addEvent",
+	}
+
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	order, _, err := s.sellerOrder(ctx, orderID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var shipment model.Shipment
+	if err := s.shipmentRepo.GetShipmentByOrderID(ctx, orderID, &shipment); err != nil {
+		return nil, ErrShipmentNotFound
+	}
+	if shipment.IsDelivered() {
+		return nil, model.ErrShipmentDone
+	}
+
+	shipment.AddEvent(req)
+	if err := s.shipmentRepo.UpdateShipment(ctx, &shipment); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update shipment")
+		return nil, ErrUpdateShipment
+	}
+
+	// a refunded order keeps its status, the parcel is only tracked
+	if shipment.IsDelivered() && order.Status == model.OrderStatusShipped {
+		if err := s.setOrderStatus(ctx, order, model.OrderStatusDelivered); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("update order")
+			return nil, ErrUpdateShipment
+		}
+	}
+
+	s.publish(ctx, &shipment)
+	return &shipment, nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *shipmentService) GetByOrderID(ctx context.Context, orderID string, userID string, role string) (*model.Shipment, error) {
+	var shipment model.Shipment
+	if err := s.shipmentRepo.GetShipmentByOrderID(ctx, orderID, &shipment); err != nil {
+		return nil, ErrShipmentNotFound
+	}
+
+	if role != "ADMIN" && shipment.UserID != userID && shipment.SellerID != userID {
+		return nil, ErrPermission
+	}
+	return &shipment, nil
+}
+
+// ------------------------ Private Method ------------------------
+// sellerOrder loads the order and checks the user sells its product
+func (s *shipmentService) sellerOrder(ctx context.Context, orderID string, userID string, role string) (*model.Order, string, error) {
+	var order model.Order
+	if err := s.orderRepo.GetOrderByID(ctx, orderID, &order); err != nil {
+		return nil, "", ErrOrderNotFound
+	}
+
+	product, err := s.productSvc.GetByID(ctx, order.ProductID)
+	if err != nil {
+		return nil, "", ErrOrderNotFound
+	}
+
+	if role != "ADMIN" && product.CreatedBy != userID {
+		return nil, "", ErrPermission
+	}
+	return &order, product.CreatedBy, nil
+}
+
+func (s *shipmentService) setOrderStatus(ctx context.Context, order *model.Order, status string) error {
+	order.Status = status
+	order.UpdatedAt = time.Now()
+	return s.orderRepo.UpdateOrder(ctx, order, order.ID)
+}
+
+// publish tells the buyer about the shipment, a notice that fails is only logged
+func (s *shipmentService) publish(ctx context.Context, shipment *model.Shipment) {
+	var baseLogFields = log.Fields{
+		"order_id": shipment.OrderID,
+		"layer":    "shipment_service",
+		"method":   "shipment_publish",
+	}
+
+	bodyByte, err := json.Marshal(shipment.ToShipmentNotice())
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
+		return
+	}
+
+	mqConf := &model.MQConfig{
+		ExchangeName: messagebroker.NotificationExchangeName,
+		ExchangeType: messagebroker.NotificationExchangeType,
+		QueueName:    messagebroker.NotificationQueueName,
+		RoutingKey:   "shipment.updated",
+	}
+	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+	}
+}
@@ -2,10 +2,15 @@ package stock
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
+	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"go-rebuild/internal/repository"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -16,21 +21,72 @@ var (
 	ErrCreateStock   = errors.New("fail to create stock")
 	ErrUpdateStock   = errors.New("fail to update stock")
 	ErrDeleteStock   = errors.New("fail to delete stock")
-	ErrStockNotFound = errors.New("stock not found")
+	ErrStockNotFound = model.ErrStockNotFound
 	ErrStockQuantity = errors.New("fail to increase or decrease stock quantity")
+	ErrAdjustStock   = errors.New("fail to adjust stock")
+	ErrPermission    = model.ErrStockAccess
+	ErrGetMovements  = errors.New("fail to get stock movements")
+	ErrSubscribe     = errors.New("fail to subscribe to stock alert")
+	ErrUnsubscribe   = errors.New("fail to unsubscribe from stock alert")
+	ErrSubscribed    = errors.New("already subscribed to this product")
+
+	ErrReserveStock        = errors.New("fail to reserve stock")
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	ErrReservationClosed   = errors.New("stock reservation is no longer active")
+
+	ErrWarehouseNotFound = invalid(errors.New("warehouse not found"))
+	ErrWarehouseStock    = errors.New("fail to load warehouse stock")
+	ErrTransferStock     = errors.New("fail to transfer stock")
 )
 
 type stockService struct {
-	repo repository.StockRepository
+	tx                 repository.Transactor
+	repo               repository.StockRepository
+	movementRepo       repository.StockMovementRepository
+	subRepo            repository.StockSubscriptionRepository
+	reservationRepo    repository.StockReservationRepository
+	warehouseRepo      repository.WarehouseRepository
+	warehouseStockRepo repository.WarehouseStockRepository
+	productRepo        repository.ProductRepository
+	variantRepo        repository.ProductVariantRepository
+	producerSvc        messagebroker.ProducerService
+	reservationTTL     time.Duration
+	allocation         string
+	defaultRegion      string
 }
 
 // ------------------------ Constructor ------------------------
-func NewStockService(repo repository.StockRepository) module.StockService {
-	return &stockService{repo: repo}
+func NewStockService(
+	tx repository.Transactor,
+	repo repository.StockRepository,
+	movementRepo repository.StockMovementRepository,
+	subRepo repository.StockSubscriptionRepository,
+	reservationRepo repository.StockReservationRepository,
+	warehouseRepo repository.WarehouseRepository,
+	warehouseStockRepo repository.WarehouseStockRepository,
+	productRepo repository.ProductRepository,
+	variantRepo repository.ProductVariantRepository,
+	producerSvc messagebroker.ProducerService,
+) module.StockService {
+	return &stockService{
+		tx:                 tx,
+		repo:               repo,
+		movementRepo:       movementRepo,
+		subRepo:            subRepo,
+		reservationRepo:    reservationRepo,
+		warehouseRepo:      warehouseRepo,
+		warehouseStockRepo: warehouseStockRepo,
+		productRepo:        productRepo,
+		variantRepo:        variantRepo,
+		producerSvc:        producerSvc,
+		reservationTTL:     appcore_config.Config.StockReservationTTL,
+		allocation:         appcore_config.Config.StockAllocationStrategy,
+		defaultRegion:      appcore_config.Config.StockDefaultRegion,
+	}
 }
 
 // ------------------------ Method Basic CUD ------------------------
-func (s *stockService) Save(ctx context.Context, productID string, quantity int) error {
+func (s *stockService) Save(ctx context.Context, productID string, quantity int, actorID string) error {
 	var stock = model.Stock{
 		ProductID: productID,
 		CreatedAt: time.Now(),
@@ -45,15 +101,30 @@ func (s *stockService) Save(ctx context.Context, productID string, quantity int)
 
 	stock.SetQuantity(quantity)
 
+	// new stock lands in the seller's default warehouse
+	warehouse, err := s.defaultWarehouse(ctx, actorID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get default warehouse")
+		return ErrCreateStock
+	}
+
 	if err := s.repo.AddStock(ctx, &stock); err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("add stock")
 		return ErrCreateStock
 	}
 
+	warehouseStock := model.NewWarehouseStock(productID, warehouse.ID)
+	warehouseStock.Quantity = stock.Quantity
+	if err := s.warehouseStockRepo.AddWarehouseStock(ctx, warehouseStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add warehouse stock")
+		return ErrCreateStock
+	}
+
+	s.recordMovement(ctx, productID, stock.Quantity, model.StockRef{Reason: model.StockReasonInitial, ActorID: actorID, WarehouseID: warehouse.ID})
 	return nil
 }
 
-func (s *stockService) Update(ctx context.Context, productID string, quantity int) error {
+func (s *stockService) Update(ctx context.Context, productID string, quantity int, actorID string) error {
 	var currentStock model.Stock
 	var baseLogFields = log.Fields{
 		"product_id": productID,
@@ -61,21 +132,37 @@ func (s *stockService) Update(ctx context.Context, productID string, quantity in
 		"method":     "stock_update",
 	}
 
-	if err := s.repo.GetStockByProductID(ctx, productID, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("get stock by product id")
+	// read past the cache, the move is relative to what is on hand now
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
+		return ErrUpdateStock
+	}
+
+	// the product form only knows the total, the difference lands in the
+	// default warehouse
+	warehouseStock, err := s.warehouseStock(ctx, &currentStock, "")
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get default warehouse stock")
 		return ErrUpdateStock
 	}
 
-	currentStock.SetQuantity(quantity)
-	if err := s.repo.UpdateStock(ctx, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("update stock")
+	delta := warehouseStock.ShiftQuantity(quantity - currentStock.Quantity)
+	if err := s.moveStock(ctx, productID, warehouseStock.ID, delta); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("move stock")
 		return ErrUpdateStock
 	}
 
+	s.recordMovement(ctx, productID, delta, model.StockRef{
+		Reason:      model.StockReasonAdjustment,
+		ActorID:     actorID,
+		Note:        "quantity set from product update",
+		WarehouseID: warehouseStock.WarehouseID,
+	})
+	s.publishMoveAlerts(ctx, productID, delta, 0)
 	return nil
 }
 
-func (s *stockService) IncreaseQuantity(ctx context.Context, quantity int, productID string) error {
+func (s *stockService) IncreaseQuantity(ctx context.Context, quantity int, productID string, ref model.StockRef) error {
 	var currentStock model.Stock
 	var baseLogFields = log.Fields{
 		"product_id": productID,
@@ -83,66 +170,923 @@ func (s *stockService) IncreaseQuantity(ctx context.Context, quantity int, produ
 		"method":     "stock_increaseQuantity",
 	}
 
-	if err := s.repo.GetStockByProductID(ctx, productID, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("get stock by product id")
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
 		return ErrStockNotFound
 	}
 
-	currentStock.IncreaseQuantity(quantity)
-	currentStock.UpdatedAt = time.Now()
+	// returns go back to the warehouse they left, or the default one
+	warehouseStock, err := s.warehouseStock(ctx, &currentStock, ref.WarehouseID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse stock")
+		return ErrUpdateStock
+	}
 
-	if err := s.repo.UpdateStock(ctx, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("update stock")
+	if err := s.moveStock(ctx, productID, warehouseStock.ID, quantity); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("move stock")
 		return ErrUpdateStock
 	}
 
+	ref.WarehouseID = warehouseStock.WarehouseID
+	s.recordMovement(ctx, productID, quantity, ref)
+	s.publishMoveAlerts(ctx, productID, quantity, 0)
 	return nil
 }
 
-func (s *stockService) DecreaseQuantity(ctx context.Context, quantity int, productID string) error {
+func (s *stockService) DecreaseQuantity(ctx context.Context, quantity int, productID string, ref model.StockRef) error {
 	var currentStock model.Stock
+	var err error
 	var baseLogFields = log.Fields{
 		"product_id": productID,
 		"layer":      "stock_service",
 		"method":     "stock_decreaseQuantity",
 	}
 
-	if err := s.repo.GetStockByProductID(ctx, productID, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("get stock by product id")
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
 		return ErrStockNotFound
 	}
 
-	if err := currentStock.DecreaseQuantity(quantity); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("decrease quantity")
+	var warehouseStock *model.WarehouseStock
+	if ref.WarehouseID != "" {
+		warehouseStock, err = s.warehouseStock(ctx, &currentStock, ref.WarehouseID)
+	} else {
+		warehouseStock, err = s.allocateStock(ctx, &currentStock, quantity, "")
+	}
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse stock")
 		return ErrUpdateStock
 	}
 
-	currentStock.UpdatedAt = time.Now()
-	if err := s.repo.UpdateStock(ctx, &currentStock); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("update stock")
+	// the guards take it from the available quantity only, reserved items leave
+	// through CommitReservation
+	if err := s.moveStock(ctx, productID, warehouseStock.ID, -quantity); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("move stock")
 		return ErrUpdateStock
 	}
 
+	ref.WarehouseID = warehouseStock.WarehouseID
+	s.recordMovement(ctx, productID, -quantity, ref)
+	s.publishMoveAlerts(ctx, productID, -quantity, 0)
+	return nil
+}
+
+// Adjust applies a manual restock, write-off or correction made by the seller who owns the product
+func (s *stockService) Adjust(ctx context.Context, productID string, req *model.StockAdjustReq, userID string) (*model.Stock, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_adjust",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, invalid(err)
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return nil, err
+	}
+
+	if req.WarehouseID != "" {
+		if err := s.checkWarehouse(ctx, req.WarehouseID, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("check warehouse")
+			return nil, err
+		}
+	}
+
+	var currentStock model.Stock
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
+		return nil, ErrStockNotFound
+	}
+
+	warehouseStock, err := s.warehouseStock(ctx, &currentStock, req.WarehouseID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse stock")
+		return nil, ErrAdjustStock
+	}
+
+	// Apply only works out the change, the guarded move decides if it still fits
+	delta, err := warehouseStock.Apply(req)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("apply adjust")
+		return nil, invalid(err)
+	}
+
+	if err := s.moveStock(ctx, productID, warehouseStock.ID, delta); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("move stock")
+		if errors.Is(err, model.ErrDebtStock) {
+			return nil, invalid(err)
+		}
+		return nil, ErrAdjustStock
+	}
+
+	s.recordMovement(ctx, productID, delta, model.StockRef{
+		Reason:      req.AdjustReason(),
+		ActorID:     userID,
+		Note:        strings.TrimSpace(req.Type + " " + req.ReasonCode + " " + req.Note),
+		WarehouseID: warehouseStock.WarehouseID,
+	})
+	s.publishMoveAlerts(ctx, productID, delta, 0)
+
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("reload stock")
+		return nil, ErrStockNotFound
+	}
+
+	log.WithFields(baseLogFields).Infof("[Service]: stock adjusted %s %d (%s)", req.Type, req.Quantity, req.ReasonCode)
+	return &currentStock, nil
+}
+
+// Transfer moves available quantity between two warehouses of the seller, the
+// product totals stay the same
+func (s *stockService) Transfer(ctx context.Context, productID string, req *model.StockTransferReq, userID string) (*model.StockResp, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_transfer",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, invalid(err)
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return nil, err
+	}
+
+	for _, warehouseID := range []string{req.FromWarehouseID, req.ToWarehouseID} {
+		if err := s.checkWarehouse(ctx, warehouseID, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("check warehouse")
+			return nil, err
+		}
+	}
+
+	var currentStock model.Stock
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
+		return nil, ErrStockNotFound
+	}
+
+	from, err := s.warehouseStock(ctx, &currentStock, req.FromWarehouseID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get source warehouse stock")
+		return nil, ErrTransferStock
+	}
+	to, err := s.warehouseStock(ctx, &currentStock, req.ToWarehouseID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get target warehouse stock")
+		return nil, ErrTransferStock
+	}
+
+	// both rows move or neither does, the source only if it still has the quantity available
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.warehouseStockRepo.MoveWarehouseStock(ctx, from.ID, -req.Quantity, 0); err != nil {
+			return err
+		}
+		return s.warehouseStockRepo.MoveWarehouseStock(ctx, to.ID, req.Quantity, 0)
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("move warehouse stock")
+		if errors.Is(err, model.ErrDebtStock) {
+			return nil, invalid(err)
+		}
+		return nil, ErrTransferStock
+	}
+
+	// the pair nets to zero, so Reconcile still matches the product total
+	ref := model.StockRef{Reason: model.StockReasonTransfer, ActorID: userID, Note: req.Note}
+	ref.WarehouseID, ref.ReferenceID = from.WarehouseID, to.WarehouseID
+	s.recordMovement(ctx, productID, -req.Quantity, ref)
+	ref.WarehouseID, ref.ReferenceID = to.WarehouseID, from.WarehouseID
+	s.recordMovement(ctx, productID, req.Quantity, ref)
+
+	log.WithFields(baseLogFields).Infof("[Service]: stock transferred %d from {%s} to {%s}", req.Quantity, from.WarehouseID, to.WarehouseID)
+	return s.GetByProductID(ctx, productID)
+}
+
+// ------------------------ Method Reservation ------------------------
+// Reserve holds quantity for an unpaid order in the warehouse picked by the
+// allocation rule, it stays on hand until the reservation is committed and
+// returns to available when released
+func (s *stockService) Reserve(ctx context.Context, orderID string, productID string, quantity int, region string) (*model.StockReservation, error) {
+	var baseLogFields = log.Fields{
+		"order_id":   orderID,
+		"product_id": productID,
+		"layer":      "stock_service",
+		"method":     "stock_reserve",
+	}
+
+	// read past the cache, the guarded writes below decide, this only picks the warehouse
+	var currentStock model.Stock
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
+		return nil, ErrStockNotFound
+	}
+
+	if quantity <= 0 {
+		return nil, model.ErrQuantity
+	}
+	if currentStock.Available() < quantity {
+		return nil, model.ErrDebtStock
+	}
+
+	warehouseStock, err := s.allocateStock(ctx, &currentStock, quantity, region)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("allocate warehouse")
+		if errors.Is(err, model.ErrDebtStock) {
+			return nil, err
+		}
+		return nil, ErrReserveStock
+	}
+
+	reservation := model.NewStockReservation(orderID, productID, warehouseStock.WarehouseID, quantity, s.reservationTTL)
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.MoveStock(ctx, productID, 0, quantity); err != nil {
+			return err
+		}
+		if err := s.warehouseStockRepo.MoveWarehouseStock(ctx, warehouseStock.ID, 0, quantity); err != nil {
+			return err
+		}
+		return s.reservationRepo.AddReservation(ctx, reservation)
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("reserve")
+		if errors.Is(err, model.ErrDebtStock) {
+			return nil, err
+		}
+		return nil, ErrReserveStock
+	}
+
+	s.publishMoveAlerts(ctx, productID, 0, quantity)
+	return reservation, nil
+}
+
+// ReleaseReservation returns the held quantity of an active reservation,
+// status is RELEASED for a cancelled order or EXPIRED from the sweeper
+func (s *stockService) ReleaseReservation(ctx context.Context, orderID string, status string) error {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"status":   status,
+		"layer":    "stock_service",
+		"method":   "stock_releaseReservation",
+	}
+
+	reservation, err := s.closeReservation(ctx, orderID, status, 0)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("close reservation")
+		return err
+	}
+
+	s.publishMoveAlerts(ctx, reservation.ProductID, 0, -reservation.Quantity)
+	return nil
+}
+
+// CommitReservation turns the reservation of a paid order into a sale
+func (s *stockService) CommitReservation(ctx context.Context, orderID string, actorID string) error {
+	var baseLogFields = log.Fields{
+		"order_id": orderID,
+		"layer":    "stock_service",
+		"method":   "stock_commitReservation",
+	}
+
+	reservation, err := s.closeReservation(ctx, orderID, model.ReservationCommitted, -1)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("close reservation")
+		return err
+	}
+
+	s.recordMovement(ctx, reservation.ProductID, -reservation.Quantity, model.StockRef{
+		Reason:      model.StockReasonOrderPlaced,
+		ReferenceID: orderID,
+		ActorID:     actorID,
+		WarehouseID: reservation.WarehouseID,
+	})
+	s.publishMoveAlerts(ctx, reservation.ProductID, -reservation.Quantity, -reservation.Quantity)
+	return nil
+}
+
+func (s *stockService) GetReservationByOrderID(ctx context.Context, orderID string) (*model.StockReservation, error) {
+	var reservation model.StockReservation
+	if err := s.reservationRepo.GetReservationByOrderID(ctx, orderID, &reservation); err != nil {
+		return nil, ErrReservationNotFound
+	}
+	return &reservation, nil
+}
+
+func (s *stockService) GetExpiredReservations(ctx context.Context) ([]model.StockReservation, error) {
+	reservations, err := s.reservationRepo.GetReservationsByStatus(ctx, model.ReservationActive)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"layer":  "stock_service",
+			"method": "stock_getExpiredReservations",
+		}).Error("get active reservations")
+		return nil, ErrReservationNotFound
+	}
+
+	now := time.Now()
+	var expired []model.StockReservation
+	for _, reservation := range reservations {
+		if reservation.IsExpired(now) {
+			expired = append(expired, reservation)
+		}
+	}
+	return expired, nil
+}
+
+func (s *stockService) SetReorderThreshold(ctx context.Context, productID string, threshold int, userID string) (*model.Stock, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_setReorderThreshold",
+	}
+
+	if err := s.checkOwner(ctx, productID, userID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check owner")
+		return nil, err
+	}
+
+	var currentStock model.Stock
+	if err := s.repo.GetStockByID(ctx, productID, &currentStock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by id")
+		return nil, ErrStockNotFound
+	}
+
+	if err := currentStock.SetReorderThreshold(threshold); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("set reorder threshold")
+		return nil, invalid(err)
+	}
+
+	currentStock.UpdatedAt = time.Now()
+	if err := s.repo.SetReorderThreshold(ctx, productID, threshold); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("set stock reorder threshold")
+		return nil, ErrUpdateStock
+	}
+
+	return &currentStock, nil
+}
+
+func (s *stockService) Subscribe(ctx context.Context, productID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_subscribe",
+	}
+
+	var stock model.Stock
+	if err := s.repo.GetStockByProductID(ctx, productID, &stock); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get stock by product id")
+		return ErrStockNotFound
+	}
+
+	subs, err := s.subRepo.GetSubscriptionsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get subscriptions by product id")
+		return ErrSubscribe
+	}
+
+	for _, sub := range subs {
+		if sub.UserID != userID {
+			continue
+		}
+		if !sub.IsNotified() {
+			return ErrSubscribed
+		}
+
+		// the last alert went out, wait for the next one
+		if err := s.subRepo.SetSubscriptionNotified(ctx, sub.ID, nil); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("re-arm subscription")
+			return ErrSubscribe
+		}
+		return nil
+	}
+
+	if err := s.subRepo.AddSubscription(ctx, model.NewStockSubscription(productID, userID)); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add subscription")
+		return ErrSubscribe
+	}
+
+	return nil
+}
+
+func (s *stockService) Unsubscribe(ctx context.Context, productID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_unsubscribe",
+	}
+
+	subs, err := s.subRepo.GetSubscriptionsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get subscriptions by product id")
+		return ErrUnsubscribe
+	}
+
+	for _, sub := range subs {
+		if sub.UserID != userID {
+			continue
+		}
+		if err := s.subRepo.DeleteSubscription(ctx, sub.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("delete subscription")
+			return ErrUnsubscribe
+		}
+	}
+
+	return nil
+}
+
+// MarkNotified records that the back in stock alert of a subscription was sent
+func (s *stockService) MarkNotified(ctx context.Context, subscriptionID string) error {
+	now := time.Now()
+	if err := s.subRepo.SetSubscriptionNotified(ctx, subscriptionID, &now); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"subscription_id": subscriptionID,
+			"layer":           "stock_service",
+			"method":          "stock_markNotified",
+		}).Error("set subscription notified")
+		return ErrUpdateStock
+	}
 	return nil
 }
 
+// Delete removes the stock of a product or variant with its warehouse rows and
+// subscriptions. It joins the transaction of ctx when there is one.
 func (s *stockService) Delete(ctx context.Context, id string) error {
+	var baseLogFields = log.Fields{
+		"product_id": id,
+		"layer":      "stock_service",
+		"method":     "stock_delete",
+	}
+
+	warehouseStocks, err := s.warehouseStockRepo.GetWarehouseStocksByProductID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse stocks")
+		return ErrDeleteStock
+	}
+
+	subs, err := s.subRepo.GetSubscriptionsByProductID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get subscriptions by product id")
+		return ErrDeleteStock
+	}
+
+	err = s.tx.Transaction(ctx, func(ctx context.Context) error {
+		for _, warehouseStock := range warehouseStocks {
+			if err := s.warehouseStockRepo.DeleteWarehouseStock(ctx, warehouseStock.ID); err != nil {
+				return err
+			}
+		}
+		for _, sub := range subs {
+			if err := s.subRepo.DeleteSubscription(ctx, sub.ID); err != nil {
+				return err
+			}
+		}
+		return s.repo.DeleteStock(ctx, id)
+	})
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete stock")
+		return ErrDeleteStock
+	}
 	return nil
 }
 
 // ------------------------ Method Basic Query ------------------------
-func (s *stockService) GetAll(ctx context.Context) ([]model.Stock, error) {
+func (s *stockService) GetAll(ctx context.Context) ([]model.StockResp, error) {
 	stocks, err := s.repo.GetAllStock(ctx)
 	if err != nil {
 		return nil, ErrStockNotFound
 	}
-	return stocks, nil
+
+	stocksResp := make([]model.StockResp, 0, len(stocks))
+	for _, stock := range stocks {
+		stocksResp = append(stocksResp, *stock.ToStockResp())
+	}
+	return stocksResp, nil
 }
 
-func (s *stockService) GetByProductID(ctx context.Context, productID string) (*model.Stock, error) {
+// GetByProductID returns the totals of a product with the split per warehouse
+func (s *stockService) GetByProductID(ctx context.Context, productID string) (*model.StockResp, error) {
 	var stock model.Stock
 	if err := s.repo.GetStockByID(ctx, productID, &stock); err != nil {
 		return nil, ErrStockNotFound
 	}
-	return &stock, nil
+
+	stockResp := stock.ToStockResp()
+	warehouseStocks, err := s.warehouseStockRepo.GetWarehouseStocksByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"product_id": productID,
+			"layer":      "stock_service",
+			"method":     "stock_getByProductID",
+		}).Warn("get warehouse stocks")
+		return stockResp, nil
+	}
+
+	for _, warehouseStock := range warehouseStocks {
+		stockResp.Warehouses = append(stockResp.Warehouses, *warehouseStock.ToWarehouseStockResp())
+	}
+	return stockResp, nil
+}
+
+func (s *stockService) GetBySeller(ctx context.Context, sellerID string) ([]model.StockResp, error) {
+	var baseLogFields = log.Fields{
+		"seller_id": sellerID,
+		"layer":     "stock_service",
+		"method":    "stock_getBySeller",
+	}
+
+	products, err := s.productRepo.GetProductsBySeller(ctx, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get products by seller")
+		return nil, ErrStockNotFound
+	}
+
+	stocks := make([]model.StockResp, 0, len(products))
+	for _, product := range products {
+		stockIDs, err := s.stockIDs(ctx, product.ID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Warnf("get variants of product {%s}", product.ID)
+			continue
+		}
+
+		for _, stockID := range stockIDs {
+			var stock model.Stock
+			if err := s.repo.GetStockByProductID(ctx, stockID, &stock); err != nil {
+				log.WithError(err).WithFields(baseLogFields).Warnf("no stock for {%s}", stockID)
+				continue
+			}
+			stocks = append(stocks, *stock.ToStockResp())
+		}
+	}
+
+	return stocks, nil
+}
+
+func (s *stockService) GetMovements(ctx context.Context, productID string, userID string, role string) ([]model.StockMovement, error) {
+	var baseLogFields = log.Fields{
+		"product_id": productID,
+		"user_id":    userID,
+		"layer":      "stock_service",
+		"method":     "stock_getMovements",
+	}
+
+	if role != "ADMIN" {
+		if err := s.checkOwner(ctx, productID, userID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("check owner")
+			return nil, err
+		}
+	}
+
+	movements, err := s.movementRepo.GetMovementsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get movements by product id")
+		return nil, ErrGetMovements
+	}
+	return movements, nil
+}
+
+func (s *stockService) GetSubscriptions(ctx context.Context, productID string) ([]model.StockSubscription, error) {
+	subs, err := s.subRepo.GetSubscriptionsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"product_id": productID,
+			"layer":      "stock_service",
+			"method":     "stock_getSubscriptions",
+		}).Error("get subscriptions by product id")
+		return nil, ErrStockNotFound
+	}
+	return subs, nil
+}
+
+// ------------------------ Method Reconcile ------------------------
+// Reconcile replays the ledger of every stock and reports the product totals
+// and warehouse rows whose quantity drifted from it. With apply the ledger
+// quantity is written back, never under what is reserved. Stock without an
+// INITIAL entry predates the ledger and is skipped, its sum says nothing about
+// what is on hand.
+func (s *stockService) Reconcile(ctx context.Context, apply bool) ([]model.StockDrift, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "stock_service",
+		"method": "stock_reconcile",
+	}
+
+	stocks, err := s.repo.GetAllStock(ctx)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get all stock")
+		return nil, ErrStockNotFound
+	}
+
+	var drifts []model.StockDrift
+	for _, stock := range stocks {
+		movements, err := s.movementRepo.GetMovementsByProductID(ctx, stock.ProductID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("get movements by product id")
+			return nil, ErrGetMovements
+		}
+		if !model.HasOpening(movements) {
+			log.WithFields(baseLogFields).Warnf("stock {%s} has no opening movement, skipped", stock.ProductID)
+			continue
+		}
+
+		warehouseDrifts, err := s.reconcileWarehouses(ctx, &stock, movements, apply)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Errorf("reconcile warehouses of {%s}", stock.ProductID)
+			return drifts, ErrUpdateStock
+		}
+		drifts = append(drifts, warehouseDrifts...)
+
+		ledgerQuantity := model.SumDelta(movements)
+		if ledgerQuantity == stock.Quantity {
+			continue
+		}
+
+		drift := model.StockDrift{
+			ProductID:      stock.ProductID,
+			Quantity:       stock.Quantity,
+			Reserved:       stock.Reserved,
+			LedgerQuantity: ledgerQuantity,
+			Drift:          stock.Quantity - ledgerQuantity,
+		}
+		if apply {
+			// relative and guarded, so a reservation made since the read still holds
+			err := s.repo.MoveStock(ctx, stock.ProductID, ledgerQuantity-stock.Quantity, 0)
+			if err != nil && !errors.Is(err, model.ErrDebtStock) {
+				log.WithError(err).WithFields(baseLogFields).Error("move stock")
+				return append(drifts, drift), ErrUpdateStock
+			}
+			drift.Kept = err != nil
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+// reconcileWarehouses replays the ledger per warehouse against the rows of a
+// stock. Movements from before warehouses existed belong to the owner's default
+// warehouse, where that quantity was moved to. A row is never set below what it
+// holds reserved.
+func (s *stockService) reconcileWarehouses(ctx context.Context, stock *model.Stock, movements []model.StockMovement, apply bool) ([]model.StockDrift, error) {
+	warehouseStocks, err := s.warehouseStockRepo.GetWarehouseStocksByProductID(ctx, stock.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := make(map[string]int)
+	var defaultWarehouseID string
+	for _, movement := range movements {
+		warehouseID := movement.WarehouseID
+		if warehouseID == "" && defaultWarehouseID == "" {
+			warehouse, err := s.ownerWarehouse(ctx, stock.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			defaultWarehouseID = warehouse.ID
+		}
+		if warehouseID == "" {
+			warehouseID = defaultWarehouseID
+		}
+		ledger[warehouseID] += movement.Delta
+	}
+
+	var drifts []model.StockDrift
+	for _, warehouseStock := range warehouseStocks {
+		ledgerQuantity := ledger[warehouseStock.WarehouseID]
+		delete(ledger, warehouseStock.WarehouseID)
+		if ledgerQuantity == warehouseStock.Quantity {
+			continue
+		}
+
+		drift := model.StockDrift{
+			ProductID:      stock.ProductID,
+			WarehouseID:    warehouseStock.WarehouseID,
+			Quantity:       warehouseStock.Quantity,
+			Reserved:       warehouseStock.Reserved,
+			LedgerQuantity: ledgerQuantity,
+			Drift:          warehouseStock.Quantity - ledgerQuantity,
+		}
+		if apply {
+			err := s.warehouseStockRepo.MoveWarehouseStock(ctx, warehouseStock.ID, ledgerQuantity-warehouseStock.Quantity, 0)
+			if err != nil && !errors.Is(err, model.ErrDebtStock) {
+				return append(drifts, drift), err
+			}
+			drift.Kept = err != nil
+		}
+		drifts = append(drifts, drift)
+	}
+
+	// warehouses the ledger moved items into that have no row
+	for warehouseID, ledgerQuantity := range ledger {
+		if ledgerQuantity == 0 {
+			continue
+		}
+
+		drift := model.StockDrift{
+			ProductID:      stock.ProductID,
+			WarehouseID:    warehouseID,
+			LedgerQuantity: ledgerQuantity,
+			Drift:          -ledgerQuantity,
+		}
+		if apply && ledgerQuantity > 0 {
+			warehouseStock := model.NewWarehouseStock(stock.ProductID, warehouseID)
+			warehouseStock.Quantity = ledgerQuantity
+			if err := s.warehouseStockRepo.AddWarehouseStock(ctx, warehouseStock); err != nil {
+				return append(drifts, drift), err
+			}
+		}
+		drift.Kept = apply && ledgerQuantity < 0
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+// ------------------------ Private Method ------------------------
+// closeReservation claims an active reservation for status and gives back its
+// held quantity in the same transaction, taking it off hand as well when sign
+// is -1. Only one caller can claim it, so a reservation is never released twice
+// or both released and committed.
+func (s *stockService) closeReservation(ctx context.Context, orderID string, status string, sign int) (*model.StockReservation, error) {
+	var reservation model.StockReservation
+	if err := s.reservationRepo.GetReservationByOrderID(ctx, orderID, &reservation); err != nil {
+		return nil, ErrReservationNotFound
+	}
+	if !reservation.IsActive() {
+		return nil, ErrReservationClosed
+	}
+
+	var warehouseStock *model.WarehouseStock
+	if reservation.WarehouseID != "" {
+		var stock model.Stock
+		if err := s.repo.GetStockByID(ctx, reservation.ProductID, &stock); err != nil {
+			return nil, ErrStockNotFound
+		}
+
+		var err error
+		if warehouseStock, err = s.reservedWarehouseStock(ctx, &stock, &reservation); err != nil {
+			return nil, ErrUpdateStock
+		}
+	}
+
+	quantity := sign * reservation.Quantity
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		closed, err := s.reservationRepo.CloseReservation(ctx, reservation.ID, status)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return ErrReservationClosed
+		}
+
+		if err := s.repo.MoveStock(ctx, reservation.ProductID, quantity, -reservation.Quantity); err != nil {
+			return err
+		}
+		if warehouseStock != nil {
+			return s.warehouseStockRepo.MoveWarehouseStock(ctx, warehouseStock.ID, quantity, -reservation.Quantity)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrReservationClosed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrUpdateStock
+	}
+
+	reservation.SetStatus(status)
+	return &reservation, nil
+}
+
+// invalid marks err as something the stock request got wrong
+func invalid(err error) error {
+	return fmt.Errorf("%w: %w", model.ErrStockRequest, err)
+}
+
+func (s *stockService) checkOwner(ctx context.Context, productID string, userID string) error {
+	product, _, err := s.product(ctx, productID)
+	if err != nil {
+		return ErrStockNotFound
+	}
+
+	if product.CreatedBy != userID {
+		return ErrPermission
+	}
+	return nil
+}
+
+// product returns the product a stock belongs to. The stock of a variant is
+// keyed by the variant ID, which is returned as well.
+func (s *stockService) product(ctx context.Context, stockID string) (*model.Product, string, error) {
+	var product model.Product
+	if err := s.productRepo.GetProductByID(ctx, stockID, &product); err == nil {
+		return &product, "", nil
+	}
+
+	var variant model.ProductVariant
+	if err := s.variantRepo.GetVariantByID(ctx, stockID, &variant); err != nil {
+		return nil, "", err
+	}
+	if err := s.productRepo.GetProductByID(ctx, variant.ProductID, &product); err != nil {
+		return nil, "", err
+	}
+	return &product, variant.ID, nil
+}
+
+// stockIDs lists the stock keys of a product, one per variant or the product itself
+func (s *stockService) stockIDs(ctx context.Context, productID string) ([]string, error) {
+	variants, err := s.variantRepo.GetVariantsByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		return []string{productID}, nil
+	}
+
+	stockIDs := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		stockIDs = append(stockIDs, variant.ID)
+	}
+	return stockIDs, nil
+}
+
+// recordMovement appends to the ledger after the stock row is written. A failed
+// insert is only logged, the drift it leaves is what Reconcile reports.
+func (s *stockService) recordMovement(ctx context.Context, productID string, delta int, ref model.StockRef) {
+	// the opening entry is kept at zero too, Reconcile only trusts a ledger that has one
+	if delta == 0 && ref.Reason != model.StockReasonInitial {
+		return
+	}
+
+	movement := ref.ToStockMovement(productID, delta)
+	if err := s.movementRepo.AddMovement(ctx, movement); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"product_id": productID,
+			"delta":      delta,
+			"reason":     ref.Reason,
+			"layer":      "stock_service",
+			"method":     "stock_recordMovement",
+		}).Error("add stock movement")
+	}
+}
+
+// publishMoveAlerts reloads a stock after a guarded move of quantity and
+// reserved and publishes the alerts it crossed
+func (s *stockService) publishMoveAlerts(ctx context.Context, productID string, quantity int, reserved int) {
+	var stock model.Stock
+	if err := s.repo.GetStockByID(ctx, productID, &stock); err != nil {
+		return
+	}
+	s.publishAlerts(ctx, &stock, stock.Available()-quantity+reserved)
+}
+
+// publishAlerts emits stock.low when the quantity crosses the reorder threshold
+// and stock.back_in_stock when an empty stock is refilled. The stock change is
+// already saved, so a failed publish is only logged.
+func (s *stockService) publishAlerts(ctx context.Context, stock *model.Stock, beforeAvailable int) {
+	var routingKey string
+	switch {
+	case stock.CrossedReorderThreshold(beforeAvailable):
+		routingKey = "stock.low"
+	case stock.CameBackInStock(beforeAvailable):
+		routingKey = "stock.back_in_stock"
+	default:
+		return
+	}
+
+	var baseLogFields = log.Fields{
+		"product_id":  stock.ProductID,
+		"routing_key": routingKey,
+		"layer":       "stock_service",
+		"method":      "stock_publishAlerts",
+	}
+
+	alert := stock.ToStockAlert()
+	if product, variantID, err := s.product(ctx, stock.ProductID); err == nil && variantID != "" {
+		alert.ProductID, alert.VariantID = product.ID, variantID
+	}
+
+	bodyByte, err := json.Marshal(alert)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("json marshal")
+		return
+	}
+
+	mqConf := &model.MQConfig{
+		ExchangeName: messagebroker.NotificationExchangeName,
+		ExchangeType: messagebroker.NotificationExchangeType,
+		QueueName:    messagebroker.NotificationQueueName,
+		RoutingKey:   routingKey,
+	}
+	if err := s.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+	}
 }
@@ -0,0 +1,293 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantity     int
+		wantErr      error
+		wantReserved int
+	}{
+		{name: "holds the quantity", quantity: 3, wantReserved: 3},
+		{name: "all that is available", quantity: 10, wantReserved: 10},
+		{name: "more than available", quantity: 11, wantErr: model.ErrDebtStock},
+		{name: "nothing", quantity: 0, wantErr: model.ErrQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newStockEnv(10)
+
+			reservation, err := s.Reserve(context.Background(), "o1", "p1", tt.quantity, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if store.stock.Quantity != 10 || store.stock.Reserved != tt.wantReserved {
+				t.Errorf("stock = %d reserved %d, want 10 reserved %d", store.stock.Quantity, store.stock.Reserved, tt.wantReserved)
+			}
+			if row := store.rows["ws1"]; row.Reserved != tt.wantReserved {
+				t.Errorf("warehouse reserved = %d, want %d", row.Reserved, tt.wantReserved)
+			}
+			if tt.wantErr == nil && (!reservation.IsActive() || reservation.WarehouseID != "wh1") {
+				t.Errorf("reservation = %+v, want active in wh1", reservation)
+			}
+			if tt.wantErr != nil && len(store.reservations) != 0 {
+				t.Errorf("reservations = %d, want none", len(store.reservations))
+			}
+		})
+	}
+}
+
+// Every reservation is claimed once, by a release, the sweeper or a commit,
+// whatever comes after finds it closed and moves nothing.
+func TestCloseReservation(t *testing.T) {
+	tests := []struct {
+		name         string
+		closes       []string
+		wantErr      error
+		wantStatus   string
+		wantQuantity int
+	}{
+		{name: "released", closes: []string{model.ReservationReleased}, wantStatus: model.ReservationReleased, wantQuantity: 10},
+		{name: "expired", closes: []string{model.ReservationExpired}, wantStatus: model.ReservationExpired, wantQuantity: 10},
+		{name: "committed", closes: []string{model.ReservationCommitted}, wantStatus: model.ReservationCommitted, wantQuantity: 7},
+		{name: "released twice", closes: []string{model.ReservationReleased, model.ReservationReleased}, wantErr: ErrReservationClosed, wantStatus: model.ReservationReleased, wantQuantity: 10},
+		{name: "expired then paid", closes: []string{model.ReservationExpired, model.ReservationCommitted}, wantErr: ErrReservationClosed, wantStatus: model.ReservationExpired, wantQuantity: 10},
+		{name: "paid then expired", closes: []string{model.ReservationCommitted, model.ReservationExpired}, wantErr: ErrReservationClosed, wantStatus: model.ReservationCommitted, wantQuantity: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, store := newStockEnv(10)
+			if _, err := s.Reserve(ctx, "o1", "p1", 3, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			for _, status := range tt.closes {
+				if status == model.ReservationCommitted {
+					err = s.CommitReservation(ctx, "o1", "u1")
+				} else {
+					err = s.ReleaseReservation(ctx, "o1", status)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			reservation, err := s.GetReservationByOrderID(ctx, "o1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reservation.Status != tt.wantStatus {
+				t.Errorf("reservation status = %s, want %s", reservation.Status, tt.wantStatus)
+			}
+			row := store.rows["ws1"]
+			if store.stock.Quantity != tt.wantQuantity || store.stock.Reserved != 0 || row.Quantity != tt.wantQuantity || row.Reserved != 0 {
+				t.Errorf("stock = %d reserved %d, warehouse = %d reserved %d, want %d with nothing reserved",
+					store.stock.Quantity, store.stock.Reserved, row.Quantity, row.Reserved, tt.wantQuantity)
+			}
+			if sold := 10 - tt.wantQuantity; model.SumDelta(store.movements) != -sold {
+				t.Errorf("ledger = %d, want %d", model.SumDelta(store.movements), -sold)
+			}
+		})
+	}
+}
+
+func TestGetExpiredReservations(t *testing.T) {
+	s, store := newStockEnv(10)
+	past := time.Now().Add(-time.Minute)
+	store.reservations = map[string]model.StockReservation{
+		"r1": {ID: "r1", OrderID: "o1", Status: model.ReservationActive, ExpiresAt: past},
+		"r2": {ID: "r2", OrderID: "o2", Status: model.ReservationActive, ExpiresAt: time.Now().Add(time.Minute)},
+		"r3": {ID: "r3", OrderID: "o3", Status: model.ReservationReleased, ExpiresAt: past},
+		"r4": {ID: "r4", OrderID: "o4", Status: model.ReservationCommitted, ExpiresAt: past},
+	}
+
+	expired, err := s.GetExpiredReservations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != "r1" {
+		t.Errorf("expired = %+v, want only r1", expired)
+	}
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes embed the interface they stand in for, a method the tests don't
+// expect the service to call panics. They share one stockStore, the way the
+// repositories share a database.
+
+// stockStore is product p1 with every item in warehouse wh1
+type stockStore struct {
+	stock        model.Stock
+	rows         map[string]model.WarehouseStock
+	reservations map[string]model.StockReservation
+	movements    []model.StockMovement
+}
+
+func newStockEnv(quantity int) (*stockService, *stockStore) {
+	store := &stockStore{
+		stock:        model.Stock{ProductID: "p1", Quantity: quantity},
+		rows:         map[string]model.WarehouseStock{"ws1": {ID: "ws1", ProductID: "p1", WarehouseID: "wh1", Quantity: quantity}},
+		reservations: make(map[string]model.StockReservation),
+	}
+	return &stockService{
+		tx:                 &fakeTx{store: store},
+		repo:               &fakeStockRepo{store: store},
+		movementRepo:       &fakeMovementRepo{store: store},
+		reservationRepo:    &fakeReservationRepo{store: store},
+		warehouseStockRepo: &fakeWarehouseStockRepo{store: store},
+		productRepo:        &fakeProductRepo{},
+		producerSvc:        &fakeProducer{},
+		reservationTTL:     time.Minute,
+		allocation:         model.AllocationMostStock,
+	}, store
+}
+
+// move applies a guarded move like stockGuards, nothing is left reserved
+// beyond what is on hand
+func move(quantity *int, reserved *int, dq int, dr int) error {
+	if *quantity+dq < *reserved+dr || *reserved+dr < 0 {
+		return model.ErrDebtStock
+	}
+	*quantity += dq
+	*reserved += dr
+	return nil
+}
+
+// fakeTx rolls the store back when fn fails
+type fakeTx struct {
+	store *stockStore
+}
+
+func (tx *fakeTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := *tx.store
+	saved.rows = maps.Clone(tx.store.rows)
+	saved.reservations = maps.Clone(tx.store.reservations)
+	if err := fn(ctx); err != nil {
+		*tx.store = saved
+		return err
+	}
+	return nil
+}
+
+type fakeStockRepo struct {
+	repository.StockRepository
+	store *stockStore
+}
+
+func (r *fakeStockRepo) MoveStock(ctx context.Context, productID string, quantity int, reserved int) error {
+	return move(&r.store.stock.Quantity, &r.store.stock.Reserved, quantity, reserved)
+}
+
+func (r *fakeStockRepo) GetStockByID(ctx context.Context, productID string, stock *model.Stock) error {
+	if productID != r.store.stock.ProductID {
+		return errors.New("not found")
+	}
+	*stock = r.store.stock
+	return nil
+}
+
+type fakeWarehouseStockRepo struct {
+	repository.WarehouseStockRepository
+	store *stockStore
+}
+
+func (r *fakeWarehouseStockRepo) MoveWarehouseStock(ctx context.Context, id string, quantity int, reserved int) error {
+	row := r.store.rows[id]
+	if err := move(&row.Quantity, &row.Reserved, quantity, reserved); err != nil {
+		return err
+	}
+	r.store.rows[id] = row
+	return nil
+}
+
+func (r *fakeWarehouseStockRepo) GetWarehouseStocksByProductID(ctx context.Context, productID string) ([]model.WarehouseStock, error) {
+	var rows []model.WarehouseStock
+	for _, row := range r.store.rows {
+		if row.ProductID == productID {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type fakeReservationRepo struct {
+	repository.StockReservationRepository
+	store *stockStore
+}
+
+func (r *fakeReservationRepo) AddReservation(ctx context.Context, res *model.StockReservation) error {
+	r.store.reservations[res.ID] = *res
+	return nil
+}
+
+func (r *fakeReservationRepo) CloseReservation(ctx context.Context, id string, status string) (bool, error) {
+	res, ok := r.store.reservations[id]
+	if !ok || !res.IsActive() {
+		return false, nil
+	}
+	res.SetStatus(status)
+	r.store.reservations[id] = res
+	return true, nil
+}
+
+func (r *fakeReservationRepo) GetReservationByOrderID(ctx context.Context, orderID string, res *model.StockReservation) error {
+	for _, found := range r.store.reservations {
+		if found.OrderID == orderID {
+			*res = found
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (r *fakeReservationRepo) GetReservationsByStatus(ctx context.Context, status string) ([]model.StockReservation, error) {
+	var reservations []model.StockReservation
+	for _, res := range r.store.reservations {
+		if res.Status == status {
+			reservations = append(reservations, res)
+		}
+	}
+	return reservations, nil
+}
+
+type fakeMovementRepo struct {
+	repository.StockMovementRepository
+	store *stockStore
+}
+
+func (r *fakeMovementRepo) AddMovement(ctx context.Context, m *model.StockMovement) error {
+	r.store.movements = append(r.store.movements, *m)
+	return nil
+}
+
+// fakeProductRepo knows every product, sold by seller s1
+type fakeProductRepo struct {
+	repository.ProductRepository
+}
+
+func (r *fakeProductRepo) GetProductByID(ctx context.Context, id string, p *model.Product) error {
+	*p = model.Product{ID: id, CreatedBy: "s1"}
+	return nil
+}
+
+type fakeProducer struct {
+	published int
+}
+
+func (p *fakeProducer) Publishing(ctx context.Context, mqConf *model.MQConfig, body []byte) error {
+	p.published++
+	return nil
+}
@@ -0,0 +1,194 @@
+package stock
+
+import (
+	"context"
+	"go-rebuild/internal/model"
+)
+
+// ------------------------ Private Method Warehouse ------------------------
+// defaultWarehouse returns the seller's default warehouse, a seller without any
+// warehouse gets one on first use
+func (s *stockService) defaultWarehouse(ctx context.Context, sellerID string) (*model.Warehouse, error) {
+	warehouses, err := s.warehouseRepo.GetWarehousesBySeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range warehouses {
+		if warehouses[i].IsDefault {
+			return &warehouses[i], nil
+		}
+	}
+	if len(warehouses) > 0 {
+		return &warehouses[0], nil
+	}
+
+	warehouse := model.NewDefaultWarehouse(sellerID, s.defaultRegion)
+	if err := s.warehouseRepo.AddWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+func (s *stockService) checkWarehouse(ctx context.Context, warehouseID string, userID string) error {
+	var warehouse model.Warehouse
+	if err := s.warehouseRepo.GetWarehouseByID(ctx, warehouseID, &warehouse); err != nil {
+		return ErrWarehouseNotFound
+	}
+
+	if warehouse.SellerID != userID {
+		return ErrPermission
+	}
+	return nil
+}
+
+// warehouseStocks loads the split of a stock per warehouse. Quantity no
+// warehouse accounts for, stock created before warehouses existed, is moved
+// into the owner's default warehouse so the split always adds up to the total.
+func (s *stockService) warehouseStocks(ctx context.Context, stock *model.Stock) ([]model.WarehouseStock, error) {
+	warehouseStocks, err := s.warehouseStockRepo.GetWarehouseStocksByProductID(ctx, stock.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := 0
+	for _, warehouseStock := range warehouseStocks {
+		assigned += warehouseStock.Quantity
+	}
+	if assigned >= stock.Quantity {
+		return warehouseStocks, nil
+	}
+
+	warehouse, err := s.ownerWarehouse(ctx, stock.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if i := findWarehouseStock(warehouseStocks, warehouse.ID); i >= 0 {
+		if err := s.warehouseStockRepo.MoveWarehouseStock(ctx, warehouseStocks[i].ID, stock.Quantity-assigned, 0); err != nil {
+			return nil, err
+		}
+		warehouseStocks[i].IncreaseQuantity(stock.Quantity - assigned)
+		return warehouseStocks, nil
+	}
+
+	warehouseStock := model.NewWarehouseStock(stock.ProductID, warehouse.ID)
+	warehouseStock.Quantity = stock.Quantity - assigned
+	if err := s.warehouseStockRepo.AddWarehouseStock(ctx, warehouseStock); err != nil {
+		return nil, err
+	}
+	return append(warehouseStocks, *warehouseStock), nil
+}
+
+// warehouseStock returns the row of one warehouse, an empty id means the owner's
+// default warehouse. A warehouse that never held the product gets an empty row.
+func (s *stockService) warehouseStock(ctx context.Context, stock *model.Stock, warehouseID string) (*model.WarehouseStock, error) {
+	warehouseStocks, err := s.warehouseStocks(ctx, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	if warehouseID == "" {
+		warehouse, err := s.ownerWarehouse(ctx, stock.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		warehouseID = warehouse.ID
+	}
+
+	if i := findWarehouseStock(warehouseStocks, warehouseID); i >= 0 {
+		return &warehouseStocks[i], nil
+	}
+
+	warehouseStock := model.NewWarehouseStock(stock.ProductID, warehouseID)
+	if err := s.warehouseStockRepo.AddWarehouseStock(ctx, warehouseStock); err != nil {
+		return nil, err
+	}
+	return warehouseStock, nil
+}
+
+// reservedWarehouseStock returns the row a reservation holds items in, nil for
+// reservations made before warehouses existed which only touch the totals
+func (s *stockService) reservedWarehouseStock(ctx context.Context, stock *model.Stock, reservation *model.StockReservation) (*model.WarehouseStock, error) {
+	if reservation.WarehouseID == "" {
+		return nil, nil
+	}
+	return s.warehouseStock(ctx, stock, reservation.WarehouseID)
+}
+
+// allocateStock picks the warehouse that ships an order line. A line is never
+// split, so only warehouses able to cover the whole quantity qualify. MOST_STOCK
+// takes the one with the most available, NEAREST prefers the buyer's region (or
+// STOCK_DEFAULT_REGION) and falls back to most stock when none there can cover it.
+func (s *stockService) allocateStock(ctx context.Context, stock *model.Stock, quantity int, region string) (*model.WarehouseStock, error) {
+	warehouseStocks, err := s.warehouseStocks(ctx, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	if region == "" {
+		region = s.defaultRegion
+	}
+
+	var best, bestNear *model.WarehouseStock
+	for i := range warehouseStocks {
+		candidate := &warehouseStocks[i]
+		if candidate.Available() < quantity {
+			continue
+		}
+		if best == nil || candidate.Available() > best.Available() {
+			best = candidate
+		}
+
+		if s.allocation != model.AllocationNearest || region == "" {
+			continue
+		}
+		var warehouse model.Warehouse
+		if err := s.warehouseRepo.GetWarehouseByID(ctx, candidate.WarehouseID, &warehouse); err != nil {
+			continue
+		}
+		if warehouse.Region == region && (bestNear == nil || candidate.Available() > bestNear.Available()) {
+			bestNear = candidate
+		}
+	}
+
+	if bestNear != nil {
+		return bestNear, nil
+	}
+	if best == nil {
+		return nil, model.ErrDebtStock
+	}
+	return best, nil
+}
+
+// moveStock moves the product total and one of its warehouse rows by quantity
+// in one transaction. Both writes are guarded and relative, so neither goes
+// under what is reserved nor undoes a move made since the row was read.
+func (s *stockService) moveStock(ctx context.Context, productID string, warehouseStockID string, quantity int) error {
+	if quantity == 0 {
+		return nil
+	}
+	return s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.warehouseStockRepo.MoveWarehouseStock(ctx, warehouseStockID, quantity, 0); err != nil {
+			return err
+		}
+		return s.repo.MoveStock(ctx, productID, quantity, 0)
+	})
+}
+
+func (s *stockService) ownerWarehouse(ctx context.Context, stockID string) (*model.Warehouse, error) {
+	product, _, err := s.product(ctx, stockID)
+	if err != nil {
+		return nil, err
+	}
+	return s.defaultWarehouse(ctx, product.CreatedBy)
+}
+
+func findWarehouseStock(warehouseStocks []model.WarehouseStock, warehouseID string) int {
+	for i := range warehouseStocks {
+		if warehouseStocks[i].WarehouseID == warehouseID {
+			return i
+		}
+	}
+	return -1
+}
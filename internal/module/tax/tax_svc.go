@@ -0,0 +1,120 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateTaxRule   = errors.New("fail to create tax rule")
+	ErrUpdateTaxRule   = errors.New("fail to update tax rule")
+	ErrDeleteTaxRule   = errors.New("fail to delete tax rule")
+	ErrGetTaxRule      = errors.New("fail to get tax rules")
+	ErrTaxRuleNotFound = errors.New("tax rule not found")
+	ErrCategoryMissing = errors.New("tax rule category not found")
+)
+
+type taxRuleService struct {
+	ruleRepo     repository.TaxRuleRepository
+	categoryRepo repository.CategoryRepository
+}
+
+// ------------------------ Constructor ------------------------
+func NewTaxRuleService(ruleRepo repository.TaxRuleRepository, categoryRepo repository.CategoryRepository) module.TaxRuleService {
+	return &taxRuleService{
+		ruleRepo:     ruleRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (s *taxRuleService) Save(ctx context.Context, req *model.TaxRuleReq) (*model.TaxRule, error) {
+	var baseLogFields = log.Fields{
+		"layer":  "tax_service",
+		"method": "taxRule_save",
+	}
+
+	if err := s.verify(ctx, req); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	rule := req.ToTaxRule()
+	if err := s.ruleRepo.AddTaxRule(ctx, rule); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add tax rule")
+		return nil, ErrCreateTaxRule
+	}
+	return rule, nil
+}
+
+func (s *taxRuleService) Update(ctx context.Context, req *model.TaxRuleReq, id string) (*model.TaxRule, error) {
+	var baseLogFields = log.Fields{
+		"tax_rule_id": id,
+		"layer":       "tax_service",
+		"method":      "taxRule_update",
+	}
+
+	if err := s.verify(ctx, req); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	var rule model.TaxRule
+	if err := s.ruleRepo.GetTaxRuleByID(ctx, id, &rule); err != nil {
+		return nil, ErrTaxRuleNotFound
+	}
+
+	rule.Apply(req)
+	if err := s.ruleRepo.UpdateTaxRule(ctx, &rule); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update tax rule")
+		return nil, ErrUpdateTaxRule
+	}
+	return &rule, nil
+}
+
+func (s *taxRuleService) Delete(ctx context.Context, id string) error {
+	var rule model.TaxRule
+	if err := s.ruleRepo.GetTaxRuleByID(ctx, id, &rule); err != nil {
+		return ErrTaxRuleNotFound
+	}
+
+	if err := s.ruleRepo.DeleteTaxRule(ctx, id); err != nil {
+		log.WithError(err).WithField("tax_rule_id", id).Error("[Service]: delete tax rule")
+		return ErrDeleteTaxRule
+	}
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *taxRuleService) GetAll(ctx context.Context) ([]model.TaxRule, error) {
+	rules, err := s.ruleRepo.GetAllTaxRule(ctx)
+	if err != nil {
+		log.WithError(err).Error("[Service]: get all tax rule")
+		return nil, ErrGetTaxRule
+	}
+	if rules == nil {
+		rules = make([]model.TaxRule, 0)
+	}
+	return rules, nil
+}
+
+// ------------------------ Private Method ------------------------
+func (s *taxRuleService) verify(ctx context.Context, req *model.TaxRuleReq) error {
+	if err := req.Verify(); err != nil {
+		return err
+	}
+
+	if req.CategoryID != "" {
+		var category model.Category
+		if err := s.categoryRepo.GetCategoryByID(ctx, req.CategoryID, &category); err != nil {
+			return ErrCategoryMissing
+		}
+	}
+	return nil
+}
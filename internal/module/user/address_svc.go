@@ -0,0 +1,205 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateAddress   = errors.New("fail to create address")
+	ErrUpdateAddress   = errors.New("fail to update address")
+	ErrDeleteAddress   = errors.New("fail to delete address")
+	ErrAddressNotFound = errors.New("address not found")
+	ErrNoAddress       = errors.New("a shipping address is required, add one to the address book")
+	ErrAddressOwner    = errors.New("no permission to use this address")
+)
+
+type addressService struct {
+	addressRepo repository.AddressRepository
+}
+
+// ------------------------ Constructor ------------------------
+func NewAddressService(addressRepo repository.AddressRepository) module.AddressService {
+	return &addressService{addressRepo: addressRepo}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Save adds an address to the user's book, the first one is always the default
+func (s *addressService) Save(ctx context.Context, req *model.AddressReq, userID string) (*model.Address, error) {
+	var baseLogFields = log.Fields{
+		"user_id": userID,
+		"layer":   "address_service",
+		"method":  "address_save",
+	}
+
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get addresses by user id")
+		return nil, ErrCreateAddress
+	}
+
+	address := req.ToAddress(userID)
+	if len(addresses) == 0 {
+		address.IsDefault = true
+	}
+
+	if err := s.addressRepo.AddAddress(ctx, address); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add address")
+		return nil, ErrCreateAddress
+	}
+
+	if address.IsDefault {
+		s.unsetDefault(ctx, addresses, address.ID)
+	}
+	return address, nil
+}
+
+func (s *addressService) Update(ctx context.Context, req *model.AddressReq, id string, userID string) (*model.Address, error) {
+	var baseLogFields = log.Fields{
+		"address_id": id,
+		"user_id":    userID,
+		"layer":      "address_service",
+		"method":     "address_update",
+	}
+
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	address, err := s.ownAddress(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	wasDefault := address.IsDefault
+	address.Apply(req)
+	if err := s.addressRepo.UpdateAddress(ctx, address); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update address")
+		return nil, ErrUpdateAddress
+	}
+
+	if address.IsDefault && !wasDefault {
+		addresses, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Warn("get addresses by user id")
+			return address, nil
+		}
+		s.unsetDefault(ctx, addresses, address.ID)
+	}
+	return address, nil
+}
+
+// Delete removes an address, when it was the default the newest one left takes over
+func (s *addressService) Delete(ctx context.Context, id string, userID string) error {
+	var baseLogFields = log.Fields{
+		"address_id": id,
+		"user_id":    userID,
+		"layer":      "address_service",
+		"method":     "address_delete",
+	}
+
+	address, err := s.ownAddress(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.addressRepo.DeleteAddress(ctx, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete address")
+		return ErrDeleteAddress
+	}
+
+	if !address.IsDefault {
+		return nil
+	}
+
+	addresses, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+	if err != nil || len(addresses) == 0 {
+		return nil
+	}
+
+	newest := addresses[0]
+	for _, a := range addresses[1:] {
+		if a.CreatedAt.After(newest.CreatedAt) {
+			newest = a
+		}
+	}
+	newest.IsDefault = true
+	if err := s.addressRepo.UpdateAddress(ctx, &newest); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("set default address")
+	}
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *addressService) GetByUserID(ctx context.Context, userID string) ([]model.Address, error) {
+	addresses, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"user_id": userID,
+			"layer":   "address_service",
+			"method":  "address_getByUserID",
+		}).Error("get addresses by user id")
+		return nil, ErrAddressNotFound
+	}
+	return addresses, nil
+}
+
+// GetForOrder picks where an order ships, the given address of the user or
+// their default one when id is empty
+func (s *addressService) GetForOrder(ctx context.Context, userID string, id string) (*model.Address, error) {
+	if id != "" {
+		return s.ownAddress(ctx, id, userID)
+	}
+
+	addresses, err := s.addressRepo.GetAddressesByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+	for _, a := range addresses {
+		if a.IsDefault {
+			return &a, nil
+		}
+	}
+	return nil, ErrNoAddress
+}
+
+// ------------------------ Private Method ------------------------
+func (s *addressService) ownAddress(ctx context.Context, id string, userID string) (*model.Address, error) {
+	var address model.Address
+	if err := s.addressRepo.GetAddressByID(ctx, id, &address); err != nil {
+		return nil, ErrAddressNotFound
+	}
+
+	if address.UserID != userID {
+		return nil, ErrAddressOwner
+	}
+	return &address, nil
+}
+
+// unsetDefault keeps a single default address per user
+func (s *addressService) unsetDefault(ctx context.Context, addresses []model.Address, defaultID string) {
+	for _, address := range addresses {
+		if address.ID == defaultID || !address.IsDefault {
+			continue
+		}
+
+		address.IsDefault = false
+		if err := s.addressRepo.UpdateAddress(ctx, &address); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"address_id": address.ID,
+				"layer":      "address_service",
+				"method":     "address_unsetDefault",
+			}).Warn("unset default address")
+		}
+	}
+}
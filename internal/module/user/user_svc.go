@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	appcore_config "go-rebuild/cmd/go-rebuild/config"
 	messagebroker "go-rebuild/internal/message_broker"
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
@@ -29,14 +30,24 @@ var (
 
 type userService struct {
 	userRepo    repository.UserRepository
+	tokenRepo   repository.TokenRepository
 	producerSvc messagebroker.ProducerService
+
+	// email verification
+	secretKey      string
+	verifyTTL      time.Duration
+	resendInterval time.Duration
 }
 
 // ------------------------ Constructor ------------------------
-func NewUserService(userRepo repository.UserRepository, producerSvc messagebroker.ProducerService) module.UserService {
+func NewUserService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, producerSvc messagebroker.ProducerService) module.UserService {
 	return &userService{
-		userRepo:    userRepo,
-		producerSvc: producerSvc,
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		producerSvc:    producerSvc,
+		secretKey:      appcore_config.Config.SecretKey,
+		verifyTTL:      appcore_config.Config.EmailVerificationTTL,
+		resendInterval: appcore_config.Config.EmailVerificationResendInterval,
 	}
 }
 
@@ -54,6 +65,9 @@ func (us *userService) Save(ctx context.Context, user *model.User) error {
 		return ErrVerifyUser
 	}
 
+	// verified only through the link sent to the email
+	user.EmailVerified = false
+	user.EmailVerifiedAt = nil
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = user.CreatedAt
 	if err := user.SetPassword(user.Password); err != nil {
@@ -99,6 +113,7 @@ func (us *userService) Update(ctx context.Context, req *model.User, id string) e
 		return ErrUserNotFound
 	}
 
+	emailChanged := req.Email != "" && req.Email != currentUser.Email
 	currentUser.SetDefaultNotNilField(req)
 	if err := us.userRepo.UpdateUser(ctx, &currentUser, id); err != nil {
 		log.WithError(err).WithFields(baseLogFields).Error("update user")
@@ -112,10 +127,17 @@ func (us *userService) Update(ctx context.Context, req *model.User, id string) e
 		return ErrMarShal
 	}
 
-	mqConf := &model.MQConfig{ExchangeName: messagebroker.UserExchangeName, ExchangeType: messagebroker.UserExchangeType, QueueName: messagebroker.UserQueueName, RoutingKey: "user.update"}
-	if err := us.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
-		log.WithError(err).WithFields(baseLogFields).Error("publishing")
-		return ErrSendEmailMessage
+	routingKeys := []string{"user.update"}
+	if emailChanged {
+		// the new email is unverified, a link goes to it
+		routingKeys = append(routingKeys, "user.verify_email")
+	}
+	for _, routingKey := range routingKeys {
+		mqConf := &model.MQConfig{ExchangeName: messagebroker.UserExchangeName, ExchangeType: messagebroker.UserExchangeType, QueueName: messagebroker.UserQueueName, RoutingKey: routingKey}
+		if err := us.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("publishing")
+			return ErrSendEmailMessage
+		}
 	}
 
 	return nil
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	messagebroker "go-rebuild/internal/message_broker"
+	"go-rebuild/internal/model"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// verificationPurpose keeps a verification signature from being valid for
+// anything else signed with the same secret
+const verificationPurpose = "email-verification."
+
+// NewVerificationToken signs a verification link token for the user's email
+func (us *userService) NewVerificationToken(user *model.User) (string, error) {
+	if us.secretKey == "" {
+		return "", model.ErrVerificationSecret
+	}
+
+	payload, err := json.Marshal(model.NewEmailVerification(user, us.verifyTTL))
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + us.sign(encoded), nil
+}
+
+// VerifyEmail marks the email of the token verified, a token is refused once
+// the email is verified or when the user changed their email since
+func (us *userService) VerifyEmail(ctx context.Context, token string) error {
+	var baseLogFields = log.Fields{
+		"layer":  "user_service",
+		"method": "user_verifyEmail",
+	}
+
+	verification, err := us.parseVerificationToken(token)
+	if err != nil {
+		return err
+	}
+	baseLogFields["user_id"] = verification.UserID
+
+	var user model.User
+	if err := us.userRepo.GetUserByID(ctx, verification.UserID, &user); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get user by id")
+		return model.ErrVerificationToken
+	}
+	if !strings.EqualFold(user.Email, verification.Email) {
+		return model.ErrVerificationToken
+	}
+	if user.EmailVerified {
+		return model.ErrEmailVerified
+	}
+
+	user.MarkEmailVerified()
+	if err := us.userRepo.UpdateUser(ctx, &user, user.ID); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update user")
+		return ErrUpdateUser
+	}
+
+	log.Printf("[Service]: user {%s} email verified success:", user.ID)
+	return nil
+}
+
+// ResendVerification sends another link at most once per resend interval.
+// Nothing is reported back, not an unknown or verified email, a link sent
+// too recently nor a failure, so the answer never tells which emails have an
+// account. Failures are only logged.
+func (us *userService) ResendVerification(ctx context.Context, email string) {
+	var baseLogFields = log.Fields{
+		"user_email": email,
+		"layer":      "user_service",
+		"method":     "user_resendVerification",
+	}
+
+	var user model.User
+	if err := us.userRepo.GetUserByEmail(ctx, email, &user); err != nil || user.EmailVerified {
+		return
+	}
+
+	sent, err := us.tokenRepo.IsVerificationSent(ctx, user.ID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("check verification sent")
+		return
+	}
+	if sent {
+		return
+	}
+
+	bodyByte, err := json.Marshal(user)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("marshal")
+		return
+	}
+
+	mqConf := &model.MQConfig{ExchangeName: messagebroker.UserExchangeName, ExchangeType: messagebroker.UserExchangeType, QueueName: messagebroker.UserQueueName, RoutingKey: "user.verify_email"}
+	if err := us.producerSvc.Publishing(ctx, mqConf, bodyByte); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("publishing")
+		return
+	}
+
+	if err := us.tokenRepo.SetVerificationSent(ctx, user.ID, us.resendInterval); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Warn("set verification sent")
+	}
+}
+
+// ------------------------ Private Method ------------------------
+func (us *userService) sign(encoded string) string {
+	mac := hmac.New(sha256.New, []byte(us.secretKey))
+	mac.Write([]byte(verificationPurpose + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (us *userService) parseVerificationToken(token string) (*model.EmailVerification, error) {
+	if us.secretKey == "" {
+		return nil, model.ErrVerificationSecret
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(us.sign(encoded))) {
+		return nil, model.ErrVerificationToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, model.ErrVerificationToken
+	}
+	var verification model.EmailVerification
+	if err := json.Unmarshal(payload, &verification); err != nil {
+		return nil, model.ErrVerificationToken
+	}
+	if verification.IsExpired(time.Now()) {
+		return nil, model.ErrVerificationToken
+	}
+	return &verification, nil
+}
@@ -0,0 +1,207 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVerificationToken(t *testing.T) {
+	us := &userService{secretKey: "secret", verifyTTL: time.Hour}
+	user := &model.User{ID: "u1", Email: "u1@example.com"}
+
+	token := func(ttl time.Duration) string {
+		t.Helper()
+		signer := &userService{secretKey: "secret", verifyTTL: ttl}
+		token, err := signer.NewVerificationToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := token(time.Hour)
+	encoded, signature, _ := strings.Cut(valid, ".")
+	otherPayload, _ := json.Marshal(model.EmailVerification{UserID: "u2", Email: "u2@example.com", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	otherSecret, err := (&userService{secretKey: "other", verifyTTL: time.Hour}).NewVerificationToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		us      *userService
+		token   string
+		wantErr error
+	}{
+		{name: "valid", us: us, token: valid},
+		{name: "expired", us: us, token: token(-2 * time.Second), wantErr: model.ErrVerificationToken},
+		{name: "payload swapped", us: us, token: base64.RawURLEncoding.EncodeToString(otherPayload) + "." + signature, wantErr: model.ErrVerificationToken},
+		{name: "signature cut", us: us, token: encoded + "." + signature[:10], wantErr: model.ErrVerificationToken},
+		{name: "no signature", us: us, token: encoded, wantErr: model.ErrVerificationToken},
+		{name: "signed with another secret", us: us, token: otherSecret, wantErr: model.ErrVerificationToken},
+		{name: "no secret configured", us: &userService{}, token: valid, wantErr: model.ErrVerificationSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verification, err := tt.us.parseVerificationToken(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verification.UserID != user.ID || verification.Email != user.Email {
+				t.Errorf("verification = %+v, want the user's id and email", verification)
+			}
+		})
+	}
+}
+
+func TestEmailVerificationIsExpired(t *testing.T) {
+	expiresAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	v := model.EmailVerification{ExpiresAt: expiresAt.Unix()}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "before", now: expiresAt.Add(-time.Minute), want: false},
+		{name: "at the second it expires", now: expiresAt, want: false},
+		{name: "after", now: expiresAt.Add(time.Second), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.IsExpired(tt.now); got != tt.want {
+				t.Errorf("IsExpired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    model.User
+		wantErr   error
+		wantValid bool
+	}{
+		{name: "verifies", stored: model.User{ID: "u1", Email: "u1@example.com"}, wantValid: true},
+		{name: "email case differs", stored: model.User{ID: "u1", Email: "U1@Example.com"}, wantValid: true},
+		{name: "already verified", stored: model.User{ID: "u1", Email: "u1@example.com", EmailVerified: true}, wantErr: model.ErrEmailVerified, wantValid: true},
+		{name: "email changed since", stored: model.User{ID: "u1", Email: "new@example.com"}, wantErr: model.ErrVerificationToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepo{user: tt.stored}
+			us := &userService{userRepo: users, secretKey: "secret", verifyTTL: time.Hour}
+
+			token, err := us.NewVerificationToken(&model.User{ID: "u1", Email: "u1@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := us.VerifyEmail(context.Background(), token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if users.user.EmailVerified != tt.wantValid {
+				t.Errorf("email verified = %v, want %v", users.user.EmailVerified, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      model.User
+		email       string
+		sent        bool
+		wantPublish int
+	}{
+		{name: "sends a link", stored: model.User{ID: "u1", Email: "u1@example.com"}, email: "u1@example.com", wantPublish: 1},
+		{name: "sent too recently", stored: model.User{ID: "u1", Email: "u1@example.com"}, email: "u1@example.com", sent: true},
+		{name: "already verified", stored: model.User{ID: "u1", Email: "u1@example.com", EmailVerified: true}, email: "u1@example.com"},
+		{name: "unknown email", stored: model.User{ID: "u1", Email: "u1@example.com"}, email: "nobody@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &fakeTokenRepo{sent: tt.sent}
+			producer := &fakeProducer{}
+			us := &userService{userRepo: &fakeUserRepo{user: tt.stored}, tokenRepo: tokens, producerSvc: producer, resendInterval: time.Minute}
+
+			us.ResendVerification(context.Background(), tt.email)
+			if producer.published != tt.wantPublish {
+				t.Errorf("published %d, want %d", producer.published, tt.wantPublish)
+			}
+			if tt.wantPublish > 0 && !tokens.sent {
+				t.Error("the link was sent but not remembered for the resend interval")
+			}
+		})
+	}
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes embed the interface they stand in for, a method the tests don't
+// expect the service to call panics.
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user model.User
+}
+
+func (r *fakeUserRepo) UpdateUser(ctx context.Context, u *model.User, id string) error {
+	r.user = *u
+	return nil
+}
+
+func (r *fakeUserRepo) GetUserByID(ctx context.Context, id string, user *model.User) error {
+	if id != r.user.ID {
+		return errors.New("not found")
+	}
+	*user = r.user
+	return nil
+}
+
+func (r *fakeUserRepo) GetUserByEmail(ctx context.Context, email string, user *model.User) error {
+	if email != r.user.Email {
+		return errors.New("not found")
+	}
+	*user = r.user
+	return nil
+}
+
+type fakeTokenRepo struct {
+	repository.TokenRepository
+	sent bool
+}
+
+func (r *fakeTokenRepo) SetVerificationSent(ctx context.Context, userID string, ttl time.Duration) error {
+	r.sent = true
+	return nil
+}
+
+func (r *fakeTokenRepo) IsVerificationSent(ctx context.Context, userID string) (bool, error) {
+	return r.sent, nil
+}
+
+type fakeProducer struct {
+	published int
+}
+
+func (p *fakeProducer) Publishing(ctx context.Context, mqConf *model.MQConfig, body []byte) error {
+	p.published++
+	return nil
+}
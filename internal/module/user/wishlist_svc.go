@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrAddWishlist    = errors.New("fail to add to wishlist")
+	ErrDeleteWishlist = errors.New("fail to remove from wishlist")
+	ErrGetWishlist    = errors.New("fail to get wishlist")
+
+	ErrWishlistNotFound = errors.New("product is not in the wishlist")
+	ErrProductNotFound  = errors.New("product not found")
+)
+
+type wishlistService struct {
+	wishlistRepo repository.WishlistRepository
+	productSvc   module.ProductService
+}
+
+// ------------------------ Constructor ------------------------
+func NewWishlistService(wishlistRepo repository.WishlistRepository, productSvc module.ProductService) module.WishlistService {
+	return &wishlistService{
+		wishlistRepo: wishlistRepo,
+		productSvc:   productSvc,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Add saves a product to the user's wishlist at its current price, a product
+// already there is left as it was
+func (s *wishlistService) Add(ctx context.Context, req *model.WishlistReq, userID string) (*model.WishlistResp, error) {
+	var baseLogFields = log.Fields{
+		"user_id": userID,
+		"layer":   "wishlist_service",
+		"method":  "wishlist_add",
+	}
+
+	if err := req.Verify(); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productSvc.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	items, err := s.wishlistRepo.GetWishlistsByUserID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get wishlists by user id")
+		return nil, ErrAddWishlist
+	}
+	for _, item := range items {
+		if item.ProductID == req.ProductID {
+			return item.ToWishlistResp(product), nil
+		}
+	}
+	if len(items) >= model.WishlistMaxItems {
+		return nil, model.ErrWishlistFull
+	}
+
+	item := req.ToWishlist(userID, product.Price)
+	if err := s.wishlistRepo.AddWishlist(ctx, item); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add wishlist")
+		return nil, ErrAddWishlist
+	}
+
+	log.Info("[Service]: wishlist added success:", item.ID)
+	return item.ToWishlistResp(product), nil
+}
+
+func (s *wishlistService) Remove(ctx context.Context, productID string, userID string) error {
+	var baseLogFields = log.Fields{
+		"user_id":    userID,
+		"product_id": productID,
+		"layer":      "wishlist_service",
+		"method":     "wishlist_remove",
+	}
+
+	items, err := s.wishlistRepo.GetWishlistsByUserID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get wishlists by user id")
+		return ErrDeleteWishlist
+	}
+
+	for _, item := range items {
+		if item.ProductID != productID {
+			continue
+		}
+		if err := s.wishlistRepo.DeleteWishlist(ctx, item.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("delete wishlist")
+			return ErrDeleteWishlist
+		}
+		return nil
+	}
+	return ErrWishlistNotFound
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetByUser lists the user's wishlist at current prices, a product that is
+// gone stays listed as unavailable until it is removed
+func (s *wishlistService) GetByUser(ctx context.Context, userID string) ([]model.WishlistResp, error) {
+	items, err := s.wishlistRepo.GetWishlistsByUserID(ctx, userID)
+	if err != nil {
+		log.WithError(err).WithField("user_id", userID).Error("[Service]: get wishlists by user id")
+		return nil, ErrGetWishlist
+	}
+
+	itemsRes := make([]model.WishlistResp, 0, len(items))
+	for _, item := range items {
+		product, err := s.productSvc.GetByID(ctx, item.ProductID)
+		if err != nil {
+			product = nil
+		}
+		itemsRes = append(itemsRes, *item.ToWishlistResp(product))
+	}
+	return itemsRes, nil
+}
+
+// GetByProductID returns who has the product in their wishlist
+func (s *wishlistService) GetByProductID(ctx context.Context, productID string) ([]model.Wishlist, error) {
+	items, err := s.wishlistRepo.GetWishlistsByProductID(ctx, productID)
+	if err != nil {
+		log.WithError(err).WithField("product_id", productID).Error("[Service]: get wishlists by product id")
+		return nil, ErrGetWishlist
+	}
+	return items, nil
+}
@@ -0,0 +1,198 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/module"
+	"go-rebuild/internal/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// error
+	ErrCreateWarehouse   = errors.New("fail to create warehouse")
+	ErrUpdateWarehouse   = errors.New("fail to update warehouse")
+	ErrDeleteWarehouse   = errors.New("fail to delete warehouse")
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	ErrPermission        = errors.New("no permission to manage this warehouse")
+	ErrWarehouseDefault  = errors.New("default warehouse can't be deleted")
+	ErrWarehouseNotEmpty = errors.New("warehouse still holds stock, transfer it first")
+)
+
+type warehouseService struct {
+	warehouseRepo      repository.WarehouseRepository
+	warehouseStockRepo repository.WarehouseStockRepository
+}
+
+// ------------------------ Constructor ------------------------
+func NewWarehouseService(warehouseRepo repository.WarehouseRepository, warehouseStockRepo repository.WarehouseStockRepository) module.WarehouseService {
+	return &warehouseService{
+		warehouseRepo:      warehouseRepo,
+		warehouseStockRepo: warehouseStockRepo,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// Save creates a warehouse for the seller, the first one is always the default
+func (s *warehouseService) Save(ctx context.Context, req *model.WarehouseReq, sellerID string) (*model.Warehouse, error) {
+	var baseLogFields = log.Fields{
+		"seller_id": sellerID,
+		"layer":     "warehouse_service",
+		"method":    "warehouse_save",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	warehouses, err := s.warehouseRepo.GetWarehousesBySeller(ctx, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouses by seller")
+		return nil, ErrCreateWarehouse
+	}
+
+	warehouse := req.ToWarehouse(sellerID)
+	if len(warehouses) == 0 {
+		warehouse.IsDefault = true
+	}
+
+	if err := s.warehouseRepo.AddWarehouse(ctx, warehouse); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("add warehouse")
+		return nil, ErrCreateWarehouse
+	}
+
+	if warehouse.IsDefault {
+		s.unsetDefault(ctx, warehouses, warehouse.ID)
+	}
+	return warehouse, nil
+}
+
+func (s *warehouseService) Update(ctx context.Context, req *model.WarehouseReq, id string, sellerID string) (*model.Warehouse, error) {
+	var baseLogFields = log.Fields{
+		"warehouse_id": id,
+		"seller_id":    sellerID,
+		"layer":        "warehouse_service",
+		"method":       "warehouse_update",
+	}
+
+	if err := req.Verify(); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("verify")
+		return nil, err
+	}
+
+	warehouse, err := s.ownWarehouse(ctx, id, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse")
+		return nil, err
+	}
+
+	wasDefault := warehouse.IsDefault
+	warehouse.Apply(req)
+	if err := s.warehouseRepo.UpdateWarehouse(ctx, warehouse, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("update warehouse")
+		return nil, ErrUpdateWarehouse
+	}
+
+	if warehouse.IsDefault && !wasDefault {
+		warehouses, err := s.warehouseRepo.GetWarehousesBySeller(ctx, sellerID)
+		if err != nil {
+			log.WithError(err).WithFields(baseLogFields).Warn("get warehouses by seller")
+			return warehouse, nil
+		}
+		s.unsetDefault(ctx, warehouses, warehouse.ID)
+	}
+	return warehouse, nil
+}
+
+// Delete removes an empty warehouse that is not the seller's default
+func (s *warehouseService) Delete(ctx context.Context, id string, sellerID string) error {
+	var baseLogFields = log.Fields{
+		"warehouse_id": id,
+		"seller_id":    sellerID,
+		"layer":        "warehouse_service",
+		"method":       "warehouse_delete",
+	}
+
+	warehouse, err := s.ownWarehouse(ctx, id, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse")
+		return err
+	}
+
+	if warehouse.IsDefault {
+		return ErrWarehouseDefault
+	}
+
+	warehouseStocks, err := s.warehouseStockRepo.GetWarehouseStocksByWarehouseID(ctx, id)
+	if err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("get warehouse stocks")
+		return ErrDeleteWarehouse
+	}
+
+	for _, warehouseStock := range warehouseStocks {
+		if warehouseStock.Quantity > 0 {
+			return ErrWarehouseNotEmpty
+		}
+	}
+
+	for _, warehouseStock := range warehouseStocks {
+		if err := s.warehouseStockRepo.DeleteWarehouseStock(ctx, warehouseStock.ID); err != nil {
+			log.WithError(err).WithFields(baseLogFields).Error("delete warehouse stock")
+			return ErrDeleteWarehouse
+		}
+	}
+
+	if err := s.warehouseRepo.DeleteWarehouse(ctx, id); err != nil {
+		log.WithError(err).WithFields(baseLogFields).Error("delete warehouse")
+		return ErrDeleteWarehouse
+	}
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (s *warehouseService) GetBySeller(ctx context.Context, sellerID string) ([]model.Warehouse, error) {
+	warehouses, err := s.warehouseRepo.GetWarehousesBySeller(ctx, sellerID)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"seller_id": sellerID,
+			"layer":     "warehouse_service",
+			"method":    "warehouse_getBySeller",
+		}).Error("get warehouses by seller")
+		return nil, ErrWarehouseNotFound
+	}
+	return warehouses, nil
+}
+
+// ------------------------ Private Method ------------------------
+func (s *warehouseService) ownWarehouse(ctx context.Context, id string, sellerID string) (*model.Warehouse, error) {
+	var warehouse model.Warehouse
+	if err := s.warehouseRepo.GetWarehouseByID(ctx, id, &warehouse); err != nil {
+		return nil, ErrWarehouseNotFound
+	}
+
+	if warehouse.SellerID != sellerID {
+		return nil, ErrPermission
+	}
+	return &warehouse, nil
+}
+
+// unsetDefault keeps a single default warehouse per seller
+func (s *warehouseService) unsetDefault(ctx context.Context, warehouses []model.Warehouse, defaultID string) {
+	for _, warehouse := range warehouses {
+		if warehouse.ID == defaultID || !warehouse.IsDefault {
+			continue
+		}
+
+		warehouse.IsDefault = false
+		if err := s.warehouseRepo.UpdateWarehouse(ctx, &warehouse, warehouse.ID); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"warehouse_id": warehouse.ID,
+				"layer":        "warehouse_service",
+				"method":       "warehouse_unsetDefault",
+			}).Warn("unset default warehouse")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"go-rebuild/internal/model"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeIntent struct {
+	amount   model.Money
+	refunded model.Money
+	status   string
+}
+
+// Fake keeps intents in memory and authorizes each one as it is created, for
+// tests, develop mode and staging, main refuses it in any other mode unless
+// PAYMENT_ALLOW_FAKE is set. Its webhooks are signed with the secret like a
+// real provider's. Err, when set, is returned instead.
+type Fake struct {
+	Err error
+
+	secret  []byte
+	mu      sync.Mutex
+	intents map[string]*fakeIntent
+}
+
+// ------------------------ Constructor ------------------------
+func NewFake(secret string) *Fake {
+	return &Fake{
+		secret:  []byte(secret),
+		intents: make(map[string]*fakeIntent),
+	}
+}
+
+// ------------------------ Public Method ------------------------
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) CreateIntent(ctx context.Context, reference string, amount model.Money) (*Intent, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := "fake_pi_" + primitive.NewObjectID().Hex()
+	f.intents[id] = &fakeIntent{
+		amount:   amount,
+		refunded: model.NewMoney(0, amount.Currency),
+		status:   IntentRequiresCapture,
+	}
+	return &Intent{ID: id, ClientSecret: id + "_secret", Status: IntentRequiresCapture}, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, intentID string) (*Intent, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, ok := f.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.status != IntentRequiresCapture {
+		return nil, ErrIntentStatus
+	}
+
+	intent.status = IntentSucceeded
+	return &Intent{ID: intentID, Status: intent.status}, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, intentID string, amount model.Money) (string, error) {
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, ok := f.intents[intentID]
+	if !ok {
+		return "", ErrIntentNotFound
+	}
+	if intent.status != IntentSucceeded {
+		return "", ErrIntentStatus
+	}
+
+	refunded, err := intent.refunded.Add(amount)
+	if err != nil {
+		return "", err
+	}
+	if refunded.Amount > intent.amount.Amount {
+		return "", model.ErrRefundAmount
+	}
+
+	intent.refunded = refunded
+	return "fake_re_" + primitive.NewObjectID().Hex(), nil
+}
+
+func (f *Fake) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if !VerifySignature(f.secret, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.IntentID == "" {
+		return nil, ErrInvalidEvent
+	}
+	return &event, nil
+}
+
+// Webhook builds a signed webhook for an intent the way the provider would
+// send it, to drive the webhook endpoint in tests
+func (f *Fake) Webhook(eventType string, intentID string, reason string) ([]byte, string, error) {
+	payload, err := json.Marshal(Event{
+		ID:       "fake_evt_" + primitive.NewObjectID().Hex(),
+		Type:     eventType,
+		IntentID: intentID,
+		Reason:   reason,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, Sign(f.secret, payload), nil
+}
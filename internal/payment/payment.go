@@ -0,0 +1,70 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-rebuild/internal/model"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidEvent     = errors.New("invalid webhook event")
+	ErrIntentNotFound   = errors.New("payment intent not found")
+	ErrIntentStatus     = errors.New("payment intent can't do this in its status")
+)
+
+// intent statuses, each provider maps its own onto these
+const (
+	IntentRequiresAction  = "REQUIRES_ACTION"  // the buyer still has to confirm with the client secret
+	IntentRequiresCapture = "REQUIRES_CAPTURE" // authorized, the money is held until captured
+	IntentSucceeded       = "SUCCEEDED"
+	IntentFailed          = "FAILED"
+)
+
+// webhook event types
+const (
+	EventSucceeded = "payment.succeeded"
+	EventFailed    = "payment.failed"
+	EventRefunded  = "payment.refunded"
+)
+
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Status       string
+}
+
+// Event is a verified webhook, ID is unique per provider
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	IntentID string `json:"intent_id"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Provider takes payments through a payment service. Reference is our id of
+// the payment, providers use it to dedupe a retried CreateIntent.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, reference string, amount model.Money) (*Intent, error)
+	Capture(ctx context.Context, intentID string) (*Intent, error)
+	Refund(ctx context.Context, intentID string, amount model.Money) (string, error)
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// Sign is the hex HMAC-SHA256 of payload, what a webhook signature header carries
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret []byte, payload []byte, signature string) bool {
+	if len(secret) == 0 || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("whsec_test")
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","intent_id":"pi_1"}`)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		payload   []byte
+		signature string
+		want      bool
+	}{
+		{name: "signed with the secret", secret: secret, payload: payload, signature: Sign(secret, payload), want: true},
+		{name: "signed with another secret", secret: secret, payload: payload, signature: Sign([]byte("other"), payload)},
+		{name: "payload changed after signing", secret: secret, payload: []byte(`{"id":"evt_1","type":"payment.refunded","intent_id":"pi_1"}`), signature: Sign(secret, payload)},
+		{name: "no signature", secret: secret, payload: payload},
+		{name: "no secret configured", payload: payload, signature: Sign(nil, payload)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.payload, tt.signature); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeParseWebhook(t *testing.T) {
+	f := NewFake("whsec_test")
+	payload, signature, err := f.Webhook(EventSucceeded, "fake_pi_1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := []byte(`{"type":"payment.succeeded","intent_id":"fake_pi_1"}`)
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		wantErr   error
+	}{
+		{name: "signed event", payload: payload, signature: signature},
+		{name: "forged signature", payload: payload, signature: Sign([]byte("guess"), payload), wantErr: ErrInvalidSignature},
+		{name: "event without id", payload: unsigned, signature: Sign([]byte("whsec_test"), unsigned), wantErr: ErrInvalidEvent},
+		{name: "not json", payload: []byte("{"), signature: Sign([]byte("whsec_test"), []byte("{")), wantErr: ErrInvalidEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := f.ParseWebhook(tt.payload, tt.signature)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Type != EventSucceeded || event.IntentID != "fake_pi_1" {
+				t.Errorf("event = %+v, want a succeeded event of fake_pi_1", event)
+			}
+		})
+	}
+}
+
+func TestFakeRefund(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake("whsec_test")
+	intent, err := f.CreateIntent(ctx, "payment_1", model.NewMoney(1000, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Refund(ctx, intent.ID, model.NewMoney(100, "USD")); !errors.Is(err, ErrIntentStatus) {
+		t.Fatalf("refund before capture: err = %v, want %v", err, ErrIntentStatus)
+	}
+	if _, err := f.Capture(ctx, intent.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// each case refunds after the ones before it
+	tests := []struct {
+		name     string
+		intentID string
+		amount   model.Money
+		wantErr  error
+	}{
+		{name: "part of it", intentID: intent.ID, amount: model.NewMoney(600, "USD")},
+		{name: "more than is left", intentID: intent.ID, amount: model.NewMoney(401, "USD"), wantErr: model.ErrRefundAmount},
+		{name: "the rest", intentID: intent.ID, amount: model.NewMoney(400, "USD")},
+		{name: "nothing left", intentID: intent.ID, amount: model.NewMoney(1, "USD"), wantErr: model.ErrRefundAmount},
+		{name: "unknown intent", intentID: "fake_pi_unknown", amount: model.NewMoney(1, "USD"), wantErr: ErrIntentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Refund(ctx, tt.intentID, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"go-rebuild/internal/model"
 	"go-rebuild/internal/module"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
@@ -36,6 +37,10 @@ func NewLiveChat(realtimeSvc Realtime, messageSvc module.MessageService, authSvc
 func (cr *LiveChat) Listen(userID string, conn *websocket.Conn) {
 	// Register user
 	authenticated := false
+	// the user signed in on this connection and when their access token
+	// runs out, a refresh_token message extends it
+	var authUserID string
+	var expiresAt time.Time
 
 	defer cr.realtimeSvc.Offline(userID, conn)
 
@@ -52,6 +57,10 @@ func (cr *LiveChat) Listen(userID string, conn *websocket.Conn) {
 				conn.WriteJSON(map[string]string{"type": "ERROR", "error": "unauthorized"})
 				continue
 			}
+			if time.Now().After(expiresAt) {
+				conn.WriteJSON(map[string]string{"type": "AUTH_EXPIRED", "error": "token expire, send refresh_token"})
+				continue
+			}
 
 			var msg model.MessageReq
 			if err := json.Unmarshal(wsPayload.Payload, &msg); err != nil {
@@ -125,6 +134,8 @@ func (cr *LiveChat) Listen(userID string, conn *websocket.Conn) {
 			if cr.authSvc.CheckAllowRoles(userID, allowedRoles) {
 				log.Info("[Websocket]: Role pass")
 				authenticated = true
+				authUserID = userID
+				expiresAt = claims.Expiry()
 				cr.realtimeSvc.Online(userID, conn)
 				continue
 			}
@@ -135,11 +146,38 @@ func (cr *LiveChat) Listen(userID string, conn *websocket.Conn) {
 			conn.Close()
 			return
 
-		// case "Refresh_token":
-		// 	if !authenticated {
-		// 		log.Error("[Chat_Refresh_Token]: err invalid format", )
-		// 		conn.WriteJSON(map[string]string{"type": "AUTH_FAIL", "error": "invalid format"})
-		// 	}
+		case "refresh_token":
+			if !authenticated {
+				log.Error("[Chat_Refresh_Token]: not authenticated")
+				conn.WriteJSON(map[string]string{"type": "AUTH_FAIL", "error": "unauthorized"})
+				continue
+			}
+
+			var payload model.RefreshReq
+			if err := json.Unmarshal(wsPayload.Payload, &payload); err != nil || payload.Verify() != nil {
+				log.Error("[Chat_Refresh_Token]: err invalid format")
+				conn.WriteJSON(map[string]string{"type": "AUTH_FAIL", "error": "invalid format"})
+				continue
+			}
+
+			// the connection stays open on a failed refresh, it only cannot send
+			// until it is authorized again
+			tokens, err := cr.authSvc.Refresh(context.Background(), payload.RefreshToken)
+			if err != nil {
+				log.Errorf("[Chat_Refresh_Token]: refresh failed %v", err)
+				conn.WriteJSON(map[string]string{"type": "AUTH_FAIL", "error": err.Error()})
+				continue
+			}
+			claims, err := cr.authSvc.VerifyToken(tokens.Token)
+			if err != nil || claims.Subject != authUserID {
+				log.Error("[Chat_Refresh_Token]: refresh token of another user")
+				conn.WriteJSON(map[string]string{"type": "AUTH_FAIL", "error": "invalid token"})
+				conn.Close()
+				return
+			}
+
+			expiresAt = claims.Expiry()
+			conn.WriteJSON(map[string]any{"type": "TOKEN_REFRESHED", "data": tokens})
 
 		default:
 			log.Warnf("unknown message type: %s", wsPayload.Type)
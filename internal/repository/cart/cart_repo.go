@@ -0,0 +1,48 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-rebuild/internal/cache"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+// cartRepo keeps carts only in the cache, a cart left alone past its ttl is gone
+type cartRepo struct {
+	cacheSvc cache.Cache
+	keyGen   *cache.KeyGenerator
+	ttl      time.Duration
+}
+
+// ------------------------ Constructor ------------------------
+func NewCartRepo(cacheSvc cache.Cache, ttl time.Duration) repository.CartRepository {
+	return &cartRepo{
+		cacheSvc: cacheSvc,
+		keyGen:   cache.NewKeyGenerator("carts"),
+		ttl:      ttl,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// SaveCart writes the cart and starts its ttl again
+func (r *cartRepo) SaveCart(ctx context.Context, owner model.CartOwner, c *model.Cart) error {
+	return r.cacheSvc.Set(ctx, r.keyGen.KeyID(owner.Key()), c, r.ttl)
+}
+
+func (r *cartRepo) DeleteCart(ctx context.Context, owner model.CartOwner) error {
+	return r.cacheSvc.Delete(ctx, r.keyGen.KeyID(owner.Key()))
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetCart loads the cart, a missing or expired one is empty
+func (r *cartRepo) GetCart(ctx context.Context, owner model.CartOwner, c *model.Cart) error {
+	err := r.cacheSvc.Get(ctx, r.keyGen.KeyID(owner.Key()), c)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		*c = model.Cart{}
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package category
+
+import (
+	"context"
+	"go-rebuild/internal/cache"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type categoryRepo struct {
+	db         dbRepo.DB
+	collection string
+	cacheSvc   cache.Cache
+	keyGen     *cache.KeyGenerator
+}
+
+// ------------------------ Constructor ------------------------
+// the whole taxonomy is read on every browse, so the list is what gets cached.
+// Any write clears it and the next read rebuilds it from the db.
+func NewCategoryRepo(db dbRepo.DB, cacheSvc cache.Cache) repository.CategoryRepository {
+	keyGen := cache.NewKeyGenerator("categories")
+	return &categoryRepo{
+		db:         db,
+		collection: "categories",
+		cacheSvc:   cacheSvc,
+		keyGen:     keyGen,
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *categoryRepo) AddCategory(ctx context.Context, c *model.Category) error {
+	if err := r.db.Create(ctx, r.collection, c); err != nil {
+		return err
+	}
+
+	r.clearCache(ctx, "AddCategory")
+	return nil
+}
+
+func (r *categoryRepo) UpdateCategory(ctx context.Context, c *model.Category, id string) error {
+	// moving a category to the root writes an empty parent_id
+	if err := r.db.UpdateByField(ctx, r.collection, c, "id", id); err != nil {
+		return err
+	}
+
+	r.clearCache(ctx, "UpdateCategory")
+	return nil
+}
+
+func (r *categoryRepo) DeleteCategory(ctx context.Context, id string) error {
+	if err := r.db.Delete(ctx, r.collection, &model.Category{}, id); err != nil {
+		return err
+	}
+
+	r.clearCache(ctx, "DeleteCategory")
+	return nil
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *categoryRepo) GetAllCategory(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	cacheKeyList := r.keyGen.KeyList()
+
+	// get categories from redis
+	if err := r.cacheSvc.Get(ctx, cacheKeyList, &categories); err == nil {
+		return categories, nil
+	}
+
+	// get categories from db
+	if err := r.db.GetAll(ctx, r.collection, &categories); err != nil {
+		return nil, err
+	}
+
+	// set cache categories in redis
+	if err := r.cacheSvc.Set(ctx, cacheKeyList, categories, 15*time.Minute); err != nil {
+		log.Warn("[Repo]: failed to set cache categories in GetAllCategory: ", err)
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepo) GetCategoryByID(ctx context.Context, id string, c *model.Category) error {
+	return r.db.GetByID(ctx, r.collection, id, c)
+}
+
+func (r *categoryRepo) GetCategoryBySlug(ctx context.Context, slug string, c *model.Category) error {
+	return r.db.GetByField(ctx, r.collection, "slug", slug, c)
+}
+
+// ------------------------ Private Method ------------------------
+func (r *categoryRepo) clearCache(ctx context.Context, method string) {
+	if err := r.cacheSvc.Delete(ctx, r.keyGen.KeyList()); err != nil {
+		log.Warnf("[Repo]: failed to clear categories cache in %s: %v", method, err)
+	}
+}
@@ -0,0 +1,47 @@
+package category
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type productCategoryRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewProductCategoryRepo(db dbRepo.DB) repository.ProductCategoryRepository {
+	return &productCategoryRepo{
+		db:         db,
+		collection: "product_categories",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *productCategoryRepo) AddProductCategory(ctx context.Context, pc *model.ProductCategory) error {
+	return r.db.Create(ctx, r.collection, pc)
+}
+
+func (r *productCategoryRepo) DeleteProductCategory(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.ProductCategory{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *productCategoryRepo) GetByProductID(ctx context.Context, productID string) ([]model.ProductCategory, error) {
+	var productCategories []model.ProductCategory
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &productCategories); err != nil {
+		return nil, err
+	}
+	return productCategories, nil
+}
+
+func (r *productCategoryRepo) GetByCategoryID(ctx context.Context, categoryID string) ([]model.ProductCategory, error) {
+	var productCategories []model.ProductCategory
+	if err := r.db.GetAllByField(ctx, r.collection, "category_id", categoryID, &productCategories); err != nil {
+		return nil, err
+	}
+	return productCategories, nil
+}
@@ -0,0 +1,43 @@
+package coupon
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type couponRedemptionRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewCouponRedemptionRepo(db dbRepo.DB) repository.CouponRedemptionRepository {
+	return &couponRedemptionRepo{
+		db:         db,
+		collection: "coupon_redemptions",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *couponRedemptionRepo) AddRedemption(ctx context.Context, cr *model.CouponRedemption) error {
+	return r.db.Create(ctx, r.collection, cr)
+}
+
+func (r *couponRedemptionRepo) DeleteRedemption(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.CouponRedemption{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *couponRedemptionRepo) GetRedemptionByOrderID(ctx context.Context, orderID string, cr *model.CouponRedemption) error {
+	return r.db.GetByField(ctx, r.collection, "order_id", orderID, cr)
+}
+
+func (r *couponRedemptionRepo) GetRedemptionsByCouponID(ctx context.Context, couponID string) ([]model.CouponRedemption, error) {
+	var redemptions []model.CouponRedemption
+	if err := r.db.GetAllByField(ctx, r.collection, "coupon_id", couponID, &redemptions); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
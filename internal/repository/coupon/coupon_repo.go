@@ -0,0 +1,73 @@
+package coupon
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type couponRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewCouponRepo(db dbRepo.DB) repository.CouponRepository {
+	return &couponRepo{
+		db:         db,
+		collection: "coupons",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *couponRepo) AddCoupon(ctx context.Context, c *model.Coupon) error {
+	return r.db.Create(ctx, r.collection, c)
+}
+
+// UpdateCoupon writes every setting, an inactive coupon or a zero limit has to
+// be saved too. The use count is left to UseCoupon and UnuseCoupon.
+func (r *couponRepo) UpdateCoupon(ctx context.Context, c *model.Coupon) error {
+	_, err := r.db.UpdateWhere(ctx, r.collection, &model.Coupon{}, map[string]any{"id": c.ID}, c.Settings())
+	return err
+}
+
+// UseCoupon takes an unlimited coupon as is, a limited one only while used is under max_uses
+func (r *couponRepo) UseCoupon(ctx context.Context, id string) (bool, error) {
+	inc := map[string]int{"used": 1}
+	used, err := r.db.IncrementWhere(ctx, r.collection, &model.Coupon{}, map[string]any{"id": id, "max_uses": 0}, inc)
+	if err != nil || used {
+		return used, err
+	}
+	return r.db.IncrementWhere(ctx, r.collection, &model.Coupon{}, map[string]any{"id": id}, inc,
+		dbRepo.Guard{Field: "max_uses", Minus: "used", AtLeast: 1},
+	)
+}
+
+func (r *couponRepo) UnuseCoupon(ctx context.Context, id string) error {
+	_, err := r.db.IncrementWhere(ctx, r.collection, &model.Coupon{}, map[string]any{"id": id}, map[string]int{"used": -1},
+		dbRepo.Guard{Field: "used", AtLeast: 1},
+	)
+	return err
+}
+
+func (r *couponRepo) DeleteCoupon(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.Coupon{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *couponRepo) GetAllCoupon(ctx context.Context) ([]model.Coupon, error) {
+	var coupons []model.Coupon
+	if err := r.db.GetAll(ctx, r.collection, &coupons); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (r *couponRepo) GetCouponByID(ctx context.Context, id string, c *model.Coupon) error {
+	return r.db.GetByID(ctx, r.collection, id, c)
+}
+
+func (r *couponRepo) GetCouponByCode(ctx context.Context, code string, c *model.Coupon) error {
+	return r.db.GetByField(ctx, r.collection, "code", code, c)
+}
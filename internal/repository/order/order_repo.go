@@ -67,6 +67,27 @@ func (r *orderRepo) UpdateOrder(ctx context.Context, o *model.Order, id string)
 	return nil
 }
 
+func (r *orderRepo) SetOrderStatus(ctx context.Context, id string, from string, to string) (bool, error) {
+	changed, err := r.db.UpdateWhere(ctx, r.collection, &model.Order{},
+		map[string]any{"id": id, "status": from},
+		map[string]any{"status": to, "updated_at": time.Now()},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// the cached order may hold the old status, the next read loads it from db
+	cacheKeyID := r.keyGen.KeyID(id)
+	if err := r.cacheSvc.Delete(ctx, cacheKeyID); err != nil {
+		log.Warn("[Repo]: failed to clear cache order in SetOrderStatus: ", err)
+	}
+	if err := r.cacheSvc.Delete(ctx, r.keyGen.KeyList()); err != nil {
+		log.Warn("[Repo]: failed to clear cache orders in SetOrderStatus: ", err)
+	}
+
+	return changed, nil
+}
+
 func (r *orderRepo) DeleteOrder(ctx context.Context, id string) error {
 	// delete order in db
 	if err := r.db.Delete(ctx, r.collection, &model.Order{}, id); err != nil {
@@ -134,3 +155,25 @@ func (r *orderRepo) GetOrderByID(ctx context.Context, id string, order *model.Or
 
 	return nil
 }
+
+// GetOrderPageByUser returns the newest orders of the buyer first, an empty
+// status returns every status. Pages are not cached.
+func (r *orderRepo) GetOrderPageByUser(ctx context.Context, userID string, status string, page model.Page) ([]model.Order, int64, error) {
+	filter := map[string]any{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	var orders []model.Order
+	total, err := r.db.GetPage(ctx, r.collection, filter, page, &orders)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// GetOrderPageBySeller returns the newest orders of the seller's products
+// first, an empty status returns every status. Pages are not cached.
+func (r *orderRepo) GetOrderPageBySeller(ctx context.Context, sellerID string, status string, page model.Page) ([]model.Order, int64, error) {
+	return r.db.FindOrdersBySeller(ctx, sellerID, status, page)
+}
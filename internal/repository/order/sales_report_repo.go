@@ -0,0 +1,33 @@
+package order
+
+import (
+	"context"
+
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+// salesReportRepo aggregates orders in the database, Postgres with SQL and
+// Mongo with aggregation pipelines, so no order is loaded to be counted
+type salesReportRepo struct {
+	db dbRepo.DB
+}
+
+// ------------------------ Constructor ------------------------
+func NewSalesReportRepo(db dbRepo.DB) repository.SalesReportRepository {
+	return &salesReportRepo{db: db}
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *salesReportRepo) GetSalesByStatus(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	return r.db.SalesByStatus(ctx, filter)
+}
+
+func (r *salesReportRepo) GetSalesByPeriod(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	return r.db.SalesByPeriod(ctx, filter)
+}
+
+func (r *salesReportRepo) GetSalesByProduct(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error) {
+	return r.db.SalesByProduct(ctx, filter)
+}
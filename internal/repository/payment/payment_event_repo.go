@@ -0,0 +1,31 @@
+package payment
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type paymentEventRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewPaymentEventRepo(db dbRepo.DB) repository.PaymentEventRepository {
+	return &paymentEventRepo{
+		db:         db,
+		collection: "payment_events",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *paymentEventRepo) AddEvent(ctx context.Context, e *model.PaymentEvent) error {
+	return r.db.Create(ctx, r.collection, e)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *paymentEventRepo) GetEventByEventID(ctx context.Context, eventID string, e *model.PaymentEvent) error {
+	return r.db.GetByField(ctx, r.collection, "event_id", eventID, e)
+}
@@ -0,0 +1,48 @@
+package payment
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type paymentRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewPaymentRepo(db dbRepo.DB) repository.PaymentRepository {
+	return &paymentRepo{
+		db:         db,
+		collection: "payments",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *paymentRepo) AddPayment(ctx context.Context, p *model.Payment) error {
+	return r.db.Create(ctx, r.collection, p)
+}
+
+// UpdatePayment writes every field, a cleared failure reason has to be saved too
+func (r *paymentRepo) UpdatePayment(ctx context.Context, p *model.Payment) error {
+	return r.db.UpdateByField(ctx, r.collection, p, "id", p.ID)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *paymentRepo) GetPaymentByID(ctx context.Context, id string, p *model.Payment) error {
+	return r.db.GetByID(ctx, r.collection, id, p)
+}
+
+func (r *paymentRepo) GetPaymentByProviderRef(ctx context.Context, providerRef string, p *model.Payment) error {
+	return r.db.GetByField(ctx, r.collection, "provider_ref", providerRef, p)
+}
+
+func (r *paymentRepo) GetPaymentsByOrderID(ctx context.Context, orderID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	if err := r.db.GetAllByField(ctx, r.collection, "order_id", orderID, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,70 @@
+package product
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"sort"
+	"time"
+)
+
+type priceChangeRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewPriceChangeRepo(db dbRepo.DB) repository.PriceChangeRepository {
+	return &priceChangeRepo{
+		db:         db,
+		collection: "price_changes",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *priceChangeRepo) AddPriceChange(ctx context.Context, pc *model.PriceChange) error {
+	return r.db.Create(ctx, r.collection, pc)
+}
+
+func (r *priceChangeRepo) UpdatePriceChange(ctx context.Context, pc *model.PriceChange) error {
+	return r.db.UpdateByField(ctx, r.collection, pc, "id", pc.ID)
+}
+
+func (r *priceChangeRepo) SetPriceChangeStatus(ctx context.Context, id string, from string, to string) (bool, error) {
+	return r.db.UpdateWhere(ctx, r.collection, &model.PriceChange{},
+		map[string]any{"id": id, "status": from},
+		map[string]any{"status": to, "updated_at": time.Now()},
+	)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *priceChangeRepo) GetPriceChangeByID(ctx context.Context, id string, pc *model.PriceChange) error {
+	return r.db.GetByID(ctx, r.collection, id, pc)
+}
+
+// GetPriceChangesByProductID returns the history of a product, latest effective first
+func (r *priceChangeRepo) GetPriceChangesByProductID(ctx context.Context, productID string) ([]model.PriceChange, error) {
+	var changes []model.PriceChange
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &changes); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].EffectiveAt.After(changes[b].EffectiveAt)
+	})
+	return changes, nil
+}
+
+// GetPriceChangesByStatus returns the changes oldest effective first, the order they apply in
+func (r *priceChangeRepo) GetPriceChangesByStatus(ctx context.Context, status string) ([]model.PriceChange, error) {
+	var changes []model.PriceChange
+	if err := r.db.GetAllByField(ctx, r.collection, "status", status, &changes); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].EffectiveAt.Before(changes[b].EffectiveAt)
+	})
+	return changes, nil
+}
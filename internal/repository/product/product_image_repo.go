@@ -0,0 +1,49 @@
+package product
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"sort"
+)
+
+type productImageRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewProductImageRepo(db dbRepo.DB) repository.ProductImageRepository {
+	return &productImageRepo{
+		db:         db,
+		collection: "product_images",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *productImageRepo) AddImage(ctx context.Context, i *model.ProductImage) error {
+	return r.db.Create(ctx, r.collection, i)
+}
+
+func (r *productImageRepo) DeleteImage(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.ProductImage{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *productImageRepo) GetImageByID(ctx context.Context, id string, i *model.ProductImage) error {
+	return r.db.GetByID(ctx, r.collection, id, i)
+}
+
+// GetImagesByProductID returns the images in upload order
+func (r *productImageRepo) GetImagesByProductID(ctx context.Context, productID string) ([]model.ProductImage, error) {
+	var images []model.ProductImage
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &images); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(images, func(a, b int) bool {
+		return images[a].CreatedAt.Before(images[b].CreatedAt)
+	})
+	return images, nil
+}
@@ -139,3 +139,11 @@ func (r *productRepo) GetProductByID(ctx context.Context, id string, product *mo
 
 	return nil
 }
+
+func (r *productRepo) GetProductsBySeller(ctx context.Context, sellerID string) ([]model.Product, error) {
+	var products []model.Product
+	if err := r.db.GetAllByField(ctx, r.collection, "created_by", sellerID, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
@@ -0,0 +1,48 @@
+package product
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type productVariantRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewProductVariantRepo(db dbRepo.DB) repository.ProductVariantRepository {
+	return &productVariantRepo{
+		db:         db,
+		collection: "product_variants",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *productVariantRepo) AddVariant(ctx context.Context, v *model.ProductVariant) error {
+	return r.db.Create(ctx, r.collection, v)
+}
+
+func (r *productVariantRepo) UpdateVariant(ctx context.Context, v *model.ProductVariant, id string) error {
+	// a cleared price override has to be written as null
+	return r.db.UpdateByField(ctx, r.collection, v, "id", id)
+}
+
+func (r *productVariantRepo) DeleteVariant(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.ProductVariant{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *productVariantRepo) GetVariantByID(ctx context.Context, id string, v *model.ProductVariant) error {
+	return r.db.GetByID(ctx, r.collection, id, v)
+}
+
+func (r *productVariantRepo) GetVariantsByProductID(ctx context.Context, productID string) ([]model.ProductVariant, error) {
+	var variants []model.ProductVariant
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"go-rebuild/internal/model"
+	"time"
 )
 
 type MessageRepository interface {
@@ -18,19 +19,77 @@ type StockRepository interface {
 	AddStock(ctx context.Context, s *model.Stock) error
 	UpdateStock(ctx context.Context, s *model.Stock) error
 	DeleteStock(ctx context.Context, id string) error
+	// MoveStock adds to quantity and reserved in one guarded write, it fails with
+	// ErrDebtStock instead of leaving more reserved than on hand
+	MoveStock(ctx context.Context, productID string, quantity int, reserved int) error
+	SetReorderThreshold(ctx context.Context, productID string, threshold int) error
 
 	GetStockByProductID(ctx context.Context, productID string, stock *model.Stock) error
 	GetAllStock(ctx context.Context) ([]model.Stock, error)
 	GetStockByID(ctx context.Context, productID string, s *model.Stock) error
 }
 
+type StockMovementRepository interface {
+	AddMovement(ctx context.Context, m *model.StockMovement) error
+
+	GetMovementsByProductID(ctx context.Context, productID string) ([]model.StockMovement, error)
+}
+
+type StockSubscriptionRepository interface {
+	AddSubscription(ctx context.Context, sub *model.StockSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+	// SetSubscriptionNotified marks the alert of a subscription sent, nil re-arms it
+	SetSubscriptionNotified(ctx context.Context, id string, notifiedAt *time.Time) error
+
+	GetSubscriptionsByProductID(ctx context.Context, productID string) ([]model.StockSubscription, error)
+}
+
+type StockReservationRepository interface {
+	AddReservation(ctx context.Context, r *model.StockReservation) error
+	UpdateReservation(ctx context.Context, r *model.StockReservation, id string) error
+	// CloseReservation moves an ACTIVE reservation to status, false when it was already closed
+	CloseReservation(ctx context.Context, id string, status string) (bool, error)
+
+	GetReservationByOrderID(ctx context.Context, orderID string, r *model.StockReservation) error
+	GetReservationsByStatus(ctx context.Context, status string) ([]model.StockReservation, error)
+}
+
+type WarehouseStockRepository interface {
+	AddWarehouseStock(ctx context.Context, ws *model.WarehouseStock) error
+	MoveWarehouseStock(ctx context.Context, id string, quantity int, reserved int) error
+	DeleteWarehouseStock(ctx context.Context, id string) error
+
+	GetWarehouseStocksByProductID(ctx context.Context, productID string) ([]model.WarehouseStock, error)
+	GetWarehouseStocksByWarehouseID(ctx context.Context, warehouseID string) ([]model.WarehouseStock, error)
+}
+
+type WarehouseRepository interface {
+	AddWarehouse(ctx context.Context, w *model.Warehouse) error
+	UpdateWarehouse(ctx context.Context, w *model.Warehouse, id string) error
+	DeleteWarehouse(ctx context.Context, id string) error
+
+	GetWarehouseByID(ctx context.Context, id string, w *model.Warehouse) error
+	GetWarehousesBySeller(ctx context.Context, sellerID string) ([]model.Warehouse, error)
+}
+
 type OrderRepository interface {
 	AddOrder(ctx context.Context, o *model.Order) error
 	UpdateOrder(ctx context.Context, o *model.Order, id string) error
+	// SetOrderStatus moves an order in status from to status to, false when it
+	// was no longer in from
+	SetOrderStatus(ctx context.Context, id string, from string, to string) (bool, error)
 	DeleteOrder(ctx context.Context, id string) error
 
 	GetAllOrder(ctx context.Context) ([]model.Order, error)
 	GetOrderByID(ctx context.Context, id string, order *model.Order) error
+	GetOrderPageByUser(ctx context.Context, userID string, status string, page model.Page) ([]model.Order, int64, error)
+	GetOrderPageBySeller(ctx context.Context, sellerID string, status string, page model.Page) ([]model.Order, int64, error)
+}
+
+type SalesReportRepository interface {
+	GetSalesByStatus(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
+	GetSalesByPeriod(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
+	GetSalesByProduct(ctx context.Context, filter *model.SalesFilter) ([]model.SalesRow, error)
 }
 
 type ProductRepository interface {
@@ -40,6 +99,54 @@ type ProductRepository interface {
 
 	GetAllProduct(ctx context.Context) ([]model.Product, error)
 	GetProductByID(ctx context.Context, id string, p *model.Product) error
+	GetProductsBySeller(ctx context.Context, sellerID string) ([]model.Product, error)
+}
+
+type ProductVariantRepository interface {
+	AddVariant(ctx context.Context, v *model.ProductVariant) error
+	UpdateVariant(ctx context.Context, v *model.ProductVariant, id string) error
+	DeleteVariant(ctx context.Context, id string) error
+
+	GetVariantByID(ctx context.Context, id string, v *model.ProductVariant) error
+	GetVariantsByProductID(ctx context.Context, productID string) ([]model.ProductVariant, error)
+}
+
+type ProductImageRepository interface {
+	AddImage(ctx context.Context, i *model.ProductImage) error
+	DeleteImage(ctx context.Context, id string) error
+
+	GetImageByID(ctx context.Context, id string, i *model.ProductImage) error
+	GetImagesByProductID(ctx context.Context, productID string) ([]model.ProductImage, error)
+}
+
+type PriceChangeRepository interface {
+	AddPriceChange(ctx context.Context, pc *model.PriceChange) error
+	UpdatePriceChange(ctx context.Context, pc *model.PriceChange) error
+	// SetPriceChangeStatus moves a change from one status to another, false when
+	// it was no longer in from
+	SetPriceChangeStatus(ctx context.Context, id string, from string, to string) (bool, error)
+
+	GetPriceChangeByID(ctx context.Context, id string, pc *model.PriceChange) error
+	GetPriceChangesByProductID(ctx context.Context, productID string) ([]model.PriceChange, error)
+	GetPriceChangesByStatus(ctx context.Context, status string) ([]model.PriceChange, error)
+}
+
+type CategoryRepository interface {
+	AddCategory(ctx context.Context, c *model.Category) error
+	UpdateCategory(ctx context.Context, c *model.Category, id string) error
+	DeleteCategory(ctx context.Context, id string) error
+
+	GetAllCategory(ctx context.Context) ([]model.Category, error)
+	GetCategoryByID(ctx context.Context, id string, c *model.Category) error
+	GetCategoryBySlug(ctx context.Context, slug string, c *model.Category) error
+}
+
+type ProductCategoryRepository interface {
+	AddProductCategory(ctx context.Context, pc *model.ProductCategory) error
+	DeleteProductCategory(ctx context.Context, id string) error
+
+	GetByProductID(ctx context.Context, productID string) ([]model.ProductCategory, error)
+	GetByCategoryID(ctx context.Context, categoryID string) ([]model.ProductCategory, error)
 }
 
 type UserRepository interface {
@@ -51,3 +158,128 @@ type UserRepository interface {
 	GetUserByID(ctx context.Context, id string, user *model.User) error
 	GetUserByEmail(ctx context.Context, email string, user *model.User) error
 }
+
+type ReviewRepository interface {
+	AddReview(ctx context.Context, r *model.Review) error
+	UpdateReview(ctx context.Context, r *model.Review, id string) error
+	DeleteReview(ctx context.Context, id string) error
+
+	GetReviewByID(ctx context.Context, id string, r *model.Review) error
+	GetReviewsByProductID(ctx context.Context, productID string) ([]model.Review, error)
+	GetReviewPage(ctx context.Context, productID string, status string, page model.Page) ([]model.Review, int64, error)
+}
+
+type ProductRatingRepository interface {
+	SaveRating(ctx context.Context, r *model.ProductRating) error
+
+	GetRatingByProductID(ctx context.Context, productID string, r *model.ProductRating) error
+}
+
+type CouponRepository interface {
+	AddCoupon(ctx context.Context, c *model.Coupon) error
+	UpdateCoupon(ctx context.Context, c *model.Coupon) error
+	DeleteCoupon(ctx context.Context, id string) error
+	// UseCoupon counts one use unless the coupon reached max_uses, false then
+	UseCoupon(ctx context.Context, id string) (bool, error)
+	UnuseCoupon(ctx context.Context, id string) error
+
+	GetAllCoupon(ctx context.Context) ([]model.Coupon, error)
+	GetCouponByID(ctx context.Context, id string, c *model.Coupon) error
+	GetCouponByCode(ctx context.Context, code string, c *model.Coupon) error
+}
+
+type CouponRedemptionRepository interface {
+	AddRedemption(ctx context.Context, cr *model.CouponRedemption) error
+	DeleteRedemption(ctx context.Context, id string) error
+
+	GetRedemptionByOrderID(ctx context.Context, orderID string, cr *model.CouponRedemption) error
+	GetRedemptionsByCouponID(ctx context.Context, couponID string) ([]model.CouponRedemption, error)
+}
+
+type TaxRuleRepository interface {
+	AddTaxRule(ctx context.Context, r *model.TaxRule) error
+	UpdateTaxRule(ctx context.Context, r *model.TaxRule) error
+	DeleteTaxRule(ctx context.Context, id string) error
+
+	GetAllTaxRule(ctx context.Context) ([]model.TaxRule, error)
+	GetTaxRuleByID(ctx context.Context, id string, r *model.TaxRule) error
+}
+
+type PaymentRepository interface {
+	AddPayment(ctx context.Context, p *model.Payment) error
+	UpdatePayment(ctx context.Context, p *model.Payment) error
+
+	GetPaymentByID(ctx context.Context, id string, p *model.Payment) error
+	GetPaymentByProviderRef(ctx context.Context, providerRef string, p *model.Payment) error
+	GetPaymentsByOrderID(ctx context.Context, orderID string) ([]model.Payment, error)
+}
+
+type PaymentEventRepository interface {
+	AddEvent(ctx context.Context, e *model.PaymentEvent) error
+
+	GetEventByEventID(ctx context.Context, eventID string, e *model.PaymentEvent) error
+}
+
+type ReturnRepository interface {
+	AddReturn(ctx context.Context, r *model.Return) error
+	UpdateReturn(ctx context.Context, r *model.Return) error
+	// SetReturnStatus moves a return from one status to another, false when
+	// it was no longer in from
+	SetReturnStatus(ctx context.Context, id string, from string, to string) (bool, error)
+
+	GetReturnByID(ctx context.Context, id string, r *model.Return) error
+	GetReturnsByOrderID(ctx context.Context, orderID string) ([]model.Return, error)
+	GetReturnsBySellerID(ctx context.Context, sellerID string) ([]model.Return, error)
+}
+
+type AddressRepository interface {
+	AddAddress(ctx context.Context, a *model.Address) error
+	UpdateAddress(ctx context.Context, a *model.Address) error
+	DeleteAddress(ctx context.Context, id string) error
+
+	GetAddressByID(ctx context.Context, id string, a *model.Address) error
+	GetAddressesByUserID(ctx context.Context, userID string) ([]model.Address, error)
+}
+
+type ShipmentRepository interface {
+	AddShipment(ctx context.Context, s *model.Shipment) error
+	UpdateShipment(ctx context.Context, s *model.Shipment) error
+
+	GetShipmentByOrderID(ctx context.Context, orderID string, s *model.Shipment) error
+}
+
+type TokenRepository interface {
+	SaveSession(ctx context.Context, session *model.Session, ttl time.Duration) error
+	// RotateSession saves the session only while it still holds the token hash
+	// from, false when another refresh rotated it first or it is gone
+	RotateSession(ctx context.Context, session *model.Session, from string, ttl time.Duration) (bool, error)
+	DeleteSession(ctx context.Context, id string) error
+	RevokeToken(ctx context.Context, jti string, ttl time.Duration) error
+
+	SetVerificationSent(ctx context.Context, userID string, ttl time.Duration) error
+
+	GetSession(ctx context.Context, id string, session *model.Session) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	IsVerificationSent(ctx context.Context, userID string) (bool, error)
+}
+
+type CartRepository interface {
+	SaveCart(ctx context.Context, owner model.CartOwner, c *model.Cart) error
+	DeleteCart(ctx context.Context, owner model.CartOwner) error
+
+	GetCart(ctx context.Context, owner model.CartOwner, c *model.Cart) error
+}
+
+type WishlistRepository interface {
+	AddWishlist(ctx context.Context, w *model.Wishlist) error
+	DeleteWishlist(ctx context.Context, id string) error
+
+	GetWishlistsByUserID(ctx context.Context, userID string) ([]model.Wishlist, error)
+	GetWishlistsByProductID(ctx context.Context, productID string) ([]model.Wishlist, error)
+}
+
+// Transactor runs fn in one db transaction, repository calls made with the ctx
+// passed to fn join it
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,59 @@
+package returns
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"time"
+)
+
+type returnRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewReturnRepo(db dbRepo.DB) repository.ReturnRepository {
+	return &returnRepo{
+		db:         db,
+		collection: "returns",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *returnRepo) AddReturn(ctx context.Context, rt *model.Return) error {
+	return r.db.Create(ctx, r.collection, rt)
+}
+
+func (r *returnRepo) UpdateReturn(ctx context.Context, rt *model.Return) error {
+	return r.db.UpdateByField(ctx, r.collection, rt, "id", rt.ID)
+}
+
+func (r *returnRepo) SetReturnStatus(ctx context.Context, id string, from string, to string) (bool, error) {
+	return r.db.UpdateWhere(ctx, r.collection, &model.Return{},
+		map[string]any{"id": id, "status": from},
+		map[string]any{"status": to, "updated_at": time.Now()},
+	)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *returnRepo) GetReturnByID(ctx context.Context, id string, rt *model.Return) error {
+	return r.db.GetByID(ctx, r.collection, id, rt)
+}
+
+func (r *returnRepo) GetReturnsByOrderID(ctx context.Context, orderID string) ([]model.Return, error) {
+	var returns []model.Return
+	if err := r.db.GetAllByField(ctx, r.collection, "order_id", orderID, &returns); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+func (r *returnRepo) GetReturnsBySellerID(ctx context.Context, sellerID string) ([]model.Return, error) {
+	var returns []model.Return
+	if err := r.db.GetAllByField(ctx, r.collection, "seller_id", sellerID, &returns); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
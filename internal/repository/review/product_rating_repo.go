@@ -0,0 +1,36 @@
+package review
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type productRatingRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewProductRatingRepo(db dbRepo.DB) repository.ProductRatingRepository {
+	return &productRatingRepo{
+		db:         db,
+		collection: "product_ratings",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// SaveRating creates the rating of a product on its first review and overwrites it after
+func (r *productRatingRepo) SaveRating(ctx context.Context, rating *model.ProductRating) error {
+	var current model.ProductRating
+	if err := r.db.GetByField(ctx, r.collection, "product_id", rating.ProductID, &current); err != nil {
+		return r.db.Create(ctx, r.collection, rating)
+	}
+	return r.db.UpdateByField(ctx, r.collection, rating, "product_id", rating.ProductID)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *productRatingRepo) GetRatingByProductID(ctx context.Context, productID string, rating *model.ProductRating) error {
+	return r.db.GetByField(ctx, r.collection, "product_id", productID, rating)
+}
@@ -0,0 +1,62 @@
+package review
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type reviewRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewReviewRepo(db dbRepo.DB) repository.ReviewRepository {
+	return &reviewRepo{
+		db:         db,
+		collection: "reviews",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *reviewRepo) AddReview(ctx context.Context, review *model.Review) error {
+	return r.db.Create(ctx, r.collection, review)
+}
+
+func (r *reviewRepo) UpdateReview(ctx context.Context, review *model.Review, id string) error {
+	return r.db.Update(ctx, r.collection, review, id)
+}
+
+func (r *reviewRepo) DeleteReview(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.Review{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *reviewRepo) GetReviewByID(ctx context.Context, id string, review *model.Review) error {
+	return r.db.GetByID(ctx, r.collection, id, review)
+}
+
+func (r *reviewRepo) GetReviewsByProductID(ctx context.Context, productID string) ([]model.Review, error) {
+	var reviews []model.Review
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// GetReviewPage returns the newest reviews of a product first, an empty status returns every status
+func (r *reviewRepo) GetReviewPage(ctx context.Context, productID string, status string, page model.Page) ([]model.Review, int64, error) {
+	filter := map[string]any{"product_id": productID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	var reviews []model.Review
+	total, err := r.db.GetPage(ctx, r.collection, filter, page, &reviews)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
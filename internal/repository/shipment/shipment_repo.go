@@ -0,0 +1,35 @@
+package shipment
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type shipmentRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewShipmentRepo(db dbRepo.DB) repository.ShipmentRepository {
+	return &shipmentRepo{
+		db:         db,
+		collection: "shipments",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *shipmentRepo) AddShipment(ctx context.Context, s *model.Shipment) error {
+	return r.db.Create(ctx, r.collection, s)
+}
+
+func (r *shipmentRepo) UpdateShipment(ctx context.Context, s *model.Shipment) error {
+	return r.db.UpdateByField(ctx, r.collection, s, "id", s.ID)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *shipmentRepo) GetShipmentByOrderID(ctx context.Context, orderID string, s *model.Shipment) error {
+	return r.db.GetByField(ctx, r.collection, "order_id", orderID, s)
+}
@@ -0,0 +1,41 @@
+package stock
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"sort"
+)
+
+type stockMovementRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+// the ledger is append-only and read rarely, so it skips the cache
+func NewStockMovementRepo(db dbRepo.DB) repository.StockMovementRepository {
+	return &stockMovementRepo{
+		db:         db,
+		collection: "stock_movements",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *stockMovementRepo) AddMovement(ctx context.Context, m *model.StockMovement) error {
+	return r.db.Create(ctx, r.collection, m)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *stockMovementRepo) GetMovementsByProductID(ctx context.Context, productID string) ([]model.StockMovement, error) {
+	var movements []model.StockMovement
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &movements); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].CreatedAt.Before(movements[j].CreatedAt)
+	})
+	return movements, nil
+}
@@ -53,7 +53,7 @@ func (r *StockRepo) AddStock(ctx context.Context, s *model.Stock) error {
 
 func (r *StockRepo) UpdateStock(ctx context.Context, s *model.Stock) error {
 	var currentStock model.Stock
-	if err := r.db.GetByID(ctx, r.collection, s.ProductID, &currentStock); err != nil {
+	if err := r.db.GetByField(ctx, r.collection, "product_id", s.ProductID, &currentStock); err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"product_id": s.ProductID,
 			"layer":      "repository",
@@ -69,7 +69,7 @@ func (r *StockRepo) UpdateStock(ctx context.Context, s *model.Stock) error {
 	}
 
 	// update stock data in db
-	if err := r.db.Update(ctx, r.collection, s, s.ProductID); err != nil {
+	if err := r.db.UpdateByField(ctx, r.collection, s, "product_id", s.ProductID); err != nil {
 		return err
 	}
 
@@ -89,8 +89,8 @@ func (r *StockRepo) UpdateStock(ctx context.Context, s *model.Stock) error {
 }
 
 func (r *StockRepo) DeleteStock(ctx context.Context, id string) error {
-	// delete data from db
-	if err := r.db.Delete(ctx, r.collection, &model.Stock{}, id); err != nil {
+	// delete data from db, stocks are keyed by product_id
+	if err := r.db.DeleteByField(ctx, r.collection, &model.Stock{}, "product_id", id); err != nil {
 		return err
 	}
 
@@ -109,6 +109,50 @@ func (r *StockRepo) DeleteStock(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *StockRepo) MoveStock(ctx context.Context, productID string, quantity int, reserved int) error {
+	moved, err := r.db.IncrementWhere(ctx, r.collection, &model.Stock{},
+		map[string]any{"product_id": productID},
+		map[string]int{"quantity": quantity, "reserved": reserved},
+		stockGuards(quantity, reserved)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	// the cached copy no longer matches, the next read loads it from db
+	cacheKeyProductID := r.keyGen.KeyField("product_id", productID)
+	if err := r.cacheSvc.Delete(ctx, cacheKeyProductID); err != nil {
+		log.Warn("[Repo]: failed to clear stock cacheKeyProductID in MoveStock: ", err)
+	}
+	if err := r.cacheSvc.Delete(ctx, r.keyGen.KeyList()); err != nil {
+		log.Warn("[Repo]: failed to clear stock cachelist in MoveStock: ", err)
+	}
+
+	if !moved {
+		return model.ErrDebtStock
+	}
+	return nil
+}
+
+func (r *StockRepo) SetReorderThreshold(ctx context.Context, productID string, threshold int) error {
+	// only the threshold column, quantity and reserved move through MoveStock
+	if _, err := r.db.UpdateWhere(ctx, r.collection, &model.Stock{},
+		map[string]any{"product_id": productID},
+		map[string]any{"reorder_threshold": threshold, "updated_at": time.Now()},
+	); err != nil {
+		return err
+	}
+
+	cacheKeyProductID := r.keyGen.KeyField("product_id", productID)
+	if err := r.cacheSvc.Delete(ctx, cacheKeyProductID); err != nil {
+		log.Warn("[Repo]: failed to clear stock cacheKeyProductID in SetReorderThreshold: ", err)
+	}
+	if err := r.cacheSvc.Delete(ctx, r.keyGen.KeyList()); err != nil {
+		log.Warn("[Repo]: failed to clear stock cachelist in SetReorderThreshold: ", err)
+	}
+	return nil
+}
+
 // ------------------------ Method Basic Query ------------------------
 func (r *StockRepo) GetStockByProductID(ctx context.Context, productID string, stock *model.Stock) error {
 	// get stock from redis
@@ -146,3 +190,12 @@ func (r *StockRepo) GetStockByID(ctx context.Context, productID string, stock *m
 	}
 	return nil
 }
+
+// ------------------------ Private Method ------------------------
+// stockGuards keep reserved within what is on hand after a move
+func stockGuards(quantity int, reserved int) []dbRepo.Guard {
+	return []dbRepo.Guard{
+		{Field: "quantity", Minus: "reserved", AtLeast: reserved - quantity},
+		{Field: "reserved", AtLeast: -reserved},
+	}
+}
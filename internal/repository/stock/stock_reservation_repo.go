@@ -0,0 +1,52 @@
+package stock
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"time"
+)
+
+type stockReservationRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+// reservations change state on every order and are swept often, so they skip the cache
+func NewStockReservationRepo(db dbRepo.DB) repository.StockReservationRepository {
+	return &stockReservationRepo{
+		db:         db,
+		collection: "stock_reservations",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *stockReservationRepo) AddReservation(ctx context.Context, res *model.StockReservation) error {
+	return r.db.Create(ctx, r.collection, res)
+}
+
+func (r *stockReservationRepo) UpdateReservation(ctx context.Context, res *model.StockReservation, id string) error {
+	return r.db.Update(ctx, r.collection, res, id)
+}
+
+func (r *stockReservationRepo) CloseReservation(ctx context.Context, id string, status string) (bool, error) {
+	return r.db.UpdateWhere(ctx, r.collection, &model.StockReservation{},
+		map[string]any{"id": id, "status": model.ReservationActive},
+		map[string]any{"status": status, "updated_at": time.Now()},
+	)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *stockReservationRepo) GetReservationByOrderID(ctx context.Context, orderID string, res *model.StockReservation) error {
+	return r.db.GetByField(ctx, r.collection, "order_id", orderID, res)
+}
+
+func (r *stockReservationRepo) GetReservationsByStatus(ctx context.Context, status string) ([]model.StockReservation, error) {
+	var reservations []model.StockReservation
+	if err := r.db.GetAllByField(ctx, r.collection, "status", status, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
@@ -0,0 +1,48 @@
+package stock
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"time"
+)
+
+type stockSubscriptionRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewStockSubscriptionRepo(db dbRepo.DB) repository.StockSubscriptionRepository {
+	return &stockSubscriptionRepo{
+		db:         db,
+		collection: "stock_subscriptions",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *stockSubscriptionRepo) AddSubscription(ctx context.Context, sub *model.StockSubscription) error {
+	return r.db.Create(ctx, r.collection, sub)
+}
+
+func (r *stockSubscriptionRepo) DeleteSubscription(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.StockSubscription{}, id)
+}
+
+func (r *stockSubscriptionRepo) SetSubscriptionNotified(ctx context.Context, id string, notifiedAt *time.Time) error {
+	_, err := r.db.UpdateWhere(ctx, r.collection, &model.StockSubscription{},
+		map[string]any{"id": id},
+		map[string]any{"notified_at": notifiedAt},
+	)
+	return err
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *stockSubscriptionRepo) GetSubscriptionsByProductID(ctx context.Context, productID string) ([]model.StockSubscription, error) {
+	var subs []model.StockSubscription
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
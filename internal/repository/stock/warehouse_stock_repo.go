@@ -0,0 +1,64 @@
+package stock
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type warehouseStockRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+// warehouse rows are written together with the stock totals, so they skip the
+// cache to never serve a split that disagrees with them
+func NewWarehouseStockRepo(db dbRepo.DB) repository.WarehouseStockRepository {
+	return &warehouseStockRepo{
+		db:         db,
+		collection: "warehouse_stocks",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *warehouseStockRepo) AddWarehouseStock(ctx context.Context, ws *model.WarehouseStock) error {
+	return r.db.Create(ctx, r.collection, ws)
+}
+
+func (r *warehouseStockRepo) MoveWarehouseStock(ctx context.Context, id string, quantity int, reserved int) error {
+	moved, err := r.db.IncrementWhere(ctx, r.collection, &model.WarehouseStock{},
+		map[string]any{"id": id},
+		map[string]int{"quantity": quantity, "reserved": reserved},
+		stockGuards(quantity, reserved)...,
+	)
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return model.ErrDebtStock
+	}
+	return nil
+}
+
+func (r *warehouseStockRepo) DeleteWarehouseStock(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.WarehouseStock{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *warehouseStockRepo) GetWarehouseStocksByProductID(ctx context.Context, productID string) ([]model.WarehouseStock, error) {
+	var stocks []model.WarehouseStock
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &stocks); err != nil {
+		return nil, err
+	}
+	return stocks, nil
+}
+
+func (r *warehouseStockRepo) GetWarehouseStocksByWarehouseID(ctx context.Context, warehouseID string) ([]model.WarehouseStock, error) {
+	var stocks []model.WarehouseStock
+	if err := r.db.GetAllByField(ctx, r.collection, "warehouse_id", warehouseID, &stocks); err != nil {
+		return nil, err
+	}
+	return stocks, nil
+}
@@ -0,0 +1,48 @@
+package tax
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type taxRuleRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewTaxRuleRepo(db dbRepo.DB) repository.TaxRuleRepository {
+	return &taxRuleRepo{
+		db:         db,
+		collection: "tax_rules",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *taxRuleRepo) AddTaxRule(ctx context.Context, rule *model.TaxRule) error {
+	return r.db.Create(ctx, r.collection, rule)
+}
+
+// UpdateTaxRule writes every field, a zero rate or an empty region has to be saved too
+func (r *taxRuleRepo) UpdateTaxRule(ctx context.Context, rule *model.TaxRule) error {
+	return r.db.UpdateByField(ctx, r.collection, rule, "id", rule.ID)
+}
+
+func (r *taxRuleRepo) DeleteTaxRule(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.TaxRule{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *taxRuleRepo) GetAllTaxRule(ctx context.Context) ([]model.TaxRule, error) {
+	var rules []model.TaxRule
+	if err := r.db.GetAll(ctx, r.collection, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *taxRuleRepo) GetTaxRuleByID(ctx context.Context, id string, rule *model.TaxRule) error {
+	return r.db.GetByID(ctx, r.collection, id, rule)
+}
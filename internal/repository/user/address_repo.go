@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type addressRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewAddressRepo(db dbRepo.DB) repository.AddressRepository {
+	return &addressRepo{
+		db:         db,
+		collection: "addresses",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *addressRepo) AddAddress(ctx context.Context, a *model.Address) error {
+	return r.db.Create(ctx, r.collection, a)
+}
+
+// UpdateAddress writes every field, an address that is no longer the default has to be saved too
+func (r *addressRepo) UpdateAddress(ctx context.Context, a *model.Address) error {
+	return r.db.UpdateByField(ctx, r.collection, a, "id", a.ID)
+}
+
+func (r *addressRepo) DeleteAddress(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.Address{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *addressRepo) GetAddressByID(ctx context.Context, id string, a *model.Address) error {
+	return r.db.GetByID(ctx, r.collection, id, a)
+}
+
+func (r *addressRepo) GetAddressesByUserID(ctx context.Context, userID string) ([]model.Address, error) {
+	var addresses []model.Address
+	if err := r.db.GetAllByField(ctx, r.collection, "user_id", userID, &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-rebuild/internal/cache"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+// tokenRepo keeps sessions and revoked access tokens in the cache only, both
+// expire on their own once the token they stand for would have
+type tokenRepo struct {
+	cacheSvc        cache.Cache
+	sessionKey      *cache.KeyGenerator
+	revokedKey      *cache.KeyGenerator
+	verificationKey *cache.KeyGenerator
+}
+
+// ------------------------ Constructor ------------------------
+func NewTokenRepo(cacheSvc cache.Cache) repository.TokenRepository {
+	return &tokenRepo{
+		cacheSvc:        cacheSvc,
+		sessionKey:      cache.NewKeyGenerator("sessions"),
+		revokedKey:      cache.NewKeyGenerator("revoked_tokens"),
+		verificationKey: cache.NewKeyGenerator("verification_sent"),
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+// SaveSession writes the session and starts its ttl again
+func (r *tokenRepo) SaveSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	return r.cacheSvc.Set(ctx, r.sessionKey.KeyID(session.ID), session, ttl)
+}
+
+func (r *tokenRepo) RotateSession(ctx context.Context, session *model.Session, from string, ttl time.Duration) (bool, error) {
+	return r.cacheSvc.SetIf(ctx, r.sessionKey.KeyID(session.ID), "token_hash", from, session, ttl)
+}
+
+func (r *tokenRepo) DeleteSession(ctx context.Context, id string) error {
+	return r.cacheSvc.Delete(ctx, r.sessionKey.KeyID(id))
+}
+
+// RevokeToken denies the access token jti until it would have expired
+func (r *tokenRepo) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return r.cacheSvc.Set(ctx, r.revokedKey.KeyID(jti), true, ttl)
+}
+
+// SetVerificationSent holds off another verification email to the user for ttl
+func (r *tokenRepo) SetVerificationSent(ctx context.Context, userID string, ttl time.Duration) error {
+	return r.cacheSvc.Set(ctx, r.verificationKey.KeyID(userID), true, ttl)
+}
+
+// ------------------------ Method Basic Query ------------------------
+// GetSession loads a session, a missing or expired one is model.ErrRefreshToken
+func (r *tokenRepo) GetSession(ctx context.Context, id string, session *model.Session) error {
+	err := r.cacheSvc.Get(ctx, r.sessionKey.KeyID(id), session)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return model.ErrRefreshToken
+	}
+	return err
+}
+
+func (r *tokenRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.cacheSvc.Get(ctx, r.revokedKey.KeyID(jti), &revoked)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return false, nil
+	}
+	return revoked, err
+}
+
+func (r *tokenRepo) IsVerificationSent(ctx context.Context, userID string) (bool, error) {
+	var sent bool
+	err := r.cacheSvc.Get(ctx, r.verificationKey.KeyID(userID), &sent)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return false, nil
+	}
+	return sent, err
+}
@@ -62,8 +62,8 @@ func (r *userRepo) UpdateUser(ctx context.Context, u *model.User, id string) err
 		return err
 	}
 
-	// update user data in db
-	if err := r.db.Update(ctx, r.collection, u, id); err != nil {
+	// every field is written, an email verified flag reset to false has to be saved too
+	if err := r.db.UpdateByField(ctx, r.collection, u, "id", id); err != nil {
 		return err
 	}
 
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type wishlistRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewWishlistRepo(db dbRepo.DB) repository.WishlistRepository {
+	return &wishlistRepo{
+		db:         db,
+		collection: "wishlists",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *wishlistRepo) AddWishlist(ctx context.Context, w *model.Wishlist) error {
+	return r.db.Create(ctx, r.collection, w)
+}
+
+func (r *wishlistRepo) DeleteWishlist(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.Wishlist{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *wishlistRepo) GetWishlistsByUserID(ctx context.Context, userID string) ([]model.Wishlist, error) {
+	var items []model.Wishlist
+	if err := r.db.GetAllByField(ctx, r.collection, "user_id", userID, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *wishlistRepo) GetWishlistsByProductID(ctx context.Context, productID string) ([]model.Wishlist, error) {
+	var items []model.Wishlist
+	if err := r.db.GetAllByField(ctx, r.collection, "product_id", productID, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
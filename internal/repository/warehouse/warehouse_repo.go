@@ -0,0 +1,48 @@
+package warehouse
+
+import (
+	"context"
+	dbRepo "go-rebuild/internal/db"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type warehouseRepo struct {
+	db         dbRepo.DB
+	collection string
+}
+
+// ------------------------ Constructor ------------------------
+func NewWarehouseRepo(db dbRepo.DB) repository.WarehouseRepository {
+	return &warehouseRepo{
+		db:         db,
+		collection: "warehouses",
+	}
+}
+
+// ------------------------ Method Basic CUD ------------------------
+func (r *warehouseRepo) AddWarehouse(ctx context.Context, w *model.Warehouse) error {
+	return r.db.Create(ctx, r.collection, w)
+}
+
+func (r *warehouseRepo) UpdateWarehouse(ctx context.Context, w *model.Warehouse, id string) error {
+	// is_default can be switched off, so write every column
+	return r.db.UpdateByField(ctx, r.collection, w, "id", id)
+}
+
+func (r *warehouseRepo) DeleteWarehouse(ctx context.Context, id string) error {
+	return r.db.Delete(ctx, r.collection, &model.Warehouse{}, id)
+}
+
+// ------------------------ Method Basic Query ------------------------
+func (r *warehouseRepo) GetWarehouseByID(ctx context.Context, id string, w *model.Warehouse) error {
+	return r.db.GetByID(ctx, r.collection, id, w)
+}
+
+func (r *warehouseRepo) GetWarehousesBySeller(ctx context.Context, sellerID string) ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	if err := r.db.GetAllByField(ctx, r.collection, "seller_id", sellerID, &warehouses); err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
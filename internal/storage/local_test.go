@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePutDelete(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalStorage(filepath.Join(dir, "uploads"), "http://localhost:8080/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "nested key", key: "products/p1/a.png", wantPath: "uploads/products/p1/a.png"},
+		{name: "parent dirs stay inside", key: "../../etc/passwd", wantPath: "uploads/etc/passwd"},
+		{name: "absolute key stays inside", key: "/tmp/x.png", wantPath: "uploads/tmp/x.png"},
+		{name: "empty key", key: "", wantErr: ErrObjectKey},
+		{name: "key of only dots", key: "../..", wantErr: ErrObjectKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Put(ctx, tt.key, strings.NewReader("data"), "image/png")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(dir, tt.wantPath)
+			if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+				t.Fatalf("read %s: %q, %v", tt.wantPath, data, err)
+			}
+
+			if err := s.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s still there after delete: %v", tt.wantPath, err)
+			}
+			// deleting what is gone is not an error
+			if err := s.Delete(ctx, tt.key); err != nil {
+				t.Errorf("second delete: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.URL("products/p1/a.png"); got != "http://localhost:8080/uploads/products/p1/a.png" {
+		t.Errorf("URL = %s", got)
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		wantW     int
+		wantH     int
+		wantErr   error
+	}{
+		{name: "landscape is scaled to fit", data: encodePNG(t, 640, 320), maxPixels: 1 << 20, wantW: 100, wantH: 50},
+		{name: "small image keeps its size", data: encodePNG(t, 40, 20), maxPixels: 1 << 20, wantW: 40, wantH: 20},
+		{name: "no pixel limit", data: encodePNG(t, 20, 640), wantW: 3, wantH: 100},
+		{name: "decoded size over the limit", data: encodePNG(t, 640, 320), maxPixels: 640*320 - 1, wantErr: ErrImagePixels},
+		{name: "header claims a huge image", data: pngHeader(100_000, 100_000), maxPixels: 40_000_000, wantErr: ErrImagePixels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumbnail, err := Thumbnail(tt.data, 100, tt.maxPixels)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := jpeg.DecodeConfig(bytes.NewReader(thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			if config.Width != tt.wantW || config.Height != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", config.Width, config.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailRejectsGarbage(t *testing.T) {
+	if _, err := Thumbnail([]byte("not an image"), 100, 0); err == nil {
+		t.Fatal("expected an error for bytes that are not an image")
+	}
+}
+
+func encodePNG(t *testing.T, w int, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader is the signature and IHDR chunk of a PNG, enough for DecodeConfig
+func pngHeader(w uint32, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12] = 8 // bit depth
+	ihdr[13] = 2 // truecolor
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(13))
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return buf.Bytes()
+}
@@ -0,0 +1,26 @@
+package tax
+
+import (
+	"context"
+	"go-rebuild/internal/model"
+)
+
+// Fake taxes every line at one rate without reading rules, for tests and for
+// running without a rules table. Err, when set, is returned instead.
+type Fake struct {
+	Rate      int64
+	Inclusive bool
+	Err       error
+}
+
+func (f *Fake) Calculate(ctx context.Context, region string, lines []Line) ([]model.TaxLine, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	taxLines := make([]model.TaxLine, 0, len(lines))
+	for _, line := range lines {
+		taxLines = append(taxLines, model.NewTaxLine(line.ProductID, "", "fake", f.Rate, f.Inclusive, line.Amount))
+	}
+	return taxLines, nil
+}
@@ -0,0 +1,96 @@
+package tax
+
+import (
+	"context"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+)
+
+type rulesCalculator struct {
+	ruleRepo            repository.TaxRuleRepository
+	categoryRepo        repository.CategoryRepository
+	productCategoryRepo repository.ProductCategoryRepository
+}
+
+// ------------------------ Constructor ------------------------
+// NewRulesCalculator taxes each line at the most specific rule of the rules
+// table for its region and categories. A rule on a category also covers the
+// categories below it. A line no rule matches is not taxed.
+func NewRulesCalculator(ruleRepo repository.TaxRuleRepository, categoryRepo repository.CategoryRepository, productCategoryRepo repository.ProductCategoryRepository) Calculator {
+	return &rulesCalculator{
+		ruleRepo:            ruleRepo,
+		categoryRepo:        categoryRepo,
+		productCategoryRepo: productCategoryRepo,
+	}
+}
+
+// ------------------------ Method ------------------------
+func (c *rulesCalculator) Calculate(ctx context.Context, region string, lines []Line) ([]model.TaxLine, error) {
+	rules, err := c.ruleRepo.GetAllTaxRule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := c.categoryRepo.GetAllCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	taxLines := make([]model.TaxLine, 0, len(lines))
+	for _, line := range lines {
+		categoryIDs, err := c.categoryIDs(ctx, line.ProductID, parents)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := matchRule(rules, region, categoryIDs)
+		if rule == nil {
+			taxLines = append(taxLines, model.NewTaxLine(line.ProductID, "", "", 0, false, line.Amount))
+			continue
+		}
+		taxLines = append(taxLines, rule.TaxLineOf(line.ProductID, line.Amount))
+	}
+	return taxLines, nil
+}
+
+// ------------------------ Private Method ------------------------
+// categoryIDs returns the categories of a product and every ancestor of them
+func (c *rulesCalculator) categoryIDs(ctx context.Context, productID string, parents map[string]string) (map[string]bool, error) {
+	productCategories, err := c.productCategoryRepo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	for _, pc := range productCategories {
+		// the seen check stops on a broken tree that loops
+		for id := pc.CategoryID; id != "" && !ids[id]; id = parents[id] {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// matchRule picks the most specific rule for the region and categories, the
+// first one added wins a tie
+func matchRule(rules []model.TaxRule, region string, categoryIDs map[string]bool) *model.TaxRule {
+	var best *model.TaxRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Region != "" && rule.Region != region {
+			continue
+		}
+		if rule.CategoryID != "" && !categoryIDs[rule.CategoryID] {
+			continue
+		}
+		if best == nil || rule.Specificity() > best.Specificity() ||
+			(rule.Specificity() == best.Specificity() && rule.CreatedAt.Before(best.CreatedAt)) {
+			best = rule
+		}
+	}
+	return best
+}
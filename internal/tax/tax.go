@@ -0,0 +1,17 @@
+package tax
+
+import (
+	"context"
+	"go-rebuild/internal/model"
+)
+
+// Line is one order line to tax, Amount is after discount
+type Line struct {
+	ProductID string
+	Amount    model.Money
+}
+
+// Calculator prices the tax of order lines shipped to region, one TaxLine per line
+type Calculator interface {
+	Calculate(ctx context.Context, region string, lines []Line) ([]model.TaxLine, error)
+}
@@ -0,0 +1,180 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"go-rebuild/internal/model"
+	"go-rebuild/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRulesCalculator(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rules := []model.TaxRule{
+		{ID: "vat", Name: "VAT", Rate: 700, CreatedAt: created},
+		{ID: "food", Name: "Food", CategoryID: "food", Rate: 0, CreatedAt: created},
+		{ID: "eu", Name: "EU VAT", Region: "EU", Rate: 2000, Inclusive: true, CreatedAt: created},
+		{ID: "eu_books", Name: "EU books", Region: "EU", CategoryID: "books", Rate: 500, CreatedAt: created},
+		{ID: "eu_books_late", Name: "EU books again", Region: "EU", CategoryID: "books", Rate: 900, CreatedAt: created.Add(time.Hour)},
+	}
+	categories := []model.Category{
+		{ID: "food"},
+		{ID: "fruit", ParentID: "food"},
+		{ID: "books"},
+		{ID: "loop_a", ParentID: "loop_b"},
+		{ID: "loop_b", ParentID: "loop_a"},
+	}
+	productCategories := map[string][]string{
+		"apple": {"fruit"},
+		"novel": {"books"},
+		"phone": nil,
+		"stuck": {"loop_a"},
+	}
+
+	tests := []struct {
+		name      string
+		region    string
+		productID string
+		wantRule  string
+		wantTax   int64
+	}{
+		{name: "rule for any region and category", region: "TH", productID: "phone", wantRule: "vat", wantTax: 70},
+		{name: "category rule covers its subcategories", region: "TH", productID: "apple", wantRule: "food", wantTax: 0},
+		{name: "region rule over category rule", region: "EU", productID: "apple", wantRule: "eu", wantTax: 167},
+		{name: "region and category over region, first added wins a tie", region: "EU", productID: "novel", wantRule: "eu_books", wantTax: 50},
+		{name: "category loop still matches", region: "TH", productID: "stuck", wantRule: "vat", wantTax: 70},
+	}
+
+	calc := NewRulesCalculator(
+		&fakeRuleRepo{rules: rules},
+		&fakeCategoryRepo{categories: categories},
+		&fakeProductCategoryRepo{categoryIDs: productCategories},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := calc.Calculate(context.Background(), tt.region, []Line{{ProductID: tt.productID, Amount: model.NewMoney(1000, "THB")}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 1 {
+				t.Fatalf("got %d tax lines, want 1", len(lines))
+			}
+			if lines[0].RuleID != tt.wantRule || lines[0].Tax.Amount != tt.wantTax {
+				t.Errorf("rule %s taxed %d, want rule %s taxing %d", lines[0].RuleID, lines[0].Tax.Amount, tt.wantRule, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestRulesCalculatorNoRule(t *testing.T) {
+	calc := NewRulesCalculator(&fakeRuleRepo{}, &fakeCategoryRepo{}, &fakeProductCategoryRepo{})
+
+	lines, err := calc.Calculate(context.Background(), "TH", []Line{{ProductID: "phone", Amount: model.NewMoney(1000, "THB")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || !lines[0].Tax.IsZero() || lines[0].Taxable.Amount != 1000 {
+		t.Errorf("lines = %+v, want one untaxed line of 1000", lines)
+	}
+}
+
+func TestNewTaxLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rate      int64
+		inclusive bool
+		want      int64
+	}{
+		{name: "exclusive", amount: 1000, rate: 700, want: 70},
+		{name: "exclusive rounds half up", amount: 1050, rate: 700, want: 74},
+		{name: "inclusive takes the tax out of the gross", amount: 1070, rate: 700, inclusive: true, want: 70},
+		{name: "inclusive rounds half up", amount: 1000, rate: 700, inclusive: true, want: 65},
+		{name: "inclusive at 20%", amount: 1200, rate: 2000, inclusive: true, want: 200},
+		{name: "zero rate", amount: 1000, rate: 0, inclusive: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := model.NewTaxLine("p1", "r1", "VAT", tt.rate, tt.inclusive, model.NewMoney(tt.amount, "THB"))
+			if line.Tax.Amount != tt.want || line.Tax.Currency != "THB" {
+				t.Errorf("tax = %+v, want %d THB", line.Tax, tt.want)
+			}
+			if line.Taxable.Amount != tt.amount {
+				t.Errorf("taxable = %d, want %d", line.Taxable.Amount, tt.amount)
+			}
+		})
+	}
+}
+
+func TestFake(t *testing.T) {
+	errDown := errors.New("tax service down")
+	lines := []Line{
+		{ProductID: "p1", Amount: model.NewMoney(1000, "THB")},
+		{ProductID: "p2", Amount: model.NewMoney(1070, "THB")},
+	}
+
+	tests := []struct {
+		name    string
+		fake    *Fake
+		want    []int64
+		wantErr error
+	}{
+		{name: "exclusive", fake: &Fake{Rate: 700}, want: []int64{70, 75}},
+		{name: "inclusive", fake: &Fake{Rate: 700, Inclusive: true}, want: []int64{65, 70}},
+		{name: "error", fake: &Fake{Rate: 700, Err: errDown}, wantErr: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxLines, err := tt.fake.Calculate(context.Background(), "TH", lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(taxLines) != len(tt.want) {
+				t.Fatalf("got %d tax lines, want %d", len(taxLines), len(tt.want))
+			}
+			for i, line := range taxLines {
+				if line.ProductID != lines[i].ProductID || line.Tax.Amount != tt.want[i] {
+					t.Errorf("line %d = %s taxed %d, want %s taxed %d", i, line.ProductID, line.Tax.Amount, lines[i].ProductID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// ------------------------ Fakes ------------------------
+// The fakes embed the interface they stand in for, a method the calculator
+// is not expected to call panics.
+
+type fakeRuleRepo struct {
+	repository.TaxRuleRepository
+	rules []model.TaxRule
+}
+
+func (r *fakeRuleRepo) GetAllTaxRule(ctx context.Context) ([]model.TaxRule, error) {
+	return r.rules, nil
+}
+
+type fakeCategoryRepo struct {
+	repository.CategoryRepository
+	categories []model.Category
+}
+
+func (r *fakeCategoryRepo) GetAllCategory(ctx context.Context) ([]model.Category, error) {
+	return r.categories, nil
+}
+
+type fakeProductCategoryRepo struct {
+	repository.ProductCategoryRepository
+	categoryIDs map[string][]string
+}
+
+func (r *fakeProductCategoryRepo) GetByProductID(ctx context.Context, productID string) ([]model.ProductCategory, error) {
+	var productCategories []model.ProductCategory
+	for _, id := range r.categoryIDs[productID] {
+		productCategories = append(productCategories, model.ProductCategory{ProductID: productID, CategoryID: id})
+	}
+	return productCategories, nil
+}